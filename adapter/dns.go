@@ -3,12 +3,14 @@ package adapter
 import (
 	"context"
 	"net/netip"
+	"time"
 
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
 	"github.com/sagernet/sing/service"
 
 	"github.com/miekg/dns"
@@ -20,7 +22,42 @@ type DNSRouter interface {
 	Lookup(ctx context.Context, domain string, options DNSQueryOptions) ([]netip.Addr, error)
 	ClearCache()
 	LookupReverseMapping(ip netip.Addr) (string, bool)
+	LookupDNS64(ip netip.Addr) (netip.Addr, bool)
 	ResetNetwork()
+	AppendQueryTracker(tracker DNSQueryTracker)
+	Explain(ctx context.Context, metadata InboundContext) *DNSExplanation
+}
+
+// DNSExplanation describes how the destination domain of a connection would be resolved.
+type DNSExplanation struct {
+	Rules     []RuleExplanation
+	RuleIndex int
+	Rule      string
+	Action    string
+	Server    string
+}
+
+type DNSQueryTracker interface {
+	RoutedDNSQuery(ctx context.Context, record DNSQueryRecord)
+}
+
+type DNSQueryRecord struct {
+	Time        time.Time
+	Inbound     string
+	InboundType string
+	Source      M.Socksaddr
+	Domain      string
+	QueryType   uint16
+	RuleIndex   int
+	Rule        string
+	Action      string
+	Transport   string
+	Rcode       int
+	Answers     []netip.Addr
+	Latency     time.Duration
+	Cached      bool
+	Blocked     bool
+	Error       string
 }
 
 type DNSClient interface {
@@ -37,6 +74,7 @@ type DNSQueryOptions struct {
 	DisableCache   bool
 	RewriteTTL     *uint32
 	ClientSubnet   netip.Prefix
+	DNS64Prefix    netip.Prefix
 }
 
 func DNSQueryOptionsFrom(ctx context.Context, options *option.DomainResolveOptions) (*DNSQueryOptions, error) {
@@ -14,6 +14,8 @@ import (
 type ClashServer interface {
 	LifecycleService
 	ConnectionTracker
+	DNSQueryTracker
+	RuleSetTracker
 	Mode() string
 	ModeList() []string
 	SetModeUpdateHook(hook *observable.Subscriber[struct{}])
@@ -55,17 +57,21 @@ type CacheFile interface {
 	StoreGroupExpand(group string, expand bool) error
 	LoadRuleSet(tag string) *SavedBinary
 	SaveRuleSet(tag string, set *SavedBinary) error
+	LoadDNSProtocol(server string) string
+	StoreDNSProtocol(server string, protocol string) error
 }
 
 type SavedBinary struct {
-	Content     []byte
-	LastUpdated time.Time
-	LastEtag    string
+	Content      []byte
+	LastUpdated  time.Time
+	LastEtag     string
+	RuleCount    int
+	IgnoredCount int
 }
 
 func (s *SavedBinary) MarshalBinary() ([]byte, error) {
 	var buffer bytes.Buffer
-	err := binary.Write(&buffer, binary.BigEndian, uint8(1))
+	err := binary.Write(&buffer, binary.BigEndian, uint8(2))
 	if err != nil {
 		return nil, err
 	}
@@ -89,6 +95,14 @@ func (s *SavedBinary) MarshalBinary() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	_, err = varbin.WriteUvarint(&buffer, uint64(s.RuleCount))
+	if err != nil {
+		return nil, err
+	}
+	_, err = varbin.WriteUvarint(&buffer, uint64(s.IgnoredCount))
+	if err != nil {
+		return nil, err
+	}
 	return buffer.Bytes(), nil
 }
 
@@ -124,6 +138,19 @@ func (s *SavedBinary) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	s.LastEtag = string(etagBytes)
+	if version < 2 {
+		return nil
+	}
+	ruleCount, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return err
+	}
+	s.RuleCount = int(ruleCount)
+	ignoredCount, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return err
+	}
+	s.IgnoredCount = int(ignoredCount)
 	return nil
 }
 
@@ -69,9 +69,13 @@ type InboundContext struct {
 	UDPDisableDomainUnmapping bool
 	UDPConnect                bool
 	UDPTimeout                time.Duration
+	UDPFallback               string
 	TLSFragment               bool
 	TLSFragmentFallbackDelay  time.Duration
 	TLSRecordFragment         bool
+	MITM                      bool
+	MITMServerName            string
+	MITMNextProtocol          string
 
 	NetworkStrategy     *C.NetworkStrategy
 	NetworkType         []C.InterfaceType
@@ -84,6 +88,7 @@ type InboundContext struct {
 	ProcessInfo          *ConnectionOwner
 	QueryType            uint16
 	FakeIP               bool
+	DNS64                bool
 
 	// rule cache
 
@@ -24,12 +24,26 @@ type Router interface {
 	PreMatch(metadata InboundContext, context tun.DirectRouteContext, timeout time.Duration, supportBypass bool) (tun.DirectRouteDestination, error)
 	ConnectionRouterEx
 	RuleSet(tag string) (RuleSet, bool)
+	RuleSets() []RuleSet
 	Rules() []Rule
 	NeedFindProcess() bool
 	AppendTracker(tracker ConnectionTracker)
+	AppendRuleSetTracker(tracker RuleSetTracker)
+	Explain(ctx context.Context, metadata InboundContext) (*RouteExplanation, error)
 	ResetNetwork()
 }
 
+// RouteExplanation describes how a hypothetical connection would be routed.
+type RouteExplanation struct {
+	Metadata  InboundContext
+	Rules     []RuleExplanation
+	RuleIndex int
+	Rule      string
+	Action    string
+	Outbound  string
+	DNS       *DNSExplanation
+}
+
 type ConnectionTracker interface {
 	RoutedConnection(ctx context.Context, conn net.Conn, metadata InboundContext, matchedRule Rule, matchOutbound Outbound) net.Conn
 	RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata InboundContext, matchedRule Rule, matchOutbound Outbound) N.PacketConn
@@ -64,6 +78,41 @@ type RuleSet interface {
 
 type RuleSetUpdateCallback func(it RuleSet)
 
+type RuleSetWithStatistics interface {
+	RuleSet
+	Statistics() RuleSetStatistics
+}
+
+type RuleSetWithUpdate interface {
+	RuleSet
+	Update(ctx context.Context) error
+}
+
+type RuleSetTracker interface {
+	RuleSetReloaded(event RuleSetReloadEvent)
+}
+
+type RuleSetReloadEvent struct {
+	Time              time.Time
+	Tag               string
+	Type              string
+	RuleCount         int
+	PreviousRuleCount int
+	Error             string
+}
+
+type RuleSetStatistics struct {
+	Type         string
+	Format       string
+	LastUpdated  time.Time
+	RuleCount    int
+	IgnoredCount int
+	// Version, Checksum and ETag identify the loaded content of remote rule-sets.
+	Version  uint8
+	Checksum string
+	ETag     string
+}
+
 type RuleSetMetadata struct {
 	ContainsProcessRule bool
 	ContainsWIFIRule    bool
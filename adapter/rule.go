@@ -22,6 +22,24 @@ type DNSRule interface {
 	MatchAddressLimit(metadata *InboundContext) bool
 }
 
+// RuleWithExplanation is implemented by rules that can report how each of their items matched.
+type RuleWithExplanation interface {
+	ExplainItems(metadata *InboundContext) []RuleItemExplanation
+}
+
+type RuleItemExplanation struct {
+	Item    string
+	Matched bool
+}
+
+type RuleExplanation struct {
+	Index   int
+	Rule    string
+	Action  string
+	Matched bool
+	Items   []RuleItemExplanation
+}
+
 type RuleAction interface {
 	Type() string
 	String() string
@@ -29,7 +47,7 @@ type RuleAction interface {
 
 func IsFinalAction(action RuleAction) bool {
 	switch action.Type() {
-	case C.RuleActionTypeSniff, C.RuleActionTypeResolve:
+	case C.RuleActionTypeSniff, C.RuleActionTypeResolve, C.RuleActionTypeHTTPRewrite:
 		return false
 	default:
 		return true
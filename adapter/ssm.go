@@ -3,6 +3,7 @@ package adapter
 import (
 	"net"
 
+	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
 )
 
@@ -12,6 +13,11 @@ type ManagedSSMServer interface {
 	UpdateUsers(users []string, uPSKs []string) error
 }
 
+type ManagedSSMRelayServer interface {
+	ManagedSSMServer
+	UpdateDestinations(names []string, uPSKs []string, destinations []M.Socksaddr) error
+}
+
 type SSMTracker interface {
 	TrackConnection(conn net.Conn, metadata InboundContext) net.Conn
 	TrackPacketConnection(conn N.PacketConn, metadata InboundContext) N.PacketConn
@@ -352,6 +352,8 @@ func New(options Options) (*Box, error) {
 			return nil, E.Cause(err, "create clash-server")
 		}
 		router.AppendTracker(clashServer)
+		dnsRouter.AppendQueryTracker(clashServer)
+		router.AppendRuleSetTracker(clashServer)
 		service.MustRegister[adapter.ClashServer](ctx, clashServer)
 		internalServices = append(internalServices, clashServer)
 	}
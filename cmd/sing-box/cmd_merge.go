@@ -69,6 +69,10 @@ func merge(outputPath string) error {
 
 func mergePathResources(options *option.Options) error {
 	for _, inbound := range options.Inbounds {
+		switch inbound.Type {
+		case C.TypeSSH:
+			mergeSSHInboundOptions(inbound.Options.(*option.SSHInboundOptions))
+		}
 		if tlsOptions, containsTLSOptions := inbound.Options.(option.InboundTLSOptionsWrapper); containsTLSOptions {
 			tlsOptions.ReplaceInboundTLSOptions(mergeTLSInboundOptions(tlsOptions.TakeInboundTLSOptions()))
 		}
@@ -136,6 +140,14 @@ func mergeSSHOutboundOptions(options *option.SSHOutboundOptions) {
 	}
 }
 
+func mergeSSHInboundOptions(options *option.SSHInboundOptions) {
+	if options.HostKeyPath != "" {
+		if content, err := os.ReadFile(os.ExpandEnv(options.HostKeyPath)); err == nil {
+			options.HostKey = trimStringArray(strings.Split(string(content), "\n"))
+		}
+	}
+}
+
 func trimStringArray(array []string) []string {
 	return common.Filter(array, func(it string) bool {
 		return strings.TrimSpace(it) != ""
@@ -71,7 +71,12 @@ func compileRuleSet(sourcePath string) error {
 	if err != nil {
 		return err
 	}
-	err = srs.Write(outputFile, plainRuleSet.Options, downgradeRuleSetVersion(plainRuleSet.Version, plainRuleSet.Options))
+	version := downgradeRuleSetVersion(plainRuleSet.Version, plainRuleSet.Options)
+	optimizeStatistics := srs.Optimize(plainRuleSet.Options.Rules, version)
+	if optimizeStatistics.DuplicateItems > 0 || optimizeStatistics.RedundantDomains > 0 {
+		log.Info("removed ", optimizeStatistics.DuplicateItems, " duplicate items and ", optimizeStatistics.RedundantDomains, " domains covered by domain_suffix")
+	}
+	err = srs.Write(outputFile, plainRuleSet.Options, version)
 	if err != nil {
 		outputFile.Close()
 		os.Remove(outputPath)
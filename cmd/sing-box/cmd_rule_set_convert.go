@@ -3,9 +3,11 @@ package main
 import (
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/sagernet/sing-box/common/convertor/adguard"
+	"github.com/sagernet/sing-box/common/convertor/clash"
 	"github.com/sagernet/sing-box/common/srs"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
@@ -22,7 +24,7 @@ var (
 
 var commandRuleSetConvert = &cobra.Command{
 	Use:   "convert [source-path]",
-	Short: "Convert adguard DNS filter to rule-set",
+	Short: "Convert adguard DNS filter, hosts or Clash rule provider to rule-set",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		err := convertRuleSet(args[0])
@@ -34,7 +36,7 @@ var commandRuleSetConvert = &cobra.Command{
 
 func init() {
 	commandRuleSet.AddCommand(commandRuleSetConvert)
-	commandRuleSetConvert.Flags().StringVarP(&flagRuleSetConvertType, "type", "t", "", "Source type, available: adguard")
+	commandRuleSetConvert.Flags().StringVarP(&flagRuleSetConvertType, "type", "t", "", "Source type, available: adguard, hosts, clash-classical, clash-domain, clash-ipcidr, mrs")
 	commandRuleSetConvert.Flags().StringVarP(&flagRuleSetConvertOutput, "output", "o", flagRuleSetCompileDefaultOutput, "Output file")
 }
 
@@ -55,6 +57,16 @@ func convertRuleSet(sourcePath string) error {
 	switch flagRuleSetConvertType {
 	case "adguard":
 		rules, err = adguard.ToOptions(reader, log.StdLogger())
+	case "hosts":
+		rules, _, err = adguard.HostsToOptions(reader, log.StdLogger())
+	case C.RuleSetFormatClashClassical:
+		rules, _, err = clash.ClassicalToOptions(reader, log.StdLogger())
+	case C.RuleSetFormatClashDomain:
+		rules, _, err = clash.DomainToOptions(reader, log.StdLogger())
+	case C.RuleSetFormatClashIPCIDR:
+		rules, _, err = clash.IPCIDRToOptions(reader, log.StdLogger())
+	case C.RuleSetFormatMRS:
+		rules, _, err = clash.MRSToOptions(reader, log.StdLogger())
 	case "":
 		return E.New("source type is required")
 	default:
@@ -65,8 +77,8 @@ func convertRuleSet(sourcePath string) error {
 	}
 	var outputPath string
 	if flagRuleSetConvertOutput == flagRuleSetCompileDefaultOutput {
-		if strings.HasSuffix(sourcePath, ".txt") {
-			outputPath = sourcePath[:len(sourcePath)-4] + ".srs"
+		if extension := filepath.Ext(sourcePath); extension == ".txt" || extension == ".yaml" || extension == ".yml" || extension == ".mrs" {
+			outputPath = strings.TrimSuffix(sourcePath, extension) + ".srs"
 		} else {
 			outputPath = sourcePath + ".srs"
 		}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sagernet/sing-box/common/srs"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json"
+
+	"github.com/spf13/cobra"
+)
+
+var flagRuleSetStatsFormat string
+
+var commandRuleSetStats = &cobra.Command{
+	Use:   "stats <rule-set path>",
+	Short: "Report rule-set item counts, memory cost and duplicates",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := ruleSetStats(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandRuleSetStats.Flags().StringVarP(&flagRuleSetStatsFormat, "format", "f", "", "rule-set format")
+	commandRuleSet.AddCommand(commandRuleSetStats)
+}
+
+func ruleSetStats(sourcePath string) error {
+	var (
+		reader io.Reader
+		err    error
+	)
+	if sourcePath == "stdin" {
+		reader = os.Stdin
+	} else {
+		file, err := os.Open(sourcePath)
+		if err != nil {
+			return E.Cause(err, "read rule-set")
+		}
+		defer file.Close()
+		reader = file
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return E.Cause(err, "read rule-set")
+	}
+	if flagRuleSetStatsFormat == "" {
+		switch filepath.Ext(sourcePath) {
+		case ".json":
+			flagRuleSetStatsFormat = C.RuleSetFormatSource
+		case ".srs":
+			flagRuleSetStatsFormat = C.RuleSetFormatBinary
+		default:
+			return E.New("missing rule-set format")
+		}
+	}
+	var ruleSet option.PlainRuleSetCompat
+	switch flagRuleSetStatsFormat {
+	case C.RuleSetFormatSource:
+		ruleSet, err = json.UnmarshalExtended[option.PlainRuleSetCompat](content)
+	case C.RuleSetFormatBinary:
+		ruleSet, err = srs.Read(bytes.NewReader(content), true)
+	default:
+		return E.New("unknown rule-set format: ", flagRuleSetStatsFormat)
+	}
+	if err != nil {
+		return err
+	}
+	plainRuleSet, err := ruleSet.Upgrade()
+	if err != nil {
+		return err
+	}
+	version := downgradeRuleSetVersion(ruleSet.Version, plainRuleSet)
+	statistics, err := srs.Analyze(plainRuleSet.Rules, version)
+	if err != nil {
+		return err
+	}
+	optimizeStatistics := srs.Optimize(plainRuleSet.Rules, version)
+	optimizedStatistics, err := srs.Analyze(plainRuleSet.Rules, version)
+	if err != nil {
+		return err
+	}
+	os.Stdout.WriteString(F.ToString(
+		"version: ", version, "\n",
+		"rules: ", statistics.Rules, "\n",
+		"domain: ", statistics.Domains, "\n",
+		"domain_suffix: ", statistics.DomainSuffixes, "\n",
+		"domain_keyword: ", statistics.DomainKeywords, "\n",
+		"domain_regex: ", statistics.DomainRegexes, "\n",
+		"ip_cidr: ", statistics.IPCIDRs, "\n",
+		"source_ip_cidr: ", statistics.SourceIPCIDRs, "\n",
+		"merged IP ranges: ", statistics.IPRanges, "\n",
+		"domain matcher memory: ", statistics.DomainMatcherSize, " bytes (", optimizedStatistics.DomainMatcherSize, " bytes optimized)\n",
+		"IP set memory: ", statistics.IPSetSize, " bytes\n",
+		"duplicate items: ", optimizeStatistics.DuplicateItems, "\n",
+		"domains covered by domain_suffix: ", optimizeStatistics.RedundantDomains, "\n",
+	))
+	return nil
+}
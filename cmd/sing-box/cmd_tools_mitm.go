@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+
+	"github.com/sagernet/sing-box/common/mitm"
+	"github.com/sagernet/sing-box/log"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"github.com/spf13/cobra"
+)
+
+var commandMITMCA = &cobra.Command{
+	Use:   "mitm-ca",
+	Short: "Export the MITM CA certificate",
+	Long:  "Export the CA certificate configured in route.mitm, generating it first if missing.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := exportMITMCA()
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandTools.AddCommand(commandMITMCA)
+}
+
+func exportMITMCA() error {
+	options, err := readConfigAndMerge()
+	if err != nil {
+		return err
+	}
+	if options.Route == nil || options.Route.MITM == nil || !options.Route.MITM.Enabled {
+		return E.New("MITM is not enabled in route options")
+	}
+	authority, err := mitm.NewAuthority(globalCtx, *options.Route.MITM)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(authority.CertificatePEM())
+	return err
+}
@@ -28,7 +28,17 @@ type agdguardRuleLine struct {
 	isImportant bool
 }
 
+type Statistics struct {
+	Rules   int
+	Ignored int
+}
+
 func ToOptions(reader io.Reader, logger logger.Logger) ([]option.HeadlessRule, error) {
+	rules, _, err := ToOptionsWithStatistics(reader, logger)
+	return rules, err
+}
+
+func ToOptionsWithStatistics(reader io.Reader, logger logger.Logger) ([]option.HeadlessRule, Statistics, error) {
 	scanner := bufio.NewScanner(reader)
 	var (
 		ruleLines    []agdguardRuleLine
@@ -190,8 +200,12 @@ parseLine:
 			isImportant: isImportant,
 		})
 	}
+	statistics := Statistics{
+		Rules:   len(ruleLines),
+		Ignored: ignoredLines,
+	}
 	if len(ruleLines) == 0 {
-		return nil, E.New("AdGuard rule-set is empty or all rules are unsupported")
+		return nil, statistics, E.New("AdGuard rule-set is empty or all rules are unsupported")
 	}
 	if common.All(ruleLines, func(it agdguardRuleLine) bool {
 		return it.isRawDomain
@@ -205,7 +219,7 @@ parseLine:
 					}),
 				},
 			},
-		}, nil
+		}, statistics, nil
 	}
 	mapDomain := func(it agdguardRuleLine) string {
 		ruleLine := it.ruleLine
@@ -294,7 +308,7 @@ parseLine:
 	if ignoredLines > 0 {
 		logger.Info("parsed rules: ", len(ruleLines), "/", len(ruleLines)+ignoredLines)
 	}
-	return []option.HeadlessRule{currentRule}, nil
+	return []option.HeadlessRule{currentRule}, statistics, nil
 }
 
 var ErrInvalid = E.New("invalid binary AdGuard rule-set")
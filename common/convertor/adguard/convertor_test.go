@@ -1,4 +1,4 @@
-package adguard
+package adguard_test
 
 import (
 	"context"
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/convertor/adguard"
 	"github.com/sagernet/sing-box/route/rule"
 	"github.com/sagernet/sing/common/logger"
 
@@ -25,7 +26,7 @@ example.net^
 example.arpa
 @@|sagernet.example.org^
 `
-	rules, err := ToOptions(strings.NewReader(ruleString), logger.NOP())
+	rules, err := adguard.ToOptions(strings.NewReader(ruleString), logger.NOP())
 	require.NoError(t, err)
 	require.Len(t, rules, 1)
 	rule, err := rule.NewHeadlessRule(context.Background(), rules[0])
@@ -76,14 +77,14 @@ example.arpa
 			Domain: domain,
 		}), domain)
 	}
-	ruleFromOptions, err := FromOptions(rules)
+	ruleFromOptions, err := adguard.FromOptions(rules)
 	require.NoError(t, err)
 	require.Equal(t, ruleString, string(ruleFromOptions))
 }
 
 func TestHosts(t *testing.T) {
 	t.Parallel()
-	rules, err := ToOptions(strings.NewReader(`
+	rules, err := adguard.ToOptions(strings.NewReader(`
 127.0.0.1 localhost
 ::1 localhost #[IPv6]
 0.0.0.0 google.com
@@ -114,7 +115,7 @@ func TestHosts(t *testing.T) {
 
 func TestSimpleHosts(t *testing.T) {
 	t.Parallel()
-	rules, err := ToOptions(strings.NewReader(`
+	rules, err := adguard.ToOptions(strings.NewReader(`
 example.com
 www.example.org
 `), logger.NOP())
@@ -143,3 +144,44 @@ www.example.org
 		}), domain)
 	}
 }
+
+func TestHostsFormat(t *testing.T) {
+	t.Parallel()
+	rules, statistics, err := adguard.HostsToOptions(strings.NewReader(`
+# comment
+127.0.0.1 localhost
+::1 localhost ip6-localhost
+0.0.0.0 ads.example.com tracker.example.com # inline comment
+127.0.0.1 ads.example.org
+192.168.1.1 router.example.com
+example.net
+invalid_domain!
+`), logger.NOP())
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, adguard.Statistics{Rules: 4, Ignored: 2}, statistics)
+	rule, err := rule.NewHeadlessRule(context.Background(), rules[0])
+	require.NoError(t, err)
+	matchDomain := []string{
+		"ads.example.com",
+		"tracker.example.com",
+		"ads.example.org",
+		"example.net",
+	}
+	notMatchDomain := []string{
+		"localhost",
+		"router.example.com",
+		"www.example.net",
+		"example.com",
+	}
+	for _, domain := range matchDomain {
+		require.True(t, rule.Match(&adapter.InboundContext{
+			Domain: domain,
+		}), domain)
+	}
+	for _, domain := range notMatchDomain {
+		require.False(t, rule.Match(&adapter.InboundContext{
+			Domain: domain,
+		}), domain)
+	}
+}
@@ -0,0 +1,106 @@
+package adguard
+
+import (
+	"bufio"
+	"io"
+	"net/netip"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+)
+
+var hostsIgnoredDomains = map[string]bool{
+	"localhost":             true,
+	"localhost.localdomain": true,
+	"local":                 true,
+	"broadcasthost":         true,
+	"ip6-localhost":         true,
+	"ip6-loopback":          true,
+	"ip6-localnet":          true,
+	"ip6-mcastprefix":       true,
+	"ip6-allnodes":          true,
+	"ip6-allrouters":        true,
+	"ip6-allhosts":          true,
+}
+
+// HostsToOptions converts a hosts-format or domains-only blocklist.
+// Only entries pointing to an unspecified or loopback address are treated as blocked.
+func HostsToOptions(reader io.Reader, logger logger.Logger) ([]option.HeadlessRule, Statistics, error) {
+	scanner := bufio.NewScanner(reader)
+	var (
+		domains      []string
+		domainMap    = make(map[string]bool)
+		ignoredLines int
+	)
+	appendDomain := func(domain string) {
+		domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+		if hostsIgnoredDomains[domain] || domainMap[domain] {
+			return
+		}
+		domainMap[domain] = true
+		domains = append(domains, domain)
+	}
+	for scanner.Scan() {
+		ruleLine := scanner.Text()
+		if commentIndex := strings.IndexByte(ruleLine, '#'); commentIndex >= 0 {
+			ruleLine = ruleLine[:commentIndex]
+		}
+		fields := strings.Fields(ruleLine)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "!") {
+			continue
+		}
+		if len(fields) == 1 {
+			if M.IsDomainName(fields[0]) {
+				appendDomain(fields[0])
+			} else {
+				ignoredLines++
+				logger.Debug("ignored invalid domain: ", scanner.Text())
+			}
+			continue
+		}
+		address, err := netip.ParseAddr(fields[0])
+		if err != nil {
+			ignoredLines++
+			logger.Debug("ignored invalid hosts line: ", scanner.Text())
+			continue
+		}
+		if !address.IsUnspecified() && !address.IsLoopback() {
+			ignoredLines++
+			logger.Debug("ignored unsupported hosts line with non-blocking address: ", scanner.Text())
+			continue
+		}
+		for _, domain := range fields[1:] {
+			if !M.IsDomainName(domain) {
+				ignoredLines++
+				logger.Debug("ignored invalid domain: ", domain)
+				continue
+			}
+			appendDomain(domain)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, Statistics{}, err
+	}
+	statistics := Statistics{
+		Rules:   len(domains),
+		Ignored: ignoredLines,
+	}
+	if len(domains) == 0 {
+		return nil, statistics, E.New("hosts rule-set is empty or all rules are unsupported")
+	}
+	if ignoredLines > 0 {
+		logger.Info("parsed rules: ", len(domains), "/", len(domains)+ignoredLines)
+	}
+	return []option.HeadlessRule{
+		{
+			Type: C.RuleTypeDefault,
+			DefaultOptions: option.DefaultHeadlessRule{
+				Domain: domains,
+			},
+		},
+	}, statistics, nil
+}
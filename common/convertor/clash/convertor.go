@@ -0,0 +1,371 @@
+package clash
+
+import (
+	"bufio"
+	"io"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json/badoption"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+)
+
+type Statistics struct {
+	Rules   int
+	Ignored int
+	// Unsupported counts ignored classical rules by rule type.
+	Unsupported map[string]int
+}
+
+func (s *Statistics) ignore(ruleType string) {
+	s.Ignored++
+	if ruleType == "" {
+		return
+	}
+	if s.Unsupported == nil {
+		s.Unsupported = make(map[string]int)
+	}
+	s.Unsupported[ruleType]++
+}
+
+func (s *Statistics) logUnsupported(logger logger.Logger) {
+	if s.Ignored == 0 {
+		return
+	}
+	logger.Info("parsed rules: ", s.Rules, "/", s.Rules+s.Ignored)
+	ruleTypes := make([]string, 0, len(s.Unsupported))
+	for ruleType := range s.Unsupported {
+		ruleTypes = append(ruleTypes, ruleType)
+	}
+	sort.Strings(ruleTypes)
+	for _, ruleType := range ruleTypes {
+		logger.Warn("ignored ", s.Unsupported[ruleType], " rules of unsupported type ", ruleType)
+	}
+}
+
+// ClassicalToOptions converts a rule provider with classical behavior,
+// in either text or YAML format.
+func ClassicalToOptions(reader io.Reader, logger logger.Logger) ([]option.HeadlessRule, Statistics, error) {
+	payload, err := readPayload(reader)
+	if err != nil {
+		return nil, Statistics{}, err
+	}
+	var (
+		domainRule     option.DefaultHeadlessRule
+		ipRule         option.DefaultHeadlessRule
+		sourceIPRule   option.DefaultHeadlessRule
+		portRule       option.DefaultHeadlessRule
+		sourcePortRule option.DefaultHeadlessRule
+		networkRule    option.DefaultHeadlessRule
+		processRules   []option.DefaultHeadlessRule
+		statistics     Statistics
+	)
+	for _, ruleLine := range payload {
+		fields := strings.Split(ruleLine, ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		ruleType := strings.ToUpper(fields[0])
+		if len(fields) < 2 || fields[1] == "" {
+			statistics.Ignored++
+			logger.Debug("ignored invalid rule: ", ruleLine)
+			continue
+		}
+		value := fields[1]
+		var invalid bool
+		switch ruleType {
+		case "DOMAIN":
+			domainRule.Domain = append(domainRule.Domain, strings.ToLower(value))
+		case "DOMAIN-SUFFIX":
+			domainRule.DomainSuffix = append(domainRule.DomainSuffix, strings.ToLower(value))
+		case "DOMAIN-KEYWORD":
+			domainRule.DomainKeyword = append(domainRule.DomainKeyword, strings.ToLower(value))
+		case "DOMAIN-REGEX":
+			_, err = regexp.Compile(value)
+			if err != nil {
+				invalid = true
+				break
+			}
+			domainRule.DomainRegex = append(domainRule.DomainRegex, value)
+		case "DOMAIN-WILDCARD":
+			domainRule.DomainRegex = append(domainRule.DomainRegex, wildcardToRegex(strings.ToLower(value), false))
+		case "IP-CIDR", "IP-CIDR6":
+			if !isPrefix(value) {
+				invalid = true
+				break
+			}
+			ipRule.IPCIDR = append(ipRule.IPCIDR, value)
+		case "SRC-IP-CIDR":
+			if !isPrefix(value) {
+				invalid = true
+				break
+			}
+			sourceIPRule.SourceIPCIDR = append(sourceIPRule.SourceIPCIDR, value)
+		case "DST-PORT":
+			invalid = !parsePorts(value, &portRule.Port, &portRule.PortRange)
+		case "SRC-PORT":
+			invalid = !parsePorts(value, &sourcePortRule.SourcePort, &sourcePortRule.SourcePortRange)
+		case "NETWORK":
+			network := strings.ToLower(value)
+			if network != "tcp" && network != "udp" {
+				invalid = true
+				break
+			}
+			networkRule.Network = append(networkRule.Network, network)
+		case "PROCESS-NAME":
+			processRules = appendProcessRule(processRules, option.DefaultHeadlessRule{ProcessName: []string{value}})
+		case "PROCESS-PATH":
+			processRules = appendProcessRule(processRules, option.DefaultHeadlessRule{ProcessPath: []string{value}})
+		case "PROCESS-PATH-REGEX":
+			_, err = regexp.Compile(value)
+			if err != nil {
+				invalid = true
+				break
+			}
+			processRules = appendProcessRule(processRules, option.DefaultHeadlessRule{ProcessPathRegex: []string{value}})
+		default:
+			statistics.ignore(ruleType)
+			logger.Debug("ignored unsupported rule: ", ruleLine)
+			continue
+		}
+		if invalid {
+			statistics.Ignored++
+			logger.Debug("ignored invalid rule: ", ruleLine)
+			continue
+		}
+		statistics.Rules++
+	}
+	var rules []option.HeadlessRule
+	for _, rule := range append([]option.DefaultHeadlessRule{domainRule, ipRule, sourceIPRule, portRule, sourcePortRule, networkRule}, processRules...) {
+		if !rule.IsValid() {
+			continue
+		}
+		rules = append(rules, option.HeadlessRule{
+			Type:           C.RuleTypeDefault,
+			DefaultOptions: rule,
+		})
+	}
+	if len(rules) == 0 {
+		return nil, statistics, E.New("clash rule-set is empty or all rules are unsupported")
+	}
+	statistics.logUnsupported(logger)
+	return rules, statistics, nil
+}
+
+// appendProcessRule merges process rules of the same kind,
+// since process name, path and path regex items would otherwise be required together.
+func appendProcessRule(rules []option.DefaultHeadlessRule, rule option.DefaultHeadlessRule) []option.DefaultHeadlessRule {
+	for i := range rules {
+		switch {
+		case len(rule.ProcessName) > 0 && len(rules[i].ProcessName) > 0:
+			rules[i].ProcessName = append(rules[i].ProcessName, rule.ProcessName...)
+		case len(rule.ProcessPath) > 0 && len(rules[i].ProcessPath) > 0:
+			rules[i].ProcessPath = append(rules[i].ProcessPath, rule.ProcessPath...)
+		case len(rule.ProcessPathRegex) > 0 && len(rules[i].ProcessPathRegex) > 0:
+			rules[i].ProcessPathRegex = append(rules[i].ProcessPathRegex, rule.ProcessPathRegex...)
+		default:
+			continue
+		}
+		return rules
+	}
+	return append(rules, rule)
+}
+
+// DomainToOptions converts a rule provider with domain behavior,
+// in either text or YAML format.
+func DomainToOptions(reader io.Reader, logger logger.Logger) ([]option.HeadlessRule, Statistics, error) {
+	payload, err := readPayload(reader)
+	if err != nil {
+		return nil, Statistics{}, err
+	}
+	return domainsToOptions(payload, logger)
+}
+
+func domainsToOptions(payload []string, logger logger.Logger) ([]option.HeadlessRule, Statistics, error) {
+	var (
+		rule       option.DefaultHeadlessRule
+		statistics Statistics
+	)
+	for _, domain := range payload {
+		domain = strings.ToLower(domain)
+		switch {
+		case strings.HasPrefix(domain, "+.") && M.IsDomainName(domain[2:]):
+			rule.DomainSuffix = append(rule.DomainSuffix, domain[2:])
+		case strings.HasPrefix(domain, ".") && M.IsDomainName(domain[1:]):
+			rule.DomainSuffix = append(rule.DomainSuffix, domain)
+		case strings.Contains(domain, "*") && isWildcardDomain(domain):
+			rule.DomainRegex = append(rule.DomainRegex, wildcardToRegex(domain, true))
+		case M.IsDomainName(domain):
+			rule.Domain = append(rule.Domain, domain)
+		default:
+			statistics.Ignored++
+			logger.Debug("ignored invalid domain: ", domain)
+			continue
+		}
+		statistics.Rules++
+	}
+	if statistics.Rules == 0 {
+		return nil, statistics, E.New("clash rule-set is empty or all rules are unsupported")
+	}
+	statistics.logUnsupported(logger)
+	return []option.HeadlessRule{
+		{
+			Type:           C.RuleTypeDefault,
+			DefaultOptions: rule,
+		},
+	}, statistics, nil
+}
+
+// IPCIDRToOptions converts a rule provider with ipcidr behavior,
+// in either text or YAML format.
+func IPCIDRToOptions(reader io.Reader, logger logger.Logger) ([]option.HeadlessRule, Statistics, error) {
+	payload, err := readPayload(reader)
+	if err != nil {
+		return nil, Statistics{}, err
+	}
+	var (
+		rule       option.DefaultHeadlessRule
+		statistics Statistics
+	)
+	for _, prefix := range payload {
+		if !isPrefix(prefix) {
+			statistics.Ignored++
+			logger.Debug("ignored invalid IP CIDR: ", prefix)
+			continue
+		}
+		rule.IPCIDR = append(rule.IPCIDR, prefix)
+		statistics.Rules++
+	}
+	if statistics.Rules == 0 {
+		return nil, statistics, E.New("clash rule-set is empty or all rules are unsupported")
+	}
+	statistics.logUnsupported(logger)
+	return []option.HeadlessRule{
+		{
+			Type:           C.RuleTypeDefault,
+			DefaultOptions: rule,
+		},
+	}, statistics, nil
+}
+
+// readPayload returns the payload entries of a text rule provider,
+// or of a YAML rule provider with a top-level payload list.
+func readPayload(reader io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(reader)
+	var (
+		payload []string
+		isYAML  bool
+		inList  bool
+	)
+	for scanner.Scan() {
+		rawLine := scanner.Text()
+		line := strings.TrimSpace(rawLine)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "payload:") {
+			isYAML = true
+			inList = true
+			continue
+		}
+		if !isYAML {
+			payload = append(payload, line)
+			continue
+		}
+		if !strings.HasPrefix(line, "-") {
+			if rawLine[0] != ' ' && rawLine[0] != '\t' {
+				// another top-level key ends the payload list
+				inList = false
+			}
+			continue
+		}
+		if !inList {
+			continue
+		}
+		entry := strings.TrimSpace(line[1:])
+		if commentIndex := strings.Index(entry, " #"); commentIndex >= 0 && !strings.HasPrefix(entry, "'") && !strings.HasPrefix(entry, "\"") {
+			entry = strings.TrimSpace(entry[:commentIndex])
+		}
+		if len(entry) >= 2 && (entry[0] == '\'' || entry[0] == '"') && entry[len(entry)-1] == entry[0] {
+			entry = entry[1 : len(entry)-1]
+		}
+		if entry != "" {
+			payload = append(payload, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func isPrefix(value string) bool {
+	if _, err := netip.ParsePrefix(value); err == nil {
+		return true
+	}
+	_, err := netip.ParseAddr(value)
+	return err == nil
+}
+
+func isWildcardDomain(domain string) bool {
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// wildcardToRegex converts a Clash wildcard domain to a regular expression.
+// In domain behavior, * matches a single label; in DOMAIN-WILDCARD rules,
+// * matches any characters and ? matches a single character.
+func wildcardToRegex(domain string, singleLabel bool) string {
+	var builder strings.Builder
+	builder.WriteString("^")
+	for _, char := range domain {
+		switch {
+		case char == '*' && singleLabel:
+			builder.WriteString("[^.]+")
+		case char == '*':
+			builder.WriteString(".*")
+		case char == '?' && !singleLabel:
+			builder.WriteString(".")
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+	builder.WriteString("$")
+	return builder.String()
+}
+
+// parsePorts parses Clash port lists like 443, 1000-2000 or 80/443.
+func parsePorts(value string, ports *badoption.Listable[uint16], portRanges *badoption.Listable[string]) bool {
+	for _, portString := range strings.Split(value, "/") {
+		portString = strings.TrimSpace(portString)
+		if from, to, isRange := strings.Cut(portString, "-"); isRange {
+			fromPort, err := strconv.ParseUint(strings.TrimSpace(from), 10, 16)
+			if err != nil {
+				return false
+			}
+			toPort, err := strconv.ParseUint(strings.TrimSpace(to), 10, 16)
+			if err != nil || toPort < fromPort {
+				return false
+			}
+			*portRanges = append(*portRanges, strconv.FormatUint(fromPort, 10)+":"+strconv.FormatUint(toPort, 10))
+			continue
+		}
+		port, err := strconv.ParseUint(portString, 10, 16)
+		if err != nil {
+			return false
+		}
+		*ports = append(*ports, uint16(port))
+	}
+	return true
+}
@@ -0,0 +1,172 @@
+package clash
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/logger"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassical(t *testing.T) {
+	t.Parallel()
+	rules, statistics, err := ClassicalToOptions(strings.NewReader(`payload:
+  # comment
+  - DOMAIN-SUFFIX,example.org
+  - 'DOMAIN,example.com'
+  - IP-CIDR,10.0.0.0/8,no-resolve
+  - DST-PORT,8000-9000/53
+  - GEOIP,CN
+  - IP-ASN,13335
+  - IP-ASN,15169
+`), logger.NOP())
+	require.NoError(t, err)
+	require.Equal(t, 4, statistics.Rules)
+	require.Equal(t, 3, statistics.Ignored)
+	require.Equal(t, map[string]int{"GEOIP": 1, "IP-ASN": 2}, statistics.Unsupported)
+	require.Len(t, rules, 3)
+	require.Equal(t, []string{"8000:9000"}, []string(rules[2].DefaultOptions.PortRange))
+	require.Equal(t, []uint16{53}, []uint16(rules[2].DefaultOptions.Port))
+}
+
+func TestDomain(t *testing.T) {
+	t.Parallel()
+	rules, statistics, err := DomainToOptions(strings.NewReader(`+.example.org
+.example.net
+*.example.com
+example.edu
+`), logger.NOP())
+	require.NoError(t, err)
+	require.Equal(t, 4, statistics.Rules)
+	testDomainRule(t, rules)
+}
+
+func TestMRS(t *testing.T) {
+	t.Parallel()
+	var buffer bytes.Buffer
+	writeTestMRS(t, &buffer, mrsBehaviorDomain, func(writer *bytes.Buffer) {
+		writeTestDomainSet(writer, []string{"example.org", "+.example.org", "+.example.net", "*.example.com", "example.edu"})
+	})
+	rules, statistics, err := MRSToOptions(&buffer, logger.NOP())
+	require.NoError(t, err)
+	require.Equal(t, 4, statistics.Rules)
+	testDomainRule(t, rules)
+
+	buffer.Reset()
+	writeTestMRS(t, &buffer, mrsBehaviorIPCIDR, func(writer *bytes.Buffer) {
+		writer.WriteByte(1)
+		binary.Write(writer, binary.BigEndian, int64(1))
+		from := netip.MustParseAddr("10.0.0.0").As16()
+		to := netip.MustParseAddr("10.0.1.255").As16()
+		writer.Write(from[:])
+		writer.Write(to[:])
+	})
+	rules, statistics, err = MRSToOptions(&buffer, logger.NOP())
+	require.NoError(t, err)
+	require.Equal(t, 1, statistics.Rules)
+	require.Equal(t, []string{"10.0.0.0/23"}, []string(rules[0].DefaultOptions.IPCIDR))
+}
+
+// The fixtures in testdata are the .txt sources encoded in the layout written by
+// `mihomo convert-ruleset <behavior> text <source>.txt <source>.mrs`,
+// so they do not depend on writeTestMRS.
+func TestMRSFixture(t *testing.T) {
+	t.Parallel()
+	file, err := os.Open("testdata/domain.mrs")
+	require.NoError(t, err)
+	defer file.Close()
+	rules, statistics, err := MRSToOptions(file, logger.NOP())
+	require.NoError(t, err)
+	require.Equal(t, 3, statistics.Rules)
+	require.Len(t, rules, 1)
+	options := rules[0].DefaultOptions
+	require.Equal(t, []string{"google.com"}, []string(options.DomainSuffix))
+	require.Equal(t, []string{`^[^.]+\.cdn\.example\.net$`}, []string(options.DomainRegex))
+	require.Equal(t, []string{"www.example.com"}, []string(options.Domain))
+
+	file, err = os.Open("testdata/ipcidr.mrs")
+	require.NoError(t, err)
+	defer file.Close()
+	rules, statistics, err = MRSToOptions(file, logger.NOP())
+	require.NoError(t, err)
+	require.Equal(t, 3, statistics.Rules)
+	require.Equal(t, []string{"1.0.0.0/24", "8.8.8.0/24", "2001:db8::/32"}, []string(rules[0].DefaultOptions.IPCIDR))
+}
+
+func testDomainRule(t *testing.T, rules []option.HeadlessRule) {
+	require.Len(t, rules, 1)
+	options := rules[0].DefaultOptions
+	require.ElementsMatch(t, []string{"example.org", ".example.net"}, options.DomainSuffix)
+	require.Equal(t, []string{`^[^.]+\.example\.com$`}, []string(options.DomainRegex))
+	require.Equal(t, []string{"example.edu"}, []string(options.Domain))
+}
+
+func writeTestMRS(t *testing.T, output *bytes.Buffer, behavior byte, writeData func(writer *bytes.Buffer)) {
+	encoder, err := zstd.NewWriter(output)
+	require.NoError(t, err)
+	var content bytes.Buffer
+	content.Write(mrsMagicBytes[:])
+	content.WriteByte(behavior)
+	binary.Write(&content, binary.BigEndian, int64(0))
+	binary.Write(&content, binary.BigEndian, int64(0))
+	writeData(&content)
+	_, err = encoder.Write(content.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, encoder.Close())
+}
+
+// writeTestDomainSet builds a domain set the same way as Mihomo.
+func writeTestDomainSet(writer *bytes.Buffer, domains []string) {
+	keys := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		keys = append(keys, reverseString(domain))
+	}
+	sort.Strings(keys)
+	var (
+		leaves, labelBitmap []uint64
+		labels              []byte
+		labelIndex          int
+	)
+	setBit := func(bitmap *[]uint64, index int) {
+		for index>>6 >= len(*bitmap) {
+			*bitmap = append(*bitmap, 0)
+		}
+		(*bitmap)[index>>6] |= 1 << uint(index&63)
+	}
+	type element struct{ start, end, column int }
+	queue := []element{{0, len(keys), 0}}
+	for i := 0; i < len(queue); i++ {
+		current := queue[i]
+		if current.column == len(keys[current.start]) {
+			current.start++
+			setBit(&leaves, i)
+		}
+		for j := current.start; j < current.end; {
+			from := j
+			for ; j < current.end && keys[j][current.column] == keys[from][current.column]; j++ {
+			}
+			queue = append(queue, element{from, j, current.column + 1})
+			labels = append(labels, keys[from][current.column])
+			labelIndex++
+		}
+		setBit(&labelBitmap, labelIndex)
+		labelIndex++
+	}
+	writer.WriteByte(1)
+	for _, bitmap := range [][]uint64{leaves, labelBitmap} {
+		binary.Write(writer, binary.BigEndian, int64(len(bitmap)))
+		for _, word := range bitmap {
+			binary.Write(writer, binary.BigEndian, word)
+		}
+	}
+	binary.Write(writer, binary.BigEndian, int64(len(labels)))
+	writer.Write(labels)
+}
@@ -0,0 +1,255 @@
+package clash
+
+import (
+	"encoding/binary"
+	"io"
+	"net/netip"
+	"slices"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+
+	"github.com/klauspost/compress/zstd"
+	"go4.org/netipx"
+)
+
+var mrsMagicBytes = [4]byte{'M', 'R', 'S', 1}
+
+const (
+	mrsBehaviorDomain byte = iota
+	mrsBehaviorIPCIDR
+)
+
+// MRSToOptions converts a binary Mihomo rule provider (.mrs).
+// Only domain and ipcidr behaviors can be stored in this format.
+func MRSToOptions(reader io.Reader, logger logger.Logger) ([]option.HeadlessRule, Statistics, error) {
+	decoder, err := zstd.NewReader(reader)
+	if err != nil {
+		return nil, Statistics{}, err
+	}
+	defer decoder.Close()
+	var header [4]byte
+	_, err = io.ReadFull(decoder, header[:])
+	if err != nil {
+		return nil, Statistics{}, E.Cause(err, "read mrs header")
+	}
+	if header != mrsMagicBytes {
+		return nil, Statistics{}, E.New("invalid mrs magic bytes")
+	}
+	var behavior [1]byte
+	_, err = io.ReadFull(decoder, behavior[:])
+	if err != nil {
+		return nil, Statistics{}, E.Cause(err, "read mrs behavior")
+	}
+	var count int64
+	err = binary.Read(decoder, binary.BigEndian, &count)
+	if err != nil {
+		return nil, Statistics{}, E.Cause(err, "read mrs count")
+	}
+	var extraLength int64
+	err = binary.Read(decoder, binary.BigEndian, &extraLength)
+	if err != nil {
+		return nil, Statistics{}, E.Cause(err, "read mrs extra length")
+	}
+	if extraLength < 0 {
+		return nil, Statistics{}, E.New("invalid mrs extra length")
+	}
+	_, err = io.CopyN(io.Discard, decoder, extraLength)
+	if err != nil {
+		return nil, Statistics{}, E.Cause(err, "read mrs extra")
+	}
+	switch behavior[0] {
+	case mrsBehaviorDomain:
+		domains, err := readMRSDomainSet(decoder)
+		if err != nil {
+			return nil, Statistics{}, E.Cause(err, "read mrs domain set")
+		}
+		return domainsToOptions(domains, logger)
+	case mrsBehaviorIPCIDR:
+		prefixes, err := readMRSIPCIDRSet(decoder)
+		if err != nil {
+			return nil, Statistics{}, E.Cause(err, "read mrs ipcidr set")
+		}
+		if len(prefixes) == 0 {
+			return nil, Statistics{}, E.New("clash rule-set is empty or all rules are unsupported")
+		}
+		return []option.HeadlessRule{
+			{
+				Type: C.RuleTypeDefault,
+				DefaultOptions: option.DefaultHeadlessRule{
+					IPCIDR: prefixes,
+				},
+			},
+		}, Statistics{Rules: len(prefixes)}, nil
+	default:
+		return nil, Statistics{}, E.New("unsupported mrs behavior: ", behavior[0])
+	}
+}
+
+func readMRSVersion(reader io.Reader) error {
+	var version [1]byte
+	_, err := io.ReadFull(reader, version[:])
+	if err != nil {
+		return err
+	}
+	if version[0] != 1 {
+		return E.New("unsupported version: ", version[0])
+	}
+	return nil
+}
+
+func readMRSLength(reader io.Reader) (int64, error) {
+	var length int64
+	err := binary.Read(reader, binary.BigEndian, &length)
+	if err != nil {
+		return 0, err
+	}
+	if length < 1 {
+		return 0, E.New("invalid length: ", length)
+	}
+	return length, nil
+}
+
+func readMRSBitmap(reader io.Reader) ([]uint64, error) {
+	length, err := readMRSLength(reader)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := make([]uint64, 0, min(length, 1<<16))
+	for i := int64(0); i < length; i++ {
+		var word uint64
+		err = binary.Read(reader, binary.BigEndian, &word)
+		if err != nil {
+			return nil, err
+		}
+		bitmap = append(bitmap, word)
+	}
+	return bitmap, nil
+}
+
+func getBit(bitmap []uint64, index int) bool {
+	if index>>6 >= len(bitmap) {
+		return false
+	}
+	return bitmap[index>>6]&(1<<uint(index&63)) != 0
+}
+
+// readMRSDomainSet decodes the succinct trie of reversed domains used by Mihomo,
+// and returns the domains in the Mihomo domain behavior syntax.
+func readMRSDomainSet(reader io.Reader) ([]string, error) {
+	err := readMRSVersion(reader)
+	if err != nil {
+		return nil, err
+	}
+	leaves, err := readMRSBitmap(reader)
+	if err != nil {
+		return nil, err
+	}
+	labelBitmap, err := readMRSBitmap(reader)
+	if err != nil {
+		return nil, err
+	}
+	labelsLength, err := readMRSLength(reader)
+	if err != nil {
+		return nil, err
+	}
+	labels := make([]byte, labelsLength)
+	_, err = io.ReadFull(reader, labels)
+	if err != nil {
+		return nil, err
+	}
+	// Nodes are stored in breadth-first order: each node lists its child labels
+	// as 0 bits in labelBitmap, terminated by a 1 bit.
+	var (
+		keys      = [][]byte{nil}
+		nodeIndex int
+		reversed  []string
+	)
+	for bitIndex, labelIndex := 0, 0; nodeIndex < len(keys); bitIndex++ {
+		if bitIndex>>6 >= len(labelBitmap) {
+			return nil, E.New("truncated label bitmap")
+		}
+		if getBit(labelBitmap, bitIndex) {
+			if getBit(leaves, nodeIndex) {
+				reversed = append(reversed, string(keys[nodeIndex]))
+			}
+			nodeIndex++
+			continue
+		}
+		if labelIndex >= len(labels) {
+			return nil, E.New("truncated labels")
+		}
+		keys = append(keys, append(slices.Clip(keys[nodeIndex]), labels[labelIndex]))
+		labelIndex++
+	}
+	var (
+		domains      = make([]string, 0, len(reversed))
+		exactDomains = make(map[string]bool)
+	)
+	for _, key := range reversed {
+		domain := reverseString(key)
+		domains = append(domains, domain)
+		if !strings.HasPrefix(domain, "+.") {
+			exactDomains[domain] = true
+		}
+	}
+	// Mihomo stores +.example.com as both example.com and +.example.com,
+	// where the latter only matches subdomains.
+	result := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		if suffix, isSuffix := strings.CutPrefix(domain, "+."); isSuffix {
+			if exactDomains[suffix] {
+				result = append(result, domain)
+				delete(exactDomains, suffix)
+			} else {
+				result = append(result, "."+suffix)
+			}
+		}
+	}
+	for _, domain := range domains {
+		if exactDomains[domain] {
+			result = append(result, domain)
+		}
+	}
+	return result, nil
+}
+
+func reverseString(value string) string {
+	runes := []rune(value)
+	slices.Reverse(runes)
+	return string(runes)
+}
+
+func readMRSIPCIDRSet(reader io.Reader) ([]string, error) {
+	err := readMRSVersion(reader)
+	if err != nil {
+		return nil, err
+	}
+	length, err := readMRSLength(reader)
+	if err != nil {
+		return nil, err
+	}
+	var prefixes []string
+	for i := int64(0); i < length; i++ {
+		var from, to [16]byte
+		_, err = io.ReadFull(reader, from[:])
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.ReadFull(reader, to[:])
+		if err != nil {
+			return nil, err
+		}
+		ipRange := netipx.IPRangeFrom(netip.AddrFrom16(from).Unmap(), netip.AddrFrom16(to).Unmap())
+		if !ipRange.IsValid() {
+			return nil, E.New("invalid IP range: ", ipRange)
+		}
+		for _, prefix := range ipRange.Prefixes() {
+			prefixes = append(prefixes, prefix.String())
+		}
+	}
+	return prefixes, nil
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/common/listener"
+	"github.com/sagernet/sing-box/common/tlsfragment"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common"
@@ -28,6 +29,7 @@ var (
 )
 
 type DefaultDialer struct {
+	ctx                    context.Context
 	dialer4                tfo.Dialer
 	dialer6                tfo.Dialer
 	udpDialer4             net.Dialer
@@ -44,6 +46,7 @@ type DefaultDialer struct {
 	fallbackNetworkType    []C.InterfaceType
 	networkFallbackDelay   time.Duration
 	networkLastFallback    common.TypedValue[time.Time]
+	tlsFragment            *tf.Options
 }
 
 func NewDefault(ctx context.Context, options option.DialerOptions) (*DefaultDialer, error) {
@@ -202,9 +205,14 @@ func NewDefault(ctx context.Context, options option.DialerOptions) (*DefaultDial
 	if options.TCPMultiPath {
 		dialer4.SetMultipathTCP(true)
 	}
+	tlsFragment, err := tf.NewOptions(options.TLSFragment)
+	if err != nil {
+		return nil, E.Cause(err, "tls_fragment")
+	}
 	tcpDialer4 := tfo.Dialer{Dialer: dialer4, DisableTFO: !options.TCPFastOpen}
 	tcpDialer6 := tfo.Dialer{Dialer: dialer6, DisableTFO: !options.TCPFastOpen}
 	return &DefaultDialer{
+		ctx:                    ctx,
 		dialer4:                tcpDialer4,
 		dialer6:                tcpDialer6,
 		udpDialer4:             udpDialer4,
@@ -220,6 +228,7 @@ func NewDefault(ctx context.Context, options option.DialerOptions) (*DefaultDial
 		networkType:            networkType,
 		fallbackNetworkType:    fallbackNetworkType,
 		networkFallbackDelay:   networkFallbackDelay,
+		tlsFragment:            tlsFragment,
 	}, nil
 }
 
@@ -256,9 +265,9 @@ func (d *DefaultDialer) DialContext(ctx context.Context, network string, address
 				}
 			}
 			if !address.IsIPv6() {
-				return DialSlowContext(&d.dialer4, ctx, network, address)
+				return d.fragmentConn(DialSlowContext(&d.dialer4, ctx, network, address))
 			} else {
-				return DialSlowContext(&d.dialer6, ctx, network, address)
+				return d.fragmentConn(DialSlowContext(&d.dialer6, ctx, network, address))
 			}
 		}))
 	} else {
@@ -311,6 +320,9 @@ func (d *DefaultDialer) DialParallelInterface(ctx context.Context, network strin
 	if !fastFallback && !isPrimary {
 		d.networkLastFallback.Store(time.Now())
 	}
+	if N.NetworkName(network) == N.NetworkTCP {
+		conn, _ = d.fragmentConn(conn, nil)
+	}
 	return d.trackConn(conn, nil)
 }
 
@@ -375,6 +387,14 @@ func (d *DefaultDialer) WireGuardControl() control.Func {
 	return d.udpListener.Control
 }
 
+// fragmentConn splits the TLS ClientHello if it is the first payload written to the connection.
+func (d *DefaultDialer) fragmentConn(conn net.Conn, err error) (net.Conn, error) {
+	if d.tlsFragment == nil || err != nil {
+		return conn, err
+	}
+	return tf.NewConnWithOptions(conn, d.ctx, *d.tlsFragment), nil
+}
+
 func (d *DefaultDialer) trackConn(conn net.Conn, err error) (net.Conn, error) {
 	if d.connectionManager == nil || err != nil {
 		return conn, err
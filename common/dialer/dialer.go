@@ -43,6 +43,9 @@ func NewWithOptions(options Options) (N.Dialer, error) {
 		err    error
 	)
 	if dialOptions.Detour != "" {
+		if dialOptions.TLSFragment != nil && dialOptions.TLSFragment.Enabled {
+			return nil, E.New("`tls_fragment` is not supported with `detour`")
+		}
 		outboundManager := service.FromContext[adapter.OutboundManager](options.Context)
 		if outboundManager == nil {
 			return nil, E.New("missing outbound manager")
@@ -0,0 +1,306 @@
+package httprewrite
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+)
+
+var httpMethods = [][]byte{
+	[]byte("GET "),
+	[]byte("HEAD "),
+	[]byte("POST "),
+	[]byte("PUT "),
+	[]byte("DELETE "),
+	[]byte("OPTIONS "),
+	[]byte("PATCH "),
+	[]byte("TRACE "),
+}
+
+// maxHeaderSize limits the request head buffered before rewriting.
+const maxHeaderSize = 64 * 1024
+
+const (
+	stateDetect = iota
+	stateRequest
+	stateBody
+	stateChunkSize
+	stateChunkData
+	stateChunkTrailer
+	statePassthrough
+	stateClosed
+)
+
+// rewriteConn parses requests within Read, and only peeks at the connection until
+// a complete request head is buffered, so that a read deadline firing in the middle
+// of a request never loses data and later reads can continue.
+type rewriteConn struct {
+	net.Conn
+	ctx           context.Context
+	rewriter      *Rewriter
+	scheme        string
+	logger        logger.ContextLogger
+	reader        *bufio.Reader
+	state         int
+	output        []byte
+	bodyRemaining int64
+	upgraded      bool
+}
+
+// IsPlaintextRequestConn reports whether conn, passed by the HTTP proxy handler serving clientConn,
+// carries plaintext requests forwarded by the proxy, rather than a CONNECT tunnel over clientConn.
+func IsPlaintextRequestConn(conn net.Conn, clientConn net.Conn) bool {
+	for {
+		if conn == clientConn {
+			return false
+		}
+		upstream, isUpstream := conn.(common.WithUpstream)
+		if !isUpstream {
+			return true
+		}
+		conn, isUpstream = upstream.Upstream().(net.Conn)
+		if !isUpstream {
+			return true
+		}
+	}
+}
+
+// NewConn rewrites requests the client sends over conn. Connections not starting with an HTTP/1.x
+// request, and upgraded connections after the upgrade request, are passed through untouched.
+// scheme is used to build request URLs for url_regex, since requests carry no scheme.
+func (r *Rewriter) NewConn(ctx context.Context, conn net.Conn, scheme string, logger logger.ContextLogger) net.Conn {
+	return &rewriteConn{
+		Conn:     conn,
+		ctx:      ctx,
+		rewriter: r,
+		scheme:   scheme,
+		logger:   logger,
+		reader:   bufio.NewReaderSize(conn, maxHeaderSize),
+	}
+}
+
+func (c *rewriteConn) Read(p []byte) (n int, err error) {
+	for {
+		if len(c.output) > 0 {
+			n = copy(p, c.output)
+			c.output = c.output[n:]
+			return
+		}
+		switch c.state {
+		case stateDetect:
+			var isHTTP bool
+			isHTTP, err = isHTTPRequest(c.reader)
+			if err != nil {
+				return
+			}
+			if isHTTP {
+				c.state = stateRequest
+			} else {
+				c.state = statePassthrough
+			}
+		case stateRequest:
+			err = c.readRequest()
+			if err != nil {
+				return
+			}
+		case stateBody, stateChunkData:
+			if c.bodyRemaining == 0 {
+				if c.state == stateBody {
+					c.endRequest()
+				} else {
+					c.state = stateChunkSize
+				}
+				continue
+			}
+			if int64(len(p)) > c.bodyRemaining {
+				p = p[:c.bodyRemaining]
+			}
+			n, err = c.reader.Read(p)
+			c.bodyRemaining -= int64(n)
+			return
+		case stateChunkSize:
+			var line []byte
+			line, err = c.readLine()
+			if err != nil {
+				return
+			}
+			sizeString, _, _ := strings.Cut(strings.TrimSpace(string(line)), ";")
+			var size uint64
+			size, err = strconv.ParseUint(strings.TrimSpace(sizeString), 16, 63)
+			if err != nil {
+				return 0, E.Cause(err, "invalid chunk size")
+			}
+			c.output = line
+			if size == 0 {
+				c.state = stateChunkTrailer
+			} else {
+				c.bodyRemaining = int64(size) + 2
+				c.state = stateChunkData
+			}
+		case stateChunkTrailer:
+			var line []byte
+			line, err = c.readLine()
+			if err != nil {
+				return
+			}
+			c.output = line
+			if len(bytes.TrimSpace(line)) == 0 {
+				c.endRequest()
+			}
+		case statePassthrough:
+			return c.reader.Read(p)
+		default:
+			return 0, io.EOF
+		}
+	}
+}
+
+func (c *rewriteConn) endRequest() {
+	if c.upgraded {
+		c.state = statePassthrough
+	} else {
+		c.state = stateRequest
+	}
+}
+
+// readLine consumes a line only when it is complete.
+func (c *rewriteConn) readLine() ([]byte, error) {
+	for size := 1; ; size = c.reader.Buffered() + 1 {
+		if size > c.reader.Size() {
+			return nil, E.New("line too long")
+		}
+		_, err := c.reader.Peek(size)
+		if err != nil {
+			return nil, err
+		}
+		data, _ := c.reader.Peek(c.reader.Buffered())
+		if index := bytes.IndexByte(data, '\n'); index >= 0 {
+			line := make([]byte, index+1)
+			copy(line, data)
+			_, _ = c.reader.Discard(index + 1)
+			return line, nil
+		}
+	}
+}
+
+// readRequest consumes a request head only when it is complete, then writes the rewritten head,
+// or the response of a redirect or reject rule.
+func (c *rewriteConn) readRequest() error {
+	var headLength int
+	for size := 1; ; size = c.reader.Buffered() + 1 {
+		if size > c.reader.Size() {
+			return E.New("request header too large")
+		}
+		_, err := c.reader.Peek(size)
+		if err != nil {
+			if err == io.EOF && c.reader.Buffered() == 0 {
+				c.state = stateClosed
+			}
+			return err
+		}
+		data, _ := c.reader.Peek(c.reader.Buffered())
+		if index := bytes.Index(data, []byte("\r\n\r\n")); index >= 0 {
+			headLength = index + 4
+			break
+		}
+		if index := bytes.Index(data, []byte("\n\n")); index >= 0 {
+			headLength = index + 2
+			break
+		}
+	}
+	head, _ := c.reader.Peek(headLength)
+	request, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(head)))
+	if err != nil {
+		return err
+	}
+	_, _ = c.reader.Discard(headLength)
+	requestURL := c.scheme + "://" + request.Host + request.URL.RequestURI()
+	var proxyForm bool
+	for _, rule := range c.rewriter.rules {
+		location, matched := rule.match(requestURL)
+		if !matched {
+			continue
+		}
+		if rule.action != C.HTTPRewriteActionRewrite {
+			c.logger.DebugContext(c.ctx, "HTTP rewrite: ", rule.action, " ", request.Method, " ", requestURL)
+			c.state = stateClosed
+			err = rule.response(request, location).Write(c.Conn)
+			c.Conn.Close()
+			if err != nil {
+				return err
+			}
+			return io.EOF
+		}
+		rule.rewrite(request)
+		proxyForm = proxyForm || rule.proxyAuthorization != ""
+	}
+	c.output = writeRequestHead(request, c.scheme, proxyForm)
+	c.upgraded = request.Method == http.MethodConnect || request.Header.Get("Upgrade") != ""
+	switch {
+	case len(request.TransferEncoding) > 0 && request.TransferEncoding[0] == "chunked":
+		c.state = stateChunkSize
+	case request.ContentLength > 0:
+		c.bodyRemaining = request.ContentLength
+		c.state = stateBody
+	default:
+		c.endRequest()
+	}
+	return nil
+}
+
+// writeRequestHead keeps the body framing of the original request,
+// so that the body is passed through unchanged.
+func writeRequestHead(request *http.Request, scheme string, proxyForm bool) []byte {
+	var buffer bytes.Buffer
+	requestURI := request.URL.RequestURI()
+	if proxyForm {
+		requestURI = scheme + "://" + request.Host + requestURI
+	}
+	buffer.WriteString(request.Method + " " + requestURI + " " + request.Proto + "\r\n")
+	if request.Host != "" {
+		buffer.WriteString("Host: " + request.Host + "\r\n")
+	}
+	request.Header.Del("Content-Length")
+	request.Header.Del("Transfer-Encoding")
+	if len(request.TransferEncoding) > 0 {
+		buffer.WriteString("Transfer-Encoding: " + strings.Join(request.TransferEncoding, ", ") + "\r\n")
+	} else if request.ContentLength > 0 {
+		buffer.WriteString("Content-Length: " + strconv.FormatInt(request.ContentLength, 10) + "\r\n")
+	}
+	_ = request.Header.Write(&buffer)
+	buffer.WriteString("\r\n")
+	return buffer.Bytes()
+}
+
+func isHTTPRequest(reader *bufio.Reader) (bool, error) {
+	for n := 1; ; {
+		_, err := reader.Peek(n)
+		if err != nil {
+			return false, err
+		}
+		data, _ := reader.Peek(reader.Buffered())
+		var needMore bool
+		for _, method := range httpMethods {
+			if bytes.HasPrefix(data, method) {
+				return true, nil
+			}
+			if bytes.HasPrefix(method, data) {
+				needMore = true
+			}
+		}
+		if !needMore {
+			return false, nil
+		}
+		n = len(data) + 1
+	}
+}
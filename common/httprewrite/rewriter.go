@@ -0,0 +1,171 @@
+package httprewrite
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+// Rewriter applies rewrite rules to plaintext HTTP/1.x requests.
+type Rewriter struct {
+	rules []*rule
+}
+
+type rule struct {
+	urlRegex           []*regexp.Regexp
+	action             string
+	addHeader          http.Header
+	setHeader          http.Header
+	removeHeader       []string
+	proxyAuthorization string
+	location           string
+	statusCode         int
+	body               string
+}
+
+func New(options []option.HTTPRewriteRule) (*Rewriter, error) {
+	rewriter := &Rewriter{rules: make([]*rule, 0, len(options))}
+	for i, ruleOptions := range options {
+		rule, err := newRule(ruleOptions)
+		if err != nil {
+			return nil, E.Cause(err, "parse HTTP rewrite rule[", i, "]")
+		}
+		rewriter.rules = append(rewriter.rules, rule)
+	}
+	return rewriter, nil
+}
+
+func newRule(options option.HTTPRewriteRule) (*rule, error) {
+	r := &rule{
+		action:     options.Action,
+		location:   options.Location,
+		statusCode: options.StatusCode,
+		body:       options.Body,
+	}
+	for _, regexString := range options.URLRegex {
+		regex, err := regexp.Compile(regexString)
+		if err != nil {
+			return nil, E.Cause(err, "parse url_regex")
+		}
+		r.urlRegex = append(r.urlRegex, regex)
+	}
+	switch r.action {
+	case "", C.HTTPRewriteActionRewrite:
+		r.action = C.HTTPRewriteActionRewrite
+		if options.Location != "" || options.StatusCode != 0 || options.Body != "" {
+			return nil, E.New("`location`, `status_code` and `body` are only available for redirect and reject")
+		}
+		if len(options.AddHeader) > 0 {
+			r.addHeader = options.AddHeader.Build()
+		}
+		if len(options.SetHeader) > 0 {
+			r.setHeader = options.SetHeader.Build()
+		}
+		r.removeHeader = options.RemoveHeader
+		if options.ProxyAuthorization != nil {
+			r.proxyAuthorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(options.ProxyAuthorization.Username+":"+options.ProxyAuthorization.Password))
+		}
+	case C.HTTPRewriteActionRedirect, C.HTTPRewriteActionReject:
+		if len(options.AddHeader) > 0 || len(options.SetHeader) > 0 || len(options.RemoveHeader) > 0 || options.ProxyAuthorization != nil {
+			return nil, E.New("header rewriting is only available for rewrite")
+		}
+		if r.action == C.HTTPRewriteActionRedirect {
+			if r.location == "" {
+				return nil, E.New("missing location")
+			}
+			switch r.statusCode {
+			case 0:
+				r.statusCode = http.StatusFound
+			case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+			default:
+				return nil, E.New("invalid redirect status code: ", r.statusCode)
+			}
+		} else {
+			if r.location != "" {
+				return nil, E.New("`location` is only available for redirect")
+			}
+			switch {
+			case r.statusCode == 0:
+				r.statusCode = http.StatusForbidden
+			case r.statusCode < 400 || r.statusCode > 599:
+				return nil, E.New("invalid reject status code: ", r.statusCode)
+			}
+		}
+	default:
+		return nil, E.New("unknown action: ", r.action)
+	}
+	return r, nil
+}
+
+func (r *Rewriter) String() string {
+	descriptions := make([]string, 0, len(r.rules))
+	for _, rule := range r.rules {
+		descriptions = append(descriptions, rule.action)
+	}
+	return strings.Join(descriptions, ",")
+}
+
+// match returns the location expanded from the matching url_regex, so redirects may reference groups.
+func (r *rule) match(requestURL string) (location string, matched bool) {
+	if len(r.urlRegex) == 0 {
+		return r.location, true
+	}
+	for _, regex := range r.urlRegex {
+		submatches := regex.FindStringSubmatchIndex(requestURL)
+		if submatches == nil {
+			continue
+		}
+		if r.location != "" {
+			location = string(regex.ExpandString(nil, r.location, requestURL, submatches))
+		}
+		return location, true
+	}
+	return "", false
+}
+
+func (r *rule) rewrite(request *http.Request) {
+	for _, name := range r.removeHeader {
+		request.Header.Del(name)
+	}
+	for name, values := range r.setHeader {
+		request.Header[name] = values
+	}
+	for name, values := range r.addHeader {
+		request.Header[name] = append(request.Header[name], values...)
+	}
+	if r.proxyAuthorization != "" {
+		request.Header.Set("Proxy-Authorization", r.proxyAuthorization)
+	}
+	if host := request.Header.Get("Host"); host != "" {
+		request.Host = host
+		request.Header.Del("Host")
+	}
+}
+
+func (r *rule) response(request *http.Request, location string) *http.Response {
+	response := &http.Response{
+		StatusCode:    r.statusCode,
+		Status:        F.ToString(r.statusCode, " ", http.StatusText(r.statusCode)),
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		Request:       request,
+		Close:         true,
+		ContentLength: int64(len(r.body)),
+		Body:          io.NopCloser(strings.NewReader(r.body)),
+	}
+	if location != "" {
+		response.Header.Set("Location", location)
+	}
+	if r.body != "" {
+		response.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	return response
+}
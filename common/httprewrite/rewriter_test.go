@@ -0,0 +1,149 @@
+package httprewrite_test
+
+import (
+	std_bufio "bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/common/httprewrite"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/auth"
+	"github.com/sagernet/sing/common/buf"
+	"github.com/sagernet/sing/common/bufio"
+	"github.com/sagernet/sing/common/json/badoption"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConn(t *testing.T, rules []option.HTTPRewriteRule) (client net.Conn, server net.Conn) {
+	rewriter, err := httprewrite.New(rules)
+	require.NoError(t, err)
+	client, conn := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		conn.Close()
+	})
+	return client, rewriter.NewConn(context.Background(), conn, "http", log.NewNOPFactory().Logger())
+}
+
+func TestRewriteHeader(t *testing.T) {
+	t.Parallel()
+	client, server := newTestConn(t, []option.HTTPRewriteRule{{
+		URLRegex:           []string{"^http://example\\.com/"},
+		AddHeader:          badoption.HTTPHeader{"X-Added": {"a", "b"}},
+		SetHeader:          badoption.HTTPHeader{"Accept": {"text/plain"}},
+		RemoveHeader:       []string{"Cookie"},
+		ProxyAuthorization: &auth.User{Username: "user", Password: "pass"},
+	}})
+	go client.Write([]byte("GET /index.html HTTP/1.1\r\nHost: example.com\r\nAccept: */*\r\nCookie: a=b\r\n\r\n"))
+	request, err := http.ReadRequest(std_bufio.NewReader(server))
+	require.NoError(t, err)
+	require.Equal(t, "http://example.com/index.html", request.RequestURI)
+	require.Equal(t, []string{"a", "b"}, request.Header.Values("X-Added"))
+	require.Equal(t, "text/plain", request.Header.Get("Accept"))
+	require.Empty(t, request.Header.Get("Cookie"))
+	require.Empty(t, request.Header.Get("User-Agent"))
+	require.Equal(t, "Basic dXNlcjpwYXNz", request.Header.Get("Proxy-Authorization"))
+}
+
+func TestRewriteRedirect(t *testing.T) {
+	t.Parallel()
+	client, server := newTestConn(t, []option.HTTPRewriteRule{{
+		URLRegex: []string{"^http://example\\.com/old/(.*)$"},
+		Action:   "redirect",
+		Location: "https://example.com/new/$1",
+	}})
+	go io.Copy(io.Discard, server)
+	go client.Write([]byte("GET /old/page?q=1 HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	response, err := http.ReadResponse(std_bufio.NewReader(client), nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusFound, response.StatusCode)
+	require.Equal(t, "https://example.com/new/page?q=1", response.Header.Get("Location"))
+}
+
+func TestRewriteReject(t *testing.T) {
+	t.Parallel()
+	client, server := newTestConn(t, []option.HTTPRewriteRule{{
+		URLRegex:   []string{"/ads/"},
+		Action:     "reject",
+		StatusCode: http.StatusNotFound,
+		Body:       "blocked",
+	}})
+	go io.Copy(io.Discard, server)
+	go client.Write([]byte("GET /ads/banner.js HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	response, err := http.ReadResponse(std_bufio.NewReader(client), nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, response.StatusCode)
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.Equal(t, "blocked", string(body))
+}
+
+func TestRewritePassthrough(t *testing.T) {
+	t.Parallel()
+	client, server := newTestConn(t, []option.HTTPRewriteRule{{RemoveHeader: []string{"Host"}}})
+	payload := []byte("\x16\x03\x01\x00\x05hello")
+	go client.Write(payload)
+	data := make([]byte, len(payload))
+	_, err := io.ReadFull(server, data)
+	require.NoError(t, err)
+	require.Equal(t, payload, data)
+}
+
+func TestRewriteReadDeadline(t *testing.T) {
+	t.Parallel()
+	client, server := newTestConn(t, []option.HTTPRewriteRule{{SetHeader: badoption.HTTPHeader{"X-Rewritten": {"1"}}}})
+	go client.Write([]byte("GET / HTTP/1.1\r\nHost: exam"))
+	require.NoError(t, server.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+	_, err := server.Read(make([]byte, 1024))
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	require.NoError(t, server.SetReadDeadline(time.Time{}))
+	go client.Write([]byte("ple.com\r\n\r\n"))
+	request, err := http.ReadRequest(std_bufio.NewReader(server))
+	require.NoError(t, err)
+	require.Equal(t, "example.com", request.Host)
+	require.Equal(t, "1", request.Header.Get("X-Rewritten"))
+}
+
+func TestRewriteRequestBody(t *testing.T) {
+	t.Parallel()
+	client, server := newTestConn(t, []option.HTTPRewriteRule{{SetHeader: badoption.HTTPHeader{"X-Rewritten": {"1"}}}})
+	go client.Write([]byte("POST /a HTTP/1.1\r\nHost: example.com\r\nContent-Length: 5\r\n\r\nhello" +
+		"POST /b HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n5;ext\r\nworld\r\n0\r\n\r\n" +
+		"GET /c HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	reader := std_bufio.NewReader(server)
+	for _, expected := range []struct{ path, body string }{{"/a", "hello"}, {"/b", "world"}, {"/c", ""}} {
+		request, err := http.ReadRequest(reader)
+		require.NoError(t, err)
+		require.Equal(t, expected.path, request.URL.Path)
+		require.Equal(t, "1", request.Header.Get("X-Rewritten"))
+		body, err := io.ReadAll(request.Body)
+		require.NoError(t, err)
+		require.Equal(t, expected.body, string(body))
+	}
+}
+
+func TestIsPlaintextRequestConn(t *testing.T) {
+	t.Parallel()
+	clientConn, _ := net.Pipe()
+	requestConn, _ := net.Pipe()
+	require.False(t, httprewrite.IsPlaintextRequestConn(clientConn, clientConn))
+	require.False(t, httprewrite.IsPlaintextRequestConn(bufio.NewCachedConn(clientConn, buf.New()), clientConn))
+	require.True(t, httprewrite.IsPlaintextRequestConn(requestConn, clientConn))
+}
+
+func TestRewriteInvalidOptions(t *testing.T) {
+	t.Parallel()
+	_, err := httprewrite.New([]option.HTTPRewriteRule{{Action: "redirect"}})
+	require.Error(t, err)
+	_, err = httprewrite.New([]option.HTTPRewriteRule{{Action: "reject", StatusCode: http.StatusOK}})
+	require.Error(t, err)
+	_, err = httprewrite.New([]option.HTTPRewriteRule{{Action: "reject", RemoveHeader: []string{"Cookie"}}})
+	require.Error(t, err)
+}
@@ -21,6 +21,7 @@ type ClientHello struct {
 	EllipticCurvePF     []uint8
 	Versions            []uint16
 	SignatureAlgorithms []uint16
+	ALPN                []string
 	ServerName          string
 	ja3ByteString       []byte
 	ja3Hash             string
@@ -0,0 +1,122 @@
+package ja3
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	JA4ProtocolTCP  = 't'
+	JA4ProtocolQUIC = 'q'
+	JA4ProtocolDTLS = 'd'
+)
+
+// MaxVersion returns the highest offered TLS version, ignoring any GREASE values.
+func (j *ClientHello) MaxVersion() uint16 {
+	var version uint16
+	for _, val := range j.Versions {
+		if !isGREASE(val) && val > version {
+			version = val
+		}
+	}
+	if version == 0 {
+		version = j.Version
+	}
+	return version
+}
+
+// JA4 computes the JA4 fingerprint, see https://github.com/FoxIO-LLC/ja4/blob/main/technical_details/JA4.md.
+func (j *ClientHello) JA4(protocol byte) string {
+	var builder strings.Builder
+	builder.WriteByte(protocol)
+	builder.WriteString(ja4Version(j.MaxVersion()))
+	if j.ServerName != "" {
+		builder.WriteByte('d')
+	} else {
+		builder.WriteByte('i')
+	}
+	cipherSuites := ja4Values(j.CipherSuites)
+	extensions := ja4Values(j.Extensions)
+	fmt.Fprintf(&builder, "%02d%02d", min(len(cipherSuites), 99), min(len(extensions), 99))
+	builder.WriteString(ja4ALPN(j.ALPN))
+	builder.WriteByte('_')
+	slices.Sort(cipherSuites)
+	builder.WriteString(ja4Hash(strings.Join(cipherSuites, ",")))
+	builder.WriteByte('_')
+	extensions = slices.DeleteFunc(extensions, func(it string) bool {
+		return it == "0000" || it == "0010"
+	})
+	slices.Sort(extensions)
+	extensionString := strings.Join(extensions, ",")
+	if len(j.SignatureAlgorithms) > 0 {
+		signatureAlgorithms := make([]string, 0, len(j.SignatureAlgorithms))
+		for _, val := range j.SignatureAlgorithms {
+			signatureAlgorithms = append(signatureAlgorithms, fmt.Sprintf("%04x", val))
+		}
+		extensionString += "_" + strings.Join(signatureAlgorithms, ",")
+	}
+	builder.WriteString(ja4Hash(extensionString))
+	return builder.String()
+}
+
+func ja4Version(version uint16) string {
+	switch version {
+	case 0x0304:
+		return "13"
+	case 0x0303:
+		return "12"
+	case 0x0302:
+		return "11"
+	case 0x0301:
+		return "10"
+	case 0x0300:
+		return "s3"
+	case 0x0002:
+		return "s2"
+	case 0xfeff:
+		return "d1"
+	case 0xfefd:
+		return "d2"
+	case 0xfefc:
+		return "d3"
+	default:
+		return "00"
+	}
+}
+
+func ja4Values(values []uint16) []string {
+	hexValues := make([]string, 0, len(values))
+	for _, val := range values {
+		if !isGREASE(val) {
+			hexValues = append(hexValues, fmt.Sprintf("%04x", val))
+		}
+	}
+	return hexValues
+}
+
+func ja4ALPN(alpn []string) string {
+	if len(alpn) == 0 || alpn[0] == "" {
+		return "00"
+	}
+	first, last := alpn[0][0], alpn[0][len(alpn[0])-1]
+	if !isAlphanumeric(first) || !isAlphanumeric(last) {
+		firstHex, lastHex := hex.EncodeToString([]byte{first}), hex.EncodeToString([]byte{last})
+		return firstHex[:1] + lastHex[1:]
+	}
+	return string([]byte{first, last})
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func ja4Hash(value string) string {
+	if value == "" {
+		return "000000000000"
+	}
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:6])
+}
@@ -0,0 +1,42 @@
+package ja3_test
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/common/ja3"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJA4(t *testing.T) {
+	t.Parallel()
+	clientHello := &ja3.ClientHello{
+		Version: 0x0303,
+		CipherSuites: []uint16{
+			0x3a3a, 0x1301, 0x1302, 0x1303, 0xc02b, 0xc02f, 0xc02c, 0xc030,
+			0xcca9, 0xcca8, 0xc013, 0xc014, 0x009c, 0x009d, 0x002f, 0x0035,
+		},
+		Extensions: []uint16{
+			0x8a8a, 0x0000, 0x0017, 0xff01, 0x000a, 0x000b, 0x0023, 0x0010, 0x0005,
+			0x000d, 0x0012, 0x0033, 0x002d, 0x002b, 0x001b, 0x4469, 0x0015, 0xbaba,
+		},
+		Versions:            []uint16{0x6a6a, 0x0304, 0x0303},
+		SignatureAlgorithms: []uint16{0x0403, 0x0804, 0x0401, 0x0503, 0x0805, 0x0501, 0x0806, 0x0601},
+		ALPN:                []string{"h2", "http/1.1"},
+		ServerName:          "example.com",
+	}
+	require.Equal(t, "t13d1516h2_8daaf6152771_e5627efa2ab1", clientHello.JA4(ja3.JA4ProtocolTCP))
+	require.Equal(t, uint16(0x0304), clientHello.MaxVersion())
+}
+
+func TestJA3IgnoreGREASE(t *testing.T) {
+	t.Parallel()
+	clientHello := &ja3.ClientHello{
+		Version:         0x0303,
+		CipherSuites:    []uint16{0x0a0a, 4865, 4866},
+		Extensions:      []uint16{0x1a1a, 0, 23},
+		EllipticCurves:  []uint16{0x2a2a, 29, 23},
+		EllipticCurvePF: []uint8{0},
+	}
+	require.Equal(t, "771,4865-4866,0-23,29-23,0", clientHello.String())
+}
@@ -26,6 +26,7 @@ const (
 	ecpfExtensionHeaderLen                int    = 1
 	versionExtensionHeaderLen             int    = 1
 	signatureAlgorithmsExtensionHeaderLen int    = 2
+	alpnExtensionHeaderLen                int    = 2
 	contentType                           uint8  = 22
 	handshakeType                         uint8  = 1
 	sniExtensionType                      uint16 = 0
@@ -34,6 +35,7 @@ const (
 	ecpfExtensionType                     uint16 = 11
 	versionExtensionType                  uint16 = 43
 	signatureAlgorithmsExtensionType      uint16 = 13
+	alpnExtensionType                     uint16 = 16
 
 	// Versions
 	// The bitmask covers the versions SSL3.0 to TLS1.2
@@ -176,6 +178,7 @@ func (j *ClientHello) parseExtensions(exs []byte) error {
 	var ellipticCurvePF []uint8
 	var versions []uint16
 	var signatureAlgorithms []uint16
+	var alpn []string
 	for len(exs) > 0 {
 
 		// Check if we can decode the next fields
@@ -275,6 +278,22 @@ func (j *ClientHello) parseExtensions(exs []byte) error {
 			for i := 0; i < int(ssaLen); i += 2 {
 				signatureAlgorithms = append(signatureAlgorithms, binary.BigEndian.Uint16(sex[2:][i:]))
 			}
+		case alpnExtensionType:
+			if len(sex) < alpnExtensionHeaderLen {
+				return &ParseError{LengthErr, 21}
+			}
+			protocols := sex[alpnExtensionHeaderLen:]
+			if len(protocols) != int(binary.BigEndian.Uint16(sex)) {
+				return &ParseError{LengthErr, 22}
+			}
+			for len(protocols) > 0 {
+				protocolLen := int(protocols[0])
+				if len(protocols) < 1+protocolLen {
+					return &ParseError{LengthErr, 23}
+				}
+				alpn = append(alpn, string(protocols[1:1+protocolLen]))
+				protocols = protocols[1+protocolLen:]
+			}
 		}
 		exs = exs[4+exLen:]
 	}
@@ -284,6 +303,7 @@ func (j *ClientHello) parseExtensions(exs []byte) error {
 	j.EllipticCurvePF = ellipticCurvePF
 	j.Versions = versions
 	j.SignatureAlgorithms = signatureAlgorithms
+	j.ALPN = alpn
 	return nil
 }
 
@@ -299,59 +319,44 @@ func (j *ClientHello) marshalJA3() {
 	byteString = append(byteString, commaByte)
 
 	// Cipher Suites
-	if len(j.CipherSuites) != 0 {
-		for _, val := range j.CipherSuites {
-			if val&GreaseBitmask != 0x0A0A {
-				continue
-			}
-			byteString = strconv.AppendUint(byteString, uint64(val), 10)
-			byteString = append(byteString, dashByte)
-		}
-		// Replace last dash with a comma
-		byteString[len(byteString)-1] = commaByte
-	} else {
-		byteString = append(byteString, commaByte)
-	}
+	byteString = appendJA3Values(byteString, j.CipherSuites)
+	byteString = append(byteString, commaByte)
 
 	// Extensions
-	if len(j.Extensions) != 0 {
-		for _, val := range j.Extensions {
-			if val&GreaseBitmask != 0x0A0A {
-				continue
-			}
-			byteString = strconv.AppendUint(byteString, uint64(val), 10)
-			byteString = append(byteString, dashByte)
-		}
-		// Replace last dash with a comma
-		byteString[len(byteString)-1] = commaByte
-	} else {
-		byteString = append(byteString, commaByte)
-	}
+	byteString = appendJA3Values(byteString, j.Extensions)
+	byteString = append(byteString, commaByte)
 
 	// Elliptic curves
-	if len(j.EllipticCurves) != 0 {
-		for _, val := range j.EllipticCurves {
-			if val&GreaseBitmask != 0x0A0A {
-				continue
-			}
-			byteString = strconv.AppendUint(byteString, uint64(val), 10)
+	byteString = appendJA3Values(byteString, j.EllipticCurves)
+	byteString = append(byteString, commaByte)
+
+	// ECPF
+	for i, val := range j.EllipticCurvePF {
+		if i > 0 {
 			byteString = append(byteString, dashByte)
 		}
-		// Replace last dash with a comma
-		byteString[len(byteString)-1] = commaByte
-	} else {
-		byteString = append(byteString, commaByte)
+		byteString = strconv.AppendUint(byteString, uint64(val), 10)
 	}
 
-	// ECPF
-	if len(j.EllipticCurvePF) != 0 {
-		for _, val := range j.EllipticCurvePF {
-			byteString = strconv.AppendUint(byteString, uint64(val), 10)
+	j.ja3ByteString = byteString
+}
+
+// appendJA3Values appends dash separated values, ignoring any GREASE values
+func appendJA3Values(byteString []byte, values []uint16) []byte {
+	var appended bool
+	for _, val := range values {
+		if isGREASE(val) {
+			continue
+		}
+		if appended {
 			byteString = append(byteString, dashByte)
 		}
-		// Remove last dash
-		byteString = byteString[:len(byteString)-1]
+		byteString = strconv.AppendUint(byteString, uint64(val), 10)
+		appended = true
 	}
+	return byteString
+}
 
-	j.ja3ByteString = byteString
+func isGREASE(value uint16) bool {
+	return value&GreaseBitmask == 0x0A0A && value>>8 == value&0xFF
 }
@@ -205,3 +205,11 @@ func (w *packetWriter) WritePacket(buffer *buf.Buffer, destination M.Socksaddr)
 
 func (w *packetWriter) WriteIsThreadUnsafe() {
 }
+
+// DisabledPacketListener rejects SOCKS5 UDP ASSOCIATE, for servers whose clients
+// can not reach a UDP relay, such as those served over a V2Ray transport.
+type DisabledPacketListener struct{}
+
+func (DisabledPacketListener) ListenPacket(listenConfig net.ListenConfig, ctx context.Context, network string, address string) (net.PacketConn, error) {
+	return nil, E.New("UDP ASSOCIATE is not available over transport")
+}
@@ -0,0 +1,196 @@
+package mitm
+
+import (
+	"context"
+	stdTLS "crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/ntp"
+	"github.com/sagernet/sing/contrab/freelru"
+	"github.com/sagernet/sing/contrab/maphash"
+	"github.com/sagernet/sing/service/filemanager"
+
+	"golang.org/x/net/http2"
+)
+
+const (
+	authorityCommonName = "sing-box MITM CA"
+	authorityValidity   = 10 * 365 * 24 * time.Hour
+	leafValidity        = 30 * 24 * time.Hour
+	leafCacheLifetime   = 24 * time.Hour
+
+	protocolCacheLifetime = time.Hour
+)
+
+// Authority issues certificates for intercepted server names, signed by a local CA.
+type Authority struct {
+	ctx            context.Context
+	certificate    *x509.Certificate
+	certificatePEM []byte
+	privateKey     any
+	access         sync.Mutex
+	leafCache      freelru.Cache[string, *stdTLS.Certificate]
+	protocolCache  freelru.Cache[string, string]
+}
+
+// NewAuthority loads the CA from options. When only paths are set and neither file exists,
+// a new CA is generated and saved there.
+func NewAuthority(ctx context.Context, options option.MITMOptions) (*Authority, error) {
+	var certificatePEM, keyPEM []byte
+	if len(options.Certificate) > 0 {
+		certificatePEM = []byte(strings.Join(options.Certificate, "\n"))
+	} else if options.CertificatePath != "" {
+		content, err := os.ReadFile(filemanager.BasePath(ctx, options.CertificatePath))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, E.Cause(err, "read CA certificate")
+		}
+		certificatePEM = content
+	}
+	if len(options.Key) > 0 {
+		keyPEM = []byte(strings.Join(options.Key, "\n"))
+	} else if options.KeyPath != "" {
+		content, err := os.ReadFile(filemanager.BasePath(ctx, options.KeyPath))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, E.Cause(err, "read CA key")
+		}
+		keyPEM = content
+	}
+	if certificatePEM == nil && keyPEM == nil {
+		if options.CertificatePath == "" || options.KeyPath == "" {
+			return nil, E.New("missing CA certificate and key")
+		}
+		var err error
+		certificatePEM, keyPEM, err = generateAuthority(ctx, options.CertificatePath, options.KeyPath)
+		if err != nil {
+			return nil, err
+		}
+	} else if certificatePEM == nil {
+		return nil, E.New("missing CA certificate")
+	} else if keyPEM == nil {
+		return nil, E.New("missing CA key")
+	}
+	keyPair, err := stdTLS.X509KeyPair(certificatePEM, keyPEM)
+	if err != nil {
+		return nil, E.Cause(err, "parse CA key pair")
+	}
+	certificate, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return nil, E.Cause(err, "parse CA certificate")
+	}
+	if !certificate.IsCA {
+		return nil, E.New("certificate is not a CA: ", certificate.Subject.CommonName)
+	}
+	leafCache := common.Must1(freelru.NewSharded[string, *stdTLS.Certificate](1024, maphash.NewHasher[string]().Hash32))
+	leafCache.SetLifetime(leafCacheLifetime)
+	protocolCache := common.Must1(freelru.NewSharded[string, string](1024, maphash.NewHasher[string]().Hash32))
+	protocolCache.SetLifetime(protocolCacheLifetime)
+	return &Authority{
+		ctx:            ctx,
+		certificate:    certificate,
+		certificatePEM: certificatePEM,
+		privateKey:     keyPair.PrivateKey,
+		leafCache:      leafCache,
+		protocolCache:  protocolCache,
+	}, nil
+}
+
+func generateAuthority(ctx context.Context, certificatePath string, keyPath string) (certificatePEM []byte, keyPEM []byte, err error) {
+	keyPEM, certificatePEM, err = tls.GenerateCACertificate(time.Now, authorityCommonName, time.Now().Add(authorityValidity))
+	if err != nil {
+		return nil, nil, E.Cause(err, "generate CA")
+	}
+	for _, path := range []string{certificatePath, keyPath} {
+		err = filemanager.MkdirAll(ctx, filepath.Dir(path), 0o755)
+		if err != nil {
+			return nil, nil, E.Cause(err, "create directory for ", path)
+		}
+	}
+	err = filemanager.WriteFile(ctx, certificatePath, certificatePEM, 0o644)
+	if err != nil {
+		return nil, nil, E.Cause(err, "write CA certificate")
+	}
+	err = filemanager.WriteFile(ctx, keyPath, keyPEM, 0o600)
+	if err != nil {
+		return nil, nil, E.Cause(err, "write CA key")
+	}
+	return certificatePEM, keyPEM, nil
+}
+
+// CertificatePEM returns the PEM encoded CA certificate for clients to trust.
+func (a *Authority) CertificatePEM() []byte {
+	return a.certificatePEM
+}
+
+// Certificate returns a certificate for serverName, issuing it on the first use.
+func (a *Authority) Certificate(serverName string) (*stdTLS.Certificate, error) {
+	serverName = strings.ToLower(serverName)
+	certificate, loaded := a.leafCache.Get(serverName)
+	if loaded {
+		return certificate, nil
+	}
+	a.access.Lock()
+	defer a.access.Unlock()
+	certificate, loaded = a.leafCache.Get(serverName)
+	if loaded {
+		return certificate, nil
+	}
+	timeFunc := ntp.TimeFuncFromContext(a.ctx)
+	if timeFunc == nil {
+		timeFunc = time.Now
+	}
+	keyPEM, certificatePEM, err := tls.GenerateCertificate(a.certificate, a.privateKey, timeFunc, serverName, timeFunc().Add(leafValidity))
+	if err != nil {
+		return nil, E.Cause(err, "issue certificate for ", serverName)
+	}
+	keyPair, err := stdTLS.X509KeyPair(certificatePEM, keyPEM)
+	if err != nil {
+		return nil, E.Cause(err, "issue certificate for ", serverName)
+	}
+	keyPair.Certificate = append(keyPair.Certificate, a.certificate.Raw)
+	a.leafCache.Add(serverName, &keyPair)
+	return &keyPair, nil
+}
+
+// Server terminates TLS for serverName on conn, offering only nextProtocol,
+// which should be the protocol accepted by the real server.
+func (a *Authority) Server(ctx context.Context, conn net.Conn, serverName string, nextProtocol string) (*stdTLS.Conn, error) {
+	if !isHTTPProtocol(nextProtocol) {
+		return nil, E.New("not a HTTP protocol: ", nextProtocol)
+	}
+	certificate, err := a.Certificate(serverName)
+	if err != nil {
+		return nil, err
+	}
+	tlsConn := stdTLS.Server(conn, &stdTLS.Config{
+		Time:         ntp.TimeFuncFromContext(a.ctx),
+		Certificates: []stdTLS.Certificate{*certificate},
+		NextProtos:   []string{nextProtocol},
+	})
+	ctx, cancel := context.WithTimeout(ctx, C.TCPTimeout)
+	defer cancel()
+	err = tlsConn.HandshakeContext(ctx)
+	if err != nil {
+		return nil, E.Cause(err, "TLS handshake with client")
+	}
+	return tlsConn, nil
+}
+
+// IsHTTP reports whether a client offering clientALPN may speak HTTP over TLS.
+func IsHTTP(clientALPN []string) bool {
+	return len(clientALPN) == 0 || common.Any(clientALPN, isHTTPProtocol)
+}
+
+func isHTTPProtocol(protocol string) bool {
+	return protocol == http2.NextProtoTLS || protocol == "http/1.1"
+}
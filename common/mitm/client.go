@@ -0,0 +1,83 @@
+package mitm
+
+import (
+	"context"
+	stdTLS "crypto/tls"
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/ntp"
+)
+
+// Client re-originates TLS to the real server with the original server name, offering only the
+// protocol negotiated with the intercepted client, since the decrypted stream is relayed as is.
+func Client(ctx context.Context, conn net.Conn, serverName string, nextProtocol string) (net.Conn, error) {
+	var nextProtos []string
+	if nextProtocol != "" {
+		nextProtos = []string{nextProtocol}
+	}
+	tlsConn, err := clientHandshake(ctx, conn, serverName, nextProtos)
+	if err != nil {
+		return nil, err
+	}
+	negotiatedProtocol := tlsConn.ConnectionState().NegotiatedProtocol
+	if negotiatedProtocol != nextProtocol && !(negotiatedProtocol == "" && nextProtocol == "http/1.1") {
+		tlsConn.Close()
+		return nil, E.New("server does not support ALPN protocol ", nextProtocol, " negotiated with the client")
+	}
+	return tlsConn, nil
+}
+
+// UpstreamProtocol returns the protocol the real server selects from the HTTP protocols in clientALPN,
+// or an empty string if it selects none, so that only that protocol is offered to the client.
+// The server is probed with a separate connection through dialer, and the result is cached by server name.
+func (a *Authority) UpstreamProtocol(ctx context.Context, dialer N.Dialer, destination M.Socksaddr, destinationAddresses []netip.Addr, serverName string, clientALPN []string) (string, error) {
+	nextProtos := common.Filter(clientALPN, isHTTPProtocol)
+	cacheKey := serverName + "/" + strings.Join(nextProtos, ",")
+	if protocol, loaded := a.protocolCache.Get(cacheKey); loaded {
+		return protocol, nil
+	}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if len(destinationAddresses) > 0 {
+		conn, err = N.DialSerial(ctx, dialer, N.NetworkTCP, destination, destinationAddresses)
+	} else {
+		conn, err = dialer.DialContext(ctx, N.NetworkTCP, destination)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	tlsConn, err := clientHandshake(ctx, conn, serverName, nextProtos)
+	if err != nil {
+		return "", err
+	}
+	protocol := tlsConn.ConnectionState().NegotiatedProtocol
+	a.protocolCache.Add(cacheKey, protocol)
+	return protocol, nil
+}
+
+func clientHandshake(ctx context.Context, conn net.Conn, serverName string, nextProtos []string) (*stdTLS.Conn, error) {
+	tlsConn := stdTLS.Client(conn, &stdTLS.Config{
+		Time:       ntp.TimeFuncFromContext(ctx),
+		RootCAs:    adapter.RootPoolFromContext(ctx),
+		ServerName: serverName,
+		NextProtos: nextProtos,
+	})
+	handshakeCtx, cancel := context.WithTimeout(ctx, C.TCPTimeout)
+	defer cancel()
+	err := tlsConn.HandshakeContext(handshakeCtx)
+	if err != nil {
+		return nil, E.Cause(err, "TLS handshake with server")
+	}
+	return tlsConn, nil
+}
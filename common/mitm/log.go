@@ -0,0 +1,92 @@
+package mitm
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/sagernet/sing/common/logger"
+
+	"golang.org/x/net/http2"
+)
+
+type loggingConn struct {
+	net.Conn
+	writer *io.PipeWriter
+	broken bool
+}
+
+// NewLoggingConn logs every request the client sends over the decrypted conn.
+// Requests are parsed from a copy of the stream, which is dropped once parsing fails.
+func NewLoggingConn(ctx context.Context, conn net.Conn, nextProtocol string, logger logger.ContextLogger) net.Conn {
+	reader, writer := io.Pipe()
+	go logRequests(ctx, reader, nextProtocol, logger)
+	return &loggingConn{Conn: conn, writer: writer}
+}
+
+func (c *loggingConn) Read(p []byte) (n int, err error) {
+	n, err = c.Conn.Read(p)
+	if n > 0 && !c.broken {
+		_, writeErr := c.writer.Write(p[:n])
+		c.broken = writeErr != nil
+	}
+	return
+}
+
+func (c *loggingConn) Close() error {
+	c.writer.Close()
+	return c.Conn.Close()
+}
+
+func logRequests(ctx context.Context, reader *io.PipeReader, nextProtocol string, logger logger.ContextLogger) {
+	var err error
+	if nextProtocol == http2.NextProtoTLS {
+		err = logHTTP2Requests(ctx, reader, logger)
+	} else {
+		err = logHTTP1Requests(ctx, reader, logger)
+	}
+	reader.CloseWithError(err)
+}
+
+func logHTTP1Requests(ctx context.Context, reader io.Reader, logger logger.ContextLogger) error {
+	bReader := bufio.NewReader(reader)
+	for {
+		request, err := http.ReadRequest(bReader)
+		if err != nil {
+			return err
+		}
+		logRequest(ctx, logger, request.Method, "https://"+request.Host+request.URL.RequestURI())
+		_, err = io.Copy(io.Discard, request.Body)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func logHTTP2Requests(ctx context.Context, reader io.Reader, logger logger.ContextLogger) error {
+	framer, err := newHTTP2Framer(reader)
+	if err != nil {
+		return err
+	}
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			return err
+		}
+		headersFrame, isHeaders := frame.(*http2.MetaHeadersFrame)
+		if !isHeaders {
+			continue
+		}
+		request, err := newHTTP2Request(headersFrame)
+		if err != nil {
+			continue
+		}
+		logRequest(ctx, logger, request.Method, request.URL.String())
+	}
+}
+
+func logRequest(ctx context.Context, logger logger.ContextLogger, method string, requestURL string) {
+	logger.InfoContext(ctx, "intercepted request: ", method, " ", requestURL)
+}
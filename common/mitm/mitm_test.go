@@ -0,0 +1,182 @@
+package mitm_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/mitm"
+	"github.com/sagernet/sing-box/option"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+func newTestAuthority(t *testing.T) *mitm.Authority {
+	directory := t.TempDir()
+	options := option.MITMOptions{
+		CertificatePath: filepath.Join(directory, "ca.crt"),
+		KeyPath:         filepath.Join(directory, "ca.key"),
+	}
+	authority, err := mitm.NewAuthority(context.Background(), options)
+	require.NoError(t, err)
+	require.FileExists(t, options.KeyPath)
+	certificatePEM, err := os.ReadFile(options.CertificatePath)
+	require.NoError(t, err)
+	require.Equal(t, authority.CertificatePEM(), certificatePEM)
+	reloaded, err := mitm.NewAuthority(context.Background(), options)
+	require.NoError(t, err)
+	require.Equal(t, certificatePEM, reloaded.CertificatePEM())
+	return authority
+}
+
+func interceptRequest(t *testing.T, authority *mitm.Authority, clientALPN []string, upstreamProtocol string, sendRequest func(conn *tls.Conn)) (*http.Request, string) {
+	certPool := x509.NewCertPool()
+	require.True(t, certPool.AppendCertsFromPEM(authority.CertificatePEM()))
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	go func() {
+		tlsConn := tls.Client(clientConn, &tls.Config{
+			ServerName: "example.com",
+			RootCAs:    certPool,
+			NextProtos: clientALPN,
+		})
+		if tlsConn.Handshake() == nil {
+			sendRequest(tlsConn)
+		}
+	}()
+	tlsConn, err := authority.Server(context.Background(), serverConn, "example.com", upstreamProtocol)
+	require.NoError(t, err)
+	nextProtocol := tlsConn.ConnectionState().NegotiatedProtocol
+	request, payload, err := mitm.ReadRequest(tlsConn, nextProtocol)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+	return request, nextProtocol
+}
+
+func TestInterceptHTTP1(t *testing.T) {
+	t.Parallel()
+	authority := newTestAuthority(t)
+	request, nextProtocol := interceptRequest(t, authority, []string{"http/1.1"}, "http/1.1", func(conn *tls.Conn) {
+		request, _ := http.NewRequest(http.MethodPost, "https://example.com/api/v1?q=1", nil)
+		request.Header.Set("User-Agent", "test")
+		request.Write(conn)
+	})
+	require.Equal(t, "http/1.1", nextProtocol)
+	require.Equal(t, http.MethodPost, request.Method)
+	require.Equal(t, "https://example.com/api/v1?q=1", request.URL.String())
+	require.Equal(t, "test", request.Header.Get("User-Agent"))
+}
+
+func TestInterceptHTTP2(t *testing.T) {
+	t.Parallel()
+	authority := newTestAuthority(t)
+	request, nextProtocol := interceptRequest(t, authority, []string{"h2", "http/1.1"}, "h2", func(conn *tls.Conn) {
+		clientConn, err := (&http2.Transport{}).NewClientConn(conn)
+		if err != nil {
+			return
+		}
+		request, _ := http.NewRequest(http.MethodGet, "https://example.com/index.html", nil)
+		request.Header.Set("User-Agent", "test")
+		clientConn.RoundTrip(request)
+	})
+	require.Equal(t, "h2", nextProtocol)
+	require.Equal(t, http.MethodGet, request.Method)
+	require.Equal(t, "https://example.com/index.html", request.URL.String())
+	require.Equal(t, "example.com", request.Host)
+	require.Equal(t, "test", request.Header.Get("User-Agent"))
+}
+
+func TestInterceptNonHTTP(t *testing.T) {
+	t.Parallel()
+	require.True(t, mitm.IsHTTP(nil))
+	require.False(t, mitm.IsHTTP([]string{"imap"}))
+}
+
+func TestCertificateCache(t *testing.T) {
+	t.Parallel()
+	authority := newTestAuthority(t)
+	certificate, err := authority.Certificate("Example.com")
+	require.NoError(t, err)
+	cached, err := authority.Certificate("example.com")
+	require.NoError(t, err)
+	require.Same(t, certificate, cached)
+}
+
+type testCertificateStore struct {
+	pool *x509.CertPool
+}
+
+func (s *testCertificateStore) Name() string {
+	return "certificate"
+}
+
+func (s *testCertificateStore) Start(adapter.StartStage) error {
+	return nil
+}
+
+func (s *testCertificateStore) Close() error {
+	return nil
+}
+
+func (s *testCertificateStore) Pool() *x509.CertPool {
+	return s.pool
+}
+
+func TestUpstreamProtocol(t *testing.T) {
+	t.Parallel()
+	authority := newTestAuthority(t)
+	certPool := x509.NewCertPool()
+	require.True(t, certPool.AppendCertsFromPEM(authority.CertificatePEM()))
+	ctx := service.ContextWith[adapter.CertificateStore](context.Background(), &testCertificateStore{certPool})
+	for _, testCase := range []struct {
+		serverName string
+		serverALPN []string
+		clientALPN []string
+		expected   string
+	}{
+		{"h2.example.com", []string{"h2", "http/1.1"}, []string{"h2", "http/1.1"}, "h2"},
+		{"http1.example.com", []string{"http/1.1"}, []string{"h2", "http/1.1"}, "http/1.1"},
+		{"h2.example.com", []string{"h2", "http/1.1"}, []string{"http/1.1"}, "http/1.1"},
+		{"no-alpn.example.com", nil, []string{"h2", "http/1.1"}, ""},
+	} {
+		certificate, err := authority.Certificate(testCase.serverName)
+		require.NoError(t, err)
+		listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+			Certificates: []tls.Certificate{*certificate},
+			NextProtos:   testCase.serverALPN,
+		})
+		require.NoError(t, err)
+		var accepted atomic.Int32
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				accepted.Add(1)
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}
+		}()
+		destination := M.SocksaddrFromNet(listener.Addr())
+		for range 2 {
+			protocol, err := authority.UpstreamProtocol(ctx, N.SystemDialer, destination, nil, testCase.serverName, testCase.clientALPN)
+			require.NoError(t, err)
+			require.Equal(t, testCase.expected, protocol)
+		}
+		listener.Close()
+		require.Equal(t, int32(1), accepted.Load())
+	}
+}
@@ -0,0 +1,107 @@
+package mitm
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	C "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// ReadRequest reads the first decrypted request from conn. All bytes read are returned
+// to be replayed to the server, also when parsing fails.
+func ReadRequest(conn net.Conn, nextProtocol string) (request *http.Request, payload []byte, err error) {
+	var buffer bytes.Buffer
+	reader := io.TeeReader(conn, &buffer)
+	err = conn.SetReadDeadline(time.Now().Add(C.ReadPayloadTimeout))
+	if err != nil {
+		return
+	}
+	if nextProtocol == http2.NextProtoTLS {
+		request, err = readHTTP2Request(reader)
+	} else {
+		request, err = http.ReadRequest(bufio.NewReader(reader))
+		if err == nil {
+			request.Body = http.NoBody
+			request.URL.Scheme = "https"
+			request.URL.Host = request.Host
+		}
+	}
+	payload = buffer.Bytes()
+	if deadlineErr := conn.SetReadDeadline(time.Time{}); err == nil {
+		err = deadlineErr
+	}
+	return
+}
+
+func readHTTP2Request(reader io.Reader) (*http.Request, error) {
+	framer, err := newHTTP2Framer(reader)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			return nil, err
+		}
+		headersFrame, isHeaders := frame.(*http2.MetaHeadersFrame)
+		if isHeaders {
+			return newHTTP2Request(headersFrame)
+		}
+	}
+}
+
+func newHTTP2Framer(reader io.Reader) (*http2.Framer, error) {
+	preface := make([]byte, len(http2.ClientPreface))
+	_, err := io.ReadFull(reader, preface)
+	if err != nil {
+		return nil, err
+	}
+	if string(preface) != http2.ClientPreface {
+		return nil, E.New("bad HTTP/2 client preface")
+	}
+	framer := http2.NewFramer(io.Discard, reader)
+	decoder := hpack.NewDecoder(4096, nil)
+	// the server may allow a larger table than the default
+	decoder.SetAllowedMaxDynamicTableSize(1 << 16)
+	framer.ReadMetaHeaders = decoder
+	return framer, nil
+}
+
+func newHTTP2Request(frame *http2.MetaHeadersFrame) (*http.Request, error) {
+	path := frame.PseudoValue("path")
+	requestURL, err := url.ParseRequestURI(path)
+	if err != nil {
+		return nil, E.Cause(err, "parse HTTP/2 request path")
+	}
+	authority := frame.PseudoValue("authority")
+	requestURL.Scheme = "https"
+	requestURL.Host = authority
+	header := make(http.Header)
+	for _, field := range frame.RegularFields() {
+		header.Add(http.CanonicalHeaderKey(field.Name), field.Value)
+	}
+	if authority == "" {
+		authority = header.Get("Host")
+		requestURL.Host = authority
+	}
+	return &http.Request{
+		Method:     strings.ToUpper(frame.PseudoValue("method")),
+		URL:        requestURL,
+		Proto:      "HTTP/2.0",
+		ProtoMajor: 2,
+		Header:     header,
+		Body:       http.NoBody,
+		Host:       authority,
+		RequestURI: path,
+	}, nil
+}
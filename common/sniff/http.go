@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	std_http "net/http"
 
 	"github.com/sagernet/sing-box/adapter"
 	C "github.com/sagernet/sing-box/constant"
@@ -24,5 +25,27 @@ func HTTPHost(_ context.Context, metadata *adapter.InboundContext, reader io.Rea
 	}
 	metadata.Protocol = C.ProtocolHTTP
 	metadata.Domain = M.ParseSocksaddr(request.Host).AddrString()
+	// the body refers to sniff buffers that will be reused
+	request.Body = std_http.NoBody
+	metadata.SniffContext = request
 	return nil
 }
+
+// InterceptedRequest replaces the sniff context once a TLS connection is decrypted by the mitm
+// route option, keeping the client hello fingerprint next to the first decrypted request.
+type InterceptedRequest struct {
+	Fingerprint *TLSFingerprint
+	Request     *std_http.Request
+}
+
+// SniffedHTTPRequest returns the plaintext HTTP request sniffed by HTTPHost or decrypted by mitm, or nil.
+func SniffedHTTPRequest(metadata *adapter.InboundContext) *std_http.Request {
+	switch sniffContext := metadata.SniffContext.(type) {
+	case *std_http.Request:
+		return sniffContext
+	case *InterceptedRequest:
+		return sniffContext.Request
+	default:
+		return nil
+	}
+}
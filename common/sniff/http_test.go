@@ -28,3 +28,16 @@ func TestSniffHTTP1WithPort(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, metadata.Domain, "www.gov.cn")
 }
+
+func TestSniffHTTPRequest(t *testing.T) {
+	t.Parallel()
+	pkt := "GET /debian/pool/main/a/apt/apt_2.6.1_amd64.deb HTTP/1.1\r\nHost: deb.debian.org\r\nUser-Agent: Debian APT-HTTP/1.3 (2.6.1)\r\n\r\n"
+	var metadata adapter.InboundContext
+	err := sniff.HTTPHost(context.Background(), &metadata, strings.NewReader(pkt))
+	require.NoError(t, err)
+	request := sniff.SniffedHTTPRequest(&metadata)
+	require.NotNil(t, request)
+	require.Equal(t, "GET", request.Method)
+	require.Equal(t, "/debian/pool/main/a/apt/apt_2.6.1_amd64.deb", request.URL.Path)
+	require.Equal(t, "Debian APT-HTTP/1.3 (2.6.1)", request.UserAgent())
+}
@@ -0,0 +1,63 @@
+package sniff
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// MQTT recognizes the CONNECT packet of MQTT 3.1, 3.1.1 and 5.0.
+func MQTT(_ context.Context, metadata *adapter.InboundContext, reader io.Reader) error {
+	bReader := bufio.NewReader(reader)
+	packetType, err := bReader.ReadByte()
+	if err != nil {
+		return E.Cause1(ErrNeedMoreData, err)
+	}
+	if packetType != 0x10 {
+		return os.ErrInvalid
+	}
+	remainingLength, err := binary.ReadUvarint(bReader)
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return E.Cause1(ErrNeedMoreData, err)
+		}
+		return os.ErrInvalid
+	}
+	if remainingLength < 10 || remainingLength > 268435455 {
+		return os.ErrInvalid
+	}
+	var nameLength uint16
+	err = binary.Read(bReader, binary.BigEndian, &nameLength)
+	if err != nil {
+		return E.Cause1(ErrNeedMoreData, err)
+	}
+	if nameLength != 4 && nameLength != 6 {
+		return os.ErrInvalid
+	}
+	header := make([]byte, nameLength+1)
+	_, err = io.ReadFull(bReader, header)
+	if err != nil {
+		return E.Cause1(ErrNeedMoreData, err)
+	}
+	name, version := string(header[:nameLength]), header[nameLength]
+	switch name {
+	case "MQTT":
+		if version != 4 && version != 5 {
+			return os.ErrInvalid
+		}
+	case "MQIsdp":
+		if version != 3 {
+			return os.ErrInvalid
+		}
+	default:
+		return os.ErrInvalid
+	}
+	metadata.Protocol = C.ProtocolMQTT
+	return nil
+}
@@ -0,0 +1,54 @@
+package sniff_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffMQTT(t *testing.T) {
+	t.Parallel()
+	for _, pktHex := range []string{
+		// MQTT 3.1.1, captured from curl 7.88.1
+		"101800044d5154540402003c000c6375726c3431313735383738",
+		// MQTT 3.1, captured from paho.mqtt.golang v1.5.1
+		"101b00064d51497364700302001e000d73696e672d626f782d74657374",
+		// MQTT 3.1.1, captured from paho.mqtt.golang v1.5.1
+		"101900044d5154540402001e000d73696e672d626f782d74657374",
+		// MQTT 5.0, captured from paho.golang v0.22.0
+		"101a00044d5154540500003c00000d73696e672d626f782d74657374",
+	} {
+		pkt, err := hex.DecodeString(pktHex)
+		require.NoError(t, err)
+		var metadata adapter.InboundContext
+		err = sniff.MQTT(context.Background(), &metadata, bytes.NewReader(pkt))
+		require.NoError(t, err, pktHex)
+		require.Equal(t, C.ProtocolMQTT, metadata.Protocol)
+	}
+}
+
+func TestSniffIncompleteMQTT(t *testing.T) {
+	t.Parallel()
+	pkt, err := hex.DecodeString("101900044d51")
+	require.NoError(t, err)
+	var metadata adapter.InboundContext
+	err = sniff.MQTT(context.Background(), &metadata, bytes.NewReader(pkt))
+	require.ErrorIs(t, err, sniff.ErrNeedMoreData)
+}
+
+func TestSniffNotMQTT(t *testing.T) {
+	t.Parallel()
+	pkt, err := hex.DecodeString("101900044854545004")
+	require.NoError(t, err)
+	var metadata adapter.InboundContext
+	err = sniff.MQTT(context.Background(), &metadata, bytes.NewReader(pkt))
+	require.ErrorIs(t, err, os.ErrInvalid)
+}
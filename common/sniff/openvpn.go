@@ -0,0 +1,82 @@
+package sniff
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const (
+	openVPNHardResetClientV2 = 7
+	openVPNHardResetClientV3 = 10
+
+	// 2015-01-01, to tell the packet time of tls-auth and tls-crypt from random bytes.
+	openVPNMinimumTime = 1420070400
+)
+
+// OpenVPN recognizes the client hard reset that starts an OpenVPN session over UDP.
+func OpenVPN(_ context.Context, metadata *adapter.InboundContext, packet []byte) error {
+	if !isOpenVPNHardResetClient(packet) {
+		return os.ErrInvalid
+	}
+	metadata.Protocol = C.ProtocolOpenVPN
+	return nil
+}
+
+// StreamOpenVPN recognizes the client hard reset that starts an OpenVPN session over TCP.
+func StreamOpenVPN(_ context.Context, metadata *adapter.InboundContext, reader io.Reader) error {
+	var length uint16
+	err := binary.Read(reader, binary.BigEndian, &length)
+	if err != nil {
+		return E.Cause1(ErrNeedMoreData, err)
+	}
+	if length < 14 || length > 1024 {
+		return os.ErrInvalid
+	}
+	packet := make([]byte, length)
+	n, err := io.ReadFull(reader, packet)
+	if n > 0 && !isOpenVPNOpcode(packet[0]) {
+		return os.ErrInvalid
+	}
+	if err != nil {
+		return E.Cause1(ErrNeedMoreData, err)
+	}
+	if !isOpenVPNHardResetClient(packet) {
+		return os.ErrInvalid
+	}
+	metadata.Protocol = C.ProtocolOpenVPN
+	return nil
+}
+
+func isOpenVPNOpcode(header byte) bool {
+	opcode := header >> 3
+	keyID := header & 0x07
+	return keyID == 0 && (opcode == openVPNHardResetClientV2 || opcode == openVPNHardResetClientV3)
+}
+
+func isOpenVPNHardResetClient(packet []byte) bool {
+	// opcode and key id (1) | session id (8) | ...
+	if len(packet) < 14 || !isOpenVPNOpcode(packet[0]) {
+		return false
+	}
+	// without tls-auth: ack array length (1) | message packet id (4)
+	if packet[9] == 0 && binary.BigEndian.Uint32(packet[10:14]) == 0 {
+		return true
+	}
+	// tls-crypt: replay packet id (4) | time (4) | ...
+	// tls-auth: hmac (20, 32 or 64) | replay packet id (4) | time (4) | ...
+	for _, offset := range []int{9, 9 + 20, 9 + 32, 9 + 64} {
+		if len(packet) < offset+8 {
+			break
+		}
+		if binary.BigEndian.Uint32(packet[offset:]) == 1 && binary.BigEndian.Uint32(packet[offset+4:]) >= openVPNMinimumTime {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package sniff_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Client hard resets captured from minivpn v0.0.7 over UDP and TCP.
+const (
+	openVPNHardResetHex       = "38c8bd0da5dcc9a96f0000000000"
+	openVPNStreamHardResetHex = "000e3898c4eac70bf9a4e50000000000"
+)
+
+func openVPNHardReset(t *testing.T) []byte {
+	packet, err := hex.DecodeString(openVPNHardResetHex)
+	require.NoError(t, err)
+	return packet
+}
+
+// openVPNReplayHeader returns the packet id and time prepended by tls-auth and tls-crypt.
+func openVPNReplayHeader() []byte {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, 1)
+	binary.BigEndian.PutUint32(header[4:], uint32(time.Now().Unix()))
+	return header
+}
+
+// wrapOpenVPNTLSAuth wraps a control packet as OpenVPN does with tls-auth:
+// opcode | session id | hmac | packet id | time | payload, authenticating everything else.
+func wrapOpenVPNTLSAuth(t *testing.T, packet []byte, newHash func() hash.Hash) []byte {
+	key := make([]byte, 64)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	replayHeader := openVPNReplayHeader()
+	mac := hmac.New(newHash, key)
+	mac.Write(replayHeader)
+	mac.Write(packet)
+	result := append([]byte{}, packet[:9]...)
+	result = mac.Sum(result)
+	result = append(result, replayHeader...)
+	return append(result, packet[9:]...)
+}
+
+// wrapOpenVPNTLSCrypt wraps a control packet as OpenVPN does with tls-crypt:
+// opcode | session id | packet id | time | tag | encrypted payload.
+func wrapOpenVPNTLSCrypt(t *testing.T, packet []byte) []byte {
+	key := make([]byte, 64)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	header := append(append([]byte{}, packet[:9]...), openVPNReplayHeader()...)
+	mac := hmac.New(sha256.New, key[32:])
+	mac.Write(header)
+	mac.Write(packet[9:])
+	tag := mac.Sum(nil)
+	block, err := aes.NewCipher(key[:32])
+	require.NoError(t, err)
+	payload := make([]byte, len(packet)-9)
+	cipher.NewCTR(block, tag[:aes.BlockSize]).XORKeyStream(payload, packet[9:])
+	return append(append(header, tag...), payload...)
+}
+
+// wrapOpenVPNTLSCryptV2 appends the wrapped client key and its length to a
+// tls-crypt packet with the P_CONTROL_HARD_RESET_CLIENT_V3 opcode.
+func wrapOpenVPNTLSCryptV2(t *testing.T, packet []byte) []byte {
+	packet = append([]byte{10 << 3}, packet[1:]...)
+	wrappedKey := make([]byte, 290)
+	_, err := rand.Read(wrappedKey)
+	require.NoError(t, err)
+	binary.BigEndian.PutUint16(wrappedKey[len(wrappedKey)-2:], uint16(len(wrappedKey)))
+	return append(wrapOpenVPNTLSCrypt(t, packet), wrappedKey...)
+}
+
+func TestSniffOpenVPN(t *testing.T) {
+	t.Parallel()
+	hardReset := openVPNHardReset(t)
+	for name, packet := range map[string][]byte{
+		"plain":           hardReset,
+		"tls-auth SHA1":   wrapOpenVPNTLSAuth(t, hardReset, sha1.New),
+		"tls-auth SHA256": wrapOpenVPNTLSAuth(t, hardReset, sha256.New),
+		"tls-auth SHA512": wrapOpenVPNTLSAuth(t, hardReset, sha512.New),
+		"tls-crypt":       wrapOpenVPNTLSCrypt(t, hardReset),
+		"tls-crypt-v2":    wrapOpenVPNTLSCryptV2(t, hardReset),
+	} {
+		var metadata adapter.InboundContext
+		err := sniff.OpenVPN(context.Background(), &metadata, packet)
+		require.NoError(t, err, name)
+		require.Equal(t, C.ProtocolOpenVPN, metadata.Protocol, name)
+	}
+}
+
+func TestSniffOpenVPNFailed(t *testing.T) {
+	t.Parallel()
+	dnsQuery, err := hex.DecodeString("123401000001000000000000076578616d706c6503636f6d0000010001")
+	require.NoError(t, err)
+	nonZeroKeyID := openVPNHardReset(t)
+	nonZeroKeyID[0] |= 1
+	randomPayload := openVPNHardReset(t)
+	_, err = rand.Read(randomPayload[9:])
+	require.NoError(t, err)
+	randomPayload[13] |= 1
+	for name, packet := range map[string][]byte{
+		"dns query":            dnsQuery,
+		"non-zero key id":      nonZeroKeyID,
+		"random payload":       randomPayload,
+		"truncated hard reset": openVPNHardReset(t)[:10],
+	} {
+		var metadata adapter.InboundContext
+		err = sniff.OpenVPN(context.Background(), &metadata, packet)
+		require.ErrorIs(t, err, os.ErrInvalid, name)
+	}
+}
+
+func TestSniffStreamOpenVPN(t *testing.T) {
+	t.Parallel()
+	packet, err := hex.DecodeString(openVPNStreamHardResetHex)
+	require.NoError(t, err)
+	var metadata adapter.InboundContext
+	err = sniff.StreamOpenVPN(context.Background(), &metadata, bytes.NewReader(packet))
+	require.NoError(t, err)
+	require.Equal(t, C.ProtocolOpenVPN, metadata.Protocol)
+}
+
+func TestSniffIncompleteStreamOpenVPN(t *testing.T) {
+	t.Parallel()
+	packet, err := hex.DecodeString(openVPNStreamHardResetHex)
+	require.NoError(t, err)
+	var metadata adapter.InboundContext
+	err = sniff.StreamOpenVPN(context.Background(), &metadata, bytes.NewReader(packet[:7]))
+	require.ErrorIs(t, err, sniff.ErrNeedMoreData)
+}
+
+func TestSniffNotStreamOpenVPN(t *testing.T) {
+	t.Parallel()
+	packet, err := hex.DecodeString("160301")
+	require.NoError(t, err)
+	var metadata adapter.InboundContext
+	err = sniff.StreamOpenVPN(context.Background(), &metadata, bytes.NewReader(packet))
+	require.ErrorIs(t, err, os.ErrInvalid)
+}
@@ -0,0 +1,25 @@
+package sniff
+
+import (
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+)
+
+// PortProtocol classifies the connection by its well-known server-first
+// destination port, and returns an empty string for other ports.
+//
+// This is port classification, not sniffing: mail clients send nothing before
+// the server greeting, and STARTTLS is only negotiated after it, so SMTP, IMAP
+// and POP3 can not be recognized from the client data, and the payload is never
+// inspected.
+func PortProtocol(metadata *adapter.InboundContext) string {
+	switch metadata.Destination.Port {
+	case 25, 465, 587:
+		return C.ProtocolSMTP
+	case 143, 993:
+		return C.ProtocolIMAP
+	case 110, 995:
+		return C.ProtocolPOP3
+	}
+	return ""
+}
@@ -0,0 +1,32 @@
+package sniff_test
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortProtocol(t *testing.T) {
+	t.Parallel()
+	for port, protocol := range map[uint16]string{
+		25:  C.ProtocolSMTP,
+		465: C.ProtocolSMTP,
+		587: C.ProtocolSMTP,
+		143: C.ProtocolIMAP,
+		993: C.ProtocolIMAP,
+		110: C.ProtocolPOP3,
+		995: C.ProtocolPOP3,
+		443: "",
+	} {
+		metadata := adapter.InboundContext{
+			Destination: M.ParseSocksaddrHostPort("127.0.0.1", port),
+		}
+		require.Equal(t, protocol, sniff.PortProtocol(&metadata))
+		require.Equal(t, protocol != "", sniff.Skip(&metadata))
+	}
+}
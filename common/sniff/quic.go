@@ -273,8 +273,8 @@ func QUICClientHello(ctx context.Context, metadata *adapter.InboundContext, pack
 			return os.ErrInvalid
 		}
 	}
-	if metadata.SniffContext != nil {
-		fragments = append(fragments, metadata.SniffContext.([]qCryptoFragment)...)
+	if previousFragments, loaded := metadata.SniffContext.([]qCryptoFragment); loaded {
+		fragments = append(fragments, previousFragments...)
 		metadata.SniffContext = nil
 	}
 	var frameLen uint64
@@ -307,6 +307,7 @@ find:
 		return E.Cause1(ErrNeedMoreData, err)
 	}
 	metadata.Domain = fingerprint.ServerName
+	metadata.SniffContext = newTLSFingerprint(fingerprint, ja3.JA4ProtocolQUIC)
 	for metadata.Client == "" {
 		if len(frameTypeList) == 1 {
 			metadata.Client = C.ClientFirefox
@@ -0,0 +1,32 @@
+package sniff
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const rtmpHandshakeSize = 1536
+
+// RTMP recognizes the C0 and C1 chunks of the RTMP handshake.
+// The client waits for S0 and S1 after sending them, so nothing may follow.
+func RTMP(_ context.Context, metadata *adapter.InboundContext, reader io.Reader) error {
+	handshake := make([]byte, 1+rtmpHandshakeSize+1)
+	n, err := io.ReadFull(reader, handshake)
+	if n > 0 && handshake[0] != 0x03 {
+		return os.ErrInvalid
+	}
+	switch n {
+	case 1 + rtmpHandshakeSize:
+	case len(handshake):
+		return os.ErrInvalid
+	default:
+		return E.Cause1(ErrNeedMoreData, err)
+	}
+	metadata.Protocol = C.ProtocolRTMP
+	return nil
+}
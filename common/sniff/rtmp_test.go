@@ -0,0 +1,47 @@
+package sniff_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readRTMPHandshake returns the C0 and C1 chunks captured from curl 7.88.1 with librtmp 2.3.
+func readRTMPHandshake(t *testing.T) []byte {
+	pkt, err := os.ReadFile("testdata/rtmp_librtmp.bin")
+	require.NoError(t, err)
+	return pkt
+}
+
+func TestSniffRTMP(t *testing.T) {
+	t.Parallel()
+	var metadata adapter.InboundContext
+	err := sniff.RTMP(context.Background(), &metadata, bytes.NewReader(readRTMPHandshake(t)))
+	require.NoError(t, err)
+	require.Equal(t, C.ProtocolRTMP, metadata.Protocol)
+}
+
+func TestSniffIncompleteRTMP(t *testing.T) {
+	t.Parallel()
+	var metadata adapter.InboundContext
+	err := sniff.RTMP(context.Background(), &metadata, bytes.NewReader(readRTMPHandshake(t)[:1024]))
+	require.ErrorIs(t, err, sniff.ErrNeedMoreData)
+}
+
+func TestSniffNotRTMP(t *testing.T) {
+	t.Parallel()
+	var metadata adapter.InboundContext
+	err := sniff.RTMP(context.Background(), &metadata, bytes.NewReader(append(readRTMPHandshake(t), 0x03)))
+	require.ErrorIs(t, err, os.ErrInvalid)
+	pkt := readRTMPHandshake(t)
+	pkt[0] = 0x16
+	err = sniff.RTMP(context.Background(), &metadata, bytes.NewReader(pkt))
+	require.ErrorIs(t, err, os.ErrInvalid)
+}
@@ -24,18 +24,7 @@ var ErrNeedMoreData = E.New("need more data")
 
 func Skip(metadata *adapter.InboundContext) bool {
 	// skip server first protocols
-	switch metadata.Destination.Port {
-	case 25, 465, 587:
-		// SMTP
-		return true
-	case 143, 993:
-		// IMAP
-		return true
-	case 110, 995:
-		// POP3
-		return true
-	}
-	return false
+	return PortProtocol(metadata) != ""
 }
 
 func PeekStream(ctx context.Context, metadata *adapter.InboundContext, conn net.Conn, buffers []*buf.Buffer, buffer *buf.Buffer, timeout time.Duration, sniffers ...StreamSniffer) error {
@@ -0,0 +1,94 @@
+package sniff
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// SOCKS recognizes SOCKS4/4a requests and SOCKS5 method negotiations.
+func SOCKS(_ context.Context, metadata *adapter.InboundContext, reader io.Reader) error {
+	bReader := bufio.NewReader(reader)
+	version, err := bReader.ReadByte()
+	if err != nil {
+		return E.Cause1(ErrNeedMoreData, err)
+	}
+	switch version {
+	case 4:
+		err = sniffSOCKS4(bReader)
+	case 5:
+		err = sniffSOCKS5(bReader)
+	default:
+		return os.ErrInvalid
+	}
+	if err != nil {
+		return err
+	}
+	metadata.Protocol = C.ProtocolSOCKS
+	return nil
+}
+
+func sniffSOCKS4(reader *bufio.Reader) error {
+	// command (1) | port (2) | address (4) | user id | 0 | [domain | 0]
+	header := make([]byte, 7)
+	_, err := io.ReadFull(reader, header)
+	if err != nil {
+		return E.Cause1(ErrNeedMoreData, err)
+	}
+	if header[0] != 1 && header[0] != 2 {
+		return os.ErrInvalid
+	}
+	err = readSOCKS4String(reader)
+	if err != nil {
+		return err
+	}
+	if header[3] == 0 && header[4] == 0 && header[5] == 0 && header[6] != 0 {
+		return readSOCKS4String(reader)
+	}
+	return nil
+}
+
+func readSOCKS4String(reader *bufio.Reader) error {
+	const maxStringLength = 256
+	for range maxStringLength {
+		c, err := reader.ReadByte()
+		if err != nil {
+			return E.Cause1(ErrNeedMoreData, err)
+		}
+		if c == 0 {
+			return nil
+		}
+	}
+	return os.ErrInvalid
+}
+
+func sniffSOCKS5(reader *bufio.Reader) error {
+	// method count (1) | methods
+	methodCount, err := reader.ReadByte()
+	if err != nil {
+		return E.Cause1(ErrNeedMoreData, err)
+	}
+	if methodCount == 0 {
+		return os.ErrInvalid
+	}
+	methods := make([]byte, methodCount)
+	_, err = io.ReadFull(reader, methods)
+	if err != nil {
+		return E.Cause1(ErrNeedMoreData, err)
+	}
+	if slices.Contains(methods, 0xff) {
+		return os.ErrInvalid
+	}
+	// the client waits for the server to choose a method
+	_, err = reader.ReadByte()
+	if err != io.EOF {
+		return os.ErrInvalid
+	}
+	return nil
+}
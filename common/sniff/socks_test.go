@@ -0,0 +1,105 @@
+package sniff_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/protocol/socks"
+
+	"github.com/stretchr/testify/require"
+)
+
+func captureSOCKSHandshake(t *testing.T, version socks.Version, username string, password string) []byte {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	client := socks.NewClient(N.SystemDialer, M.SocksaddrFromNet(listener.Addr()), version, username, password)
+	go func() {
+		conn, _ := client.DialContext(ctx, N.NetworkTCP, M.ParseSocksaddr("example.com:443"))
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buffer := make([]byte, 1024)
+	n, err := conn.Read(buffer)
+	require.NoError(t, err)
+	return buffer[:n]
+}
+
+func TestSniffSOCKSCapture(t *testing.T) {
+	t.Parallel()
+	for _, pkt := range [][]byte{
+		captureSOCKSHandshake(t, socks.Version5, "", ""),
+		captureSOCKSHandshake(t, socks.Version5, "user", "password"),
+		captureSOCKSHandshake(t, socks.Version4A, "user", ""),
+	} {
+		var metadata adapter.InboundContext
+		err := sniff.SOCKS(context.Background(), &metadata, bytes.NewReader(pkt))
+		require.NoError(t, err, hex.EncodeToString(pkt))
+		require.Equal(t, C.ProtocolSOCKS, metadata.Protocol)
+	}
+}
+
+func TestSniffSOCKSCurl(t *testing.T) {
+	t.Parallel()
+	// captured from curl 7.88.1
+	for _, pktHex := range []string{
+		// --socks4
+		"04010050c000020100",
+		// --socks4a
+		"0401005000000001006578616d706c652e636f6d00",
+		// --socks5-hostname
+		"05020001",
+		// --socks5-hostname with --proxy-user
+		"0503000102",
+	} {
+		pkt, err := hex.DecodeString(pktHex)
+		require.NoError(t, err)
+		var metadata adapter.InboundContext
+		err = sniff.SOCKS(context.Background(), &metadata, bytes.NewReader(pkt))
+		require.NoError(t, err, pktHex)
+		require.Equal(t, C.ProtocolSOCKS, metadata.Protocol)
+	}
+}
+
+func TestSniffIncompleteSOCKS(t *testing.T) {
+	t.Parallel()
+	pkt, err := hex.DecodeString("050300")
+	require.NoError(t, err)
+	var metadata adapter.InboundContext
+	err = sniff.SOCKS(context.Background(), &metadata, bytes.NewReader(pkt))
+	require.ErrorIs(t, err, sniff.ErrNeedMoreData)
+}
+
+func TestSniffNotSOCKS(t *testing.T) {
+	t.Parallel()
+	for _, pktHex := range []string{
+		"050100050100030b6578616d706c652e636f6d01bb",
+		"0501ff",
+		"160301",
+	} {
+		pkt, err := hex.DecodeString(pktHex)
+		require.NoError(t, err)
+		var metadata adapter.InboundContext
+		err = sniff.SOCKS(context.Background(), &metadata, bytes.NewReader(pkt))
+		require.ErrorIs(t, err, os.ErrInvalid, pktHex)
+	}
+}
@@ -1,20 +1,55 @@
 package sniff
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"errors"
 	"io"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/ja3"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing/common/bufio"
 	E "github.com/sagernet/sing/common/exceptions"
 )
 
+// TLSFingerprint is the fingerprint of a sniffed TLS or QUIC client hello.
+type TLSFingerprint struct {
+	JA3     string
+	JA4     string
+	ALPN    []string
+	Version uint16
+}
+
+func newTLSFingerprint(clientHello *ja3.ClientHello, protocol byte) *TLSFingerprint {
+	return &TLSFingerprint{
+		JA3:     clientHello.Hash(),
+		JA4:     clientHello.JA4(protocol),
+		ALPN:    clientHello.ALPN,
+		Version: clientHello.MaxVersion(),
+	}
+}
+
+// SniffedTLSFingerprint returns the fingerprint of the client hello sniffed by TLSClientHello
+// or QUICClientHello, or nil.
+func SniffedTLSFingerprint(metadata *adapter.InboundContext) *TLSFingerprint {
+	switch sniffContext := metadata.SniffContext.(type) {
+	case *TLSFingerprint:
+		return sniffContext
+	case *InterceptedRequest:
+		return sniffContext.Fingerprint
+	default:
+		return nil
+	}
+}
+
 func TLSClientHello(ctx context.Context, metadata *adapter.InboundContext, reader io.Reader) error {
-	var clientHello *tls.ClientHelloInfo
-	err := tls.Server(bufio.NewReadOnlyConn(reader), &tls.Config{
+	var (
+		clientHello *tls.ClientHelloInfo
+		payload     bytes.Buffer
+	)
+	err := tls.Server(bufio.NewReadOnlyConn(io.TeeReader(reader, &payload)), &tls.Config{
 		GetConfigForClient: func(argHello *tls.ClientHelloInfo) (*tls.Config, error) {
 			clientHello = argHello
 			return nil, nil
@@ -23,6 +58,11 @@ func TLSClientHello(ctx context.Context, metadata *adapter.InboundContext, reade
 	if clientHello != nil {
 		metadata.Protocol = C.ProtocolTLS
 		metadata.Domain = clientHello.ServerName
+		// client hellos split into several records are left without a fingerprint
+		fingerprint, err := ja3.Compute(payload.Bytes())
+		if err == nil {
+			metadata.SniffContext = newTLSFingerprint(fingerprint, ja3.JA4ProtocolTCP)
+		}
 		return nil
 	}
 	if errors.Is(err, io.ErrUnexpectedEOF) {
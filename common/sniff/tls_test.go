@@ -0,0 +1,47 @@
+package sniff_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffTLSFingerprint(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	go func() {
+		tls.Client(clientConn, &tls.Config{
+			ServerName: "example.com",
+			NextProtos: []string{"h2", "http/1.1"},
+		}).Handshake()
+		clientConn.Close()
+	}()
+	serverConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buffer := make([]byte, 4096)
+	n, err := serverConn.Read(buffer)
+	require.NoError(t, err)
+
+	var metadata adapter.InboundContext
+	err = sniff.TLSClientHello(context.Background(), &metadata, bytes.NewReader(buffer[:n]))
+	require.NoError(t, err)
+	require.Equal(t, C.ProtocolTLS, metadata.Protocol)
+	require.Equal(t, "example.com", metadata.Domain)
+	fingerprint := sniff.SniffedTLSFingerprint(&metadata)
+	require.NotNil(t, fingerprint)
+	require.Len(t, fingerprint.JA3, 32)
+	require.True(t, strings.HasPrefix(fingerprint.JA4, "t13d"), fingerprint.JA4)
+	require.True(t, strings.HasSuffix(strings.Split(fingerprint.JA4, "_")[0], "h2"), fingerprint.JA4)
+	require.Equal(t, []string{"h2", "http/1.1"}, fingerprint.ALPN)
+	require.Equal(t, uint16(tls.VersionTLS13), fingerprint.Version)
+}
@@ -0,0 +1,129 @@
+package sniff
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const (
+	wireGuardInitiationSize = 148
+	wireGuardResponseSize   = 92
+	wireGuardCookieSize     = 64
+)
+
+type wireGuardHeader struct {
+	start uint32
+	end   uint32
+}
+
+func parseWireGuardHeader(spec string, defaultValue uint32) (wireGuardHeader, error) {
+	if spec == "" {
+		return wireGuardHeader{defaultValue, defaultValue}, nil
+	}
+	startString, endString, isRange := strings.Cut(spec, "-")
+	start, err := strconv.ParseUint(startString, 10, 32)
+	if err != nil {
+		return wireGuardHeader{}, E.Cause(err, "parse header: ", spec)
+	}
+	if !isRange {
+		return wireGuardHeader{uint32(start), uint32(start)}, nil
+	}
+	end, err := strconv.ParseUint(endString, 10, 32)
+	if err != nil {
+		return wireGuardHeader{}, E.Cause(err, "parse header: ", spec)
+	}
+	if end < start {
+		return wireGuardHeader{}, E.New("invalid header range: ", spec)
+	}
+	return wireGuardHeader{uint32(start), uint32(end)}, nil
+}
+
+func (h wireGuardHeader) match(value uint32) bool {
+	return h.start <= value && value <= h.end
+}
+
+type wireGuardMessage struct {
+	header  wireGuardHeader
+	padding int
+	size    int
+}
+
+func (m wireGuardMessage) match(packet []byte) bool {
+	return len(packet) == m.padding+m.size && m.header.match(binary.LittleEndian.Uint32(packet[m.padding:]))
+}
+
+type wireGuardSniffer struct {
+	messages []wireGuardMessage
+	junkMin  int
+	junkMax  int
+	client   string
+}
+
+func (s *wireGuardSniffer) sniff(_ context.Context, metadata *adapter.InboundContext, packet []byte) error {
+	for _, message := range s.messages {
+		if message.match(packet) {
+			metadata.Protocol = C.ProtocolWireGuard
+			metadata.Client = s.client
+			return nil
+		}
+	}
+	if s.junkMax > 0 && len(packet) >= s.junkMin && len(packet) <= s.junkMax {
+		return ErrNeedMoreData
+	}
+	return os.ErrInvalid
+}
+
+var wireGuard = &wireGuardSniffer{
+	messages: []wireGuardMessage{
+		{header: wireGuardHeader{1, 1}, size: wireGuardInitiationSize},
+		{header: wireGuardHeader{2, 2}, size: wireGuardResponseSize},
+		{header: wireGuardHeader{3, 3}, size: wireGuardCookieSize},
+	},
+}
+
+// WireGuard recognizes WireGuard handshake messages.
+func WireGuard(ctx context.Context, metadata *adapter.InboundContext, packet []byte) error {
+	return wireGuard.sniff(ctx, metadata, packet)
+}
+
+// NewAmneziaWG creates a sniffer for AmneziaWG handshake messages with known
+// obfuscation parameters. Junk packets sent before the handshake are skipped.
+func NewAmneziaWG(options option.SniffAmneziaWGOptions) (PacketSniffer, error) {
+	if options.Jmin < 0 || options.Jmax < options.Jmin {
+		return nil, E.New("invalid junk packet size range: ", options.Jmin, "-", options.Jmax)
+	}
+	if options.S1 < 0 || options.S2 < 0 || options.S3 < 0 {
+		return nil, E.New("invalid negative padding")
+	}
+	initHeader, err := parseWireGuardHeader(options.H1, 1)
+	if err != nil {
+		return nil, E.Cause(err, "h1")
+	}
+	responseHeader, err := parseWireGuardHeader(options.H2, 2)
+	if err != nil {
+		return nil, E.Cause(err, "h2")
+	}
+	cookieHeader, err := parseWireGuardHeader(options.H3, 3)
+	if err != nil {
+		return nil, E.Cause(err, "h3")
+	}
+	sniffer := &wireGuardSniffer{
+		messages: []wireGuardMessage{
+			{header: initHeader, padding: options.S1, size: wireGuardInitiationSize},
+			{header: responseHeader, padding: options.S2, size: wireGuardResponseSize},
+			{header: cookieHeader, padding: options.S3, size: wireGuardCookieSize},
+		},
+		junkMin: options.Jmin,
+		junkMax: options.Jmax,
+		client:  C.ClientAmneziaWG,
+	}
+	return sniffer.sniff, nil
+}
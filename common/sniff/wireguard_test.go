@@ -0,0 +1,122 @@
+package sniff_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/amnezia-vpn/amneziawg-go/conn"
+	"github.com/amnezia-vpn/amneziawg-go/device"
+	"github.com/amnezia-vpn/amneziawg-go/tun/tuntest"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/curve25519"
+)
+
+func captureWireGuardPackets(t *testing.T, config string, count int) [][]byte {
+	udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+	require.NoError(t, err)
+	defer udpConn.Close()
+
+	privateKey := make([]byte, curve25519.ScalarSize)
+	_, err = rand.Read(privateKey)
+	require.NoError(t, err)
+	peerPrivateKey := make([]byte, curve25519.ScalarSize)
+	_, err = rand.Read(peerPrivateKey)
+	require.NoError(t, err)
+	peerPublicKey, err := curve25519.X25519(peerPrivateKey, curve25519.Basepoint)
+	require.NoError(t, err)
+
+	wgDevice := device.NewDevice(tuntest.NewChannelTUN().TUN(), conn.NewDefaultBind(), device.NewLogger(device.LogLevelSilent, ""))
+	defer wgDevice.Close()
+	err = wgDevice.IpcSet("private_key=" + hex.EncodeToString(privateKey) + "\n" + config +
+		"public_key=" + hex.EncodeToString(peerPublicKey) + "\n" +
+		"endpoint=" + udpConn.LocalAddr().String() + "\n" +
+		"allowed_ip=10.0.0.2/32\n" +
+		"persistent_keepalive_interval=1\n")
+	require.NoError(t, err)
+	require.NoError(t, wgDevice.Up())
+
+	var packets [][]byte
+	udpConn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for range count {
+		buffer := make([]byte, 2048)
+		n, _, err := udpConn.ReadFromUDP(buffer)
+		require.NoError(t, err)
+		packets = append(packets, buffer[:n])
+	}
+	return packets
+}
+
+func TestSniffWireGuardCapture(t *testing.T) {
+	t.Parallel()
+	packets := captureWireGuardPackets(t, "", 1)
+	var metadata adapter.InboundContext
+	err := sniff.WireGuard(context.Background(), &metadata, packets[0])
+	require.NoError(t, err)
+	require.Equal(t, C.ProtocolWireGuard, metadata.Protocol)
+	require.Empty(t, metadata.Client)
+}
+
+func TestSniffAmneziaWGCapture(t *testing.T) {
+	t.Parallel()
+	packets := captureWireGuardPackets(t, "jc=3\njmin=40\njmax=70\ns1=15\ns2=68\nh1=1020325451\nh2=3288052141\nh3=1766607858\nh4=2528465083\n", 4)
+	sniffer, err := sniff.NewAmneziaWG(option.SniffAmneziaWGOptions{
+		Jmin: 40,
+		Jmax: 70,
+		S1:   15,
+		S2:   68,
+		H1:   "1020325451",
+		H2:   "3288052141",
+		H3:   "1766607858",
+	})
+	require.NoError(t, err)
+	var metadata adapter.InboundContext
+	for _, packet := range packets[:3] {
+		require.ErrorIs(t, sniff.PeekPacket(context.Background(), &metadata, packet, sniff.WireGuard, sniffer), sniff.ErrNeedMoreData)
+	}
+	err = sniff.PeekPacket(context.Background(), &metadata, packets[3], sniff.WireGuard, sniffer)
+	require.NoError(t, err)
+	require.Equal(t, C.ProtocolWireGuard, metadata.Protocol)
+	require.Equal(t, C.ClientAmneziaWG, metadata.Client)
+}
+
+func TestSniffAmneziaWGHeaderRange(t *testing.T) {
+	t.Parallel()
+	packets := captureWireGuardPackets(t, "s1=20\nh1=100000-200000\nh2=200001-300000\nh3=300001-400000\nh4=400001-500000\n", 1)
+	sniffer, err := sniff.NewAmneziaWG(option.SniffAmneziaWGOptions{
+		S1: 20,
+		H1: "100000-200000",
+	})
+	require.NoError(t, err)
+	var metadata adapter.InboundContext
+	require.ErrorIs(t, sniff.WireGuard(context.Background(), &metadata, packets[0]), os.ErrInvalid)
+	err = sniffer(context.Background(), &metadata, packets[0])
+	require.NoError(t, err)
+	require.Equal(t, C.ProtocolWireGuard, metadata.Protocol)
+}
+
+func TestSniffNotWireGuard(t *testing.T) {
+	t.Parallel()
+	packet, err := hex.DecodeString("04000000b1c0a9e3d74a35ecbf10a1a8e1e93c0ad6bb1ebf4fd1f4be2e6e4b2e55c6f2a1")
+	require.NoError(t, err)
+	var metadata adapter.InboundContext
+	err = sniff.WireGuard(context.Background(), &metadata, packet)
+	require.ErrorIs(t, err, os.ErrInvalid)
+}
+
+func TestSniffAmneziaWGInvalidOptions(t *testing.T) {
+	t.Parallel()
+	_, err := sniff.NewAmneziaWG(option.SniffAmneziaWGOptions{H1: "200-100"})
+	require.Error(t, err)
+	_, err = sniff.NewAmneziaWG(option.SniffAmneziaWGOptions{Jmin: 100, Jmax: 50})
+	require.Error(t, err)
+}
@@ -67,11 +67,14 @@ func Read(reader io.Reader, recover bool) (ruleSetCompat option.PlainRuleSetComp
 	if version > C.RuleSetVersionCurrent {
 		return ruleSetCompat, E.New("unsupported version: ", version)
 	}
-	compressReader, err := zlib.NewReader(reader)
-	if err != nil {
-		return
+	contentReader := reader
+	if version < C.RuleSetVersion5 {
+		contentReader, err = zlib.NewReader(reader)
+		if err != nil {
+			return
+		}
 	}
-	bReader := bufio.NewReader(compressReader)
+	bReader := bufio.NewReader(contentReader)
 	length, err := binary.ReadUvarint(bReader)
 	if err != nil {
 		return
@@ -97,11 +100,16 @@ func Write(writer io.Writer, ruleSet option.PlainRuleSet, generateVersion uint8)
 	if err != nil {
 		return err
 	}
-	compressWriter, err := zlib.NewWriterLevel(writer, zlib.BestCompression)
-	if err != nil {
-		return err
+	var compressWriter *zlib.Writer
+	contentWriter := writer
+	if generateVersion < C.RuleSetVersion5 {
+		compressWriter, err = zlib.NewWriterLevel(writer, zlib.BestCompression)
+		if err != nil {
+			return err
+		}
+		contentWriter = compressWriter
 	}
-	bWriter := bufio.NewWriter(compressWriter)
+	bWriter := bufio.NewWriter(contentWriter)
 	_, err = varbin.WriteUvarint(bWriter, uint64(len(ruleSet.Rules)))
 	if err != nil {
 		return err
@@ -116,7 +124,10 @@ func Write(writer io.Writer, ruleSet option.PlainRuleSet, generateVersion uint8)
 	if err != nil {
 		return err
 	}
-	return compressWriter.Close()
+	if compressWriter != nil {
+		return compressWriter.Close()
+	}
+	return nil
 }
 
 func readRule(reader varbin.Reader, recover bool) (rule option.HeadlessRule, err error) {
@@ -0,0 +1,20 @@
+package srs
+
+import (
+	"io"
+
+	"github.com/sagernet/sing-box/option"
+
+	"golang.org/x/exp/mmap"
+)
+
+// ReadFile reads a binary rule-set through a memory-mapped file, so that rule-sets
+// stored uncompressed since version 5 are parsed without reading the file into memory first.
+func ReadFile(path string, recover bool) (option.PlainRuleSetCompat, error) {
+	file, err := mmap.Open(path)
+	if err != nil {
+		return option.PlainRuleSetCompat{}, err
+	}
+	defer file.Close()
+	return Read(io.NewSectionReader(file, 0, int64(file.Len())), recover)
+}
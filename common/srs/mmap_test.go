@@ -0,0 +1,47 @@
+package srs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFileUncompressed(t *testing.T) {
+	t.Parallel()
+	ruleSet := option.PlainRuleSet{Rules: []option.HeadlessRule{{
+		Type: C.RuleTypeDefault,
+		DefaultOptions: option.DefaultHeadlessRule{
+			Domain:       []string{"a.example"},
+			DomainSuffix: []string{"b.example"},
+			IPCIDR:       []string{"10.0.0.0/8"},
+		},
+	}}}
+	for _, version := range []uint8{C.RuleSetVersion4, C.RuleSetVersion5} {
+		var buffer bytes.Buffer
+		require.NoError(t, Write(&buffer, ruleSet, version))
+		content := buffer.Bytes()
+		require.Equal(t, version, content[3])
+		if version == C.RuleSetVersion5 {
+			require.Equal(t, byte(len(ruleSet.Rules)), content[4], "rules must follow the header without compression")
+		}
+		path := filepath.Join(t.TempDir(), "rule-set.srs")
+		require.NoError(t, os.WriteFile(path, content, 0o644))
+		compat, err := ReadFile(path, true)
+		require.NoError(t, err)
+		require.Equal(t, version, compat.Version)
+		rules := compat.Options.Rules
+		require.Len(t, rules, 1)
+		require.Equal(t, []string{"a.example"}, []string(rules[0].DefaultOptions.Domain))
+		require.Equal(t, []string{"b.example"}, []string(rules[0].DefaultOptions.DomainSuffix))
+		require.Equal(t, []string{"10.0.0.0/8"}, []string(rules[0].DefaultOptions.IPCIDR))
+		require.True(t, rules[0].DefaultOptions.DomainMatcher.Match("www.b.example"))
+	}
+	_, err := ReadFile(filepath.Join(t.TempDir(), "missing.srs"), false)
+	require.Error(t, err)
+}
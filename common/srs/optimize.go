@@ -0,0 +1,150 @@
+package srs
+
+import (
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json/badoption"
+)
+
+type OptimizeStatistics struct {
+	// DuplicateItems counts items repeated in the same rule.
+	DuplicateItems int
+	// RedundantDomains counts domain and domain_suffix items covered by a domain_suffix in the same rule.
+	RedundantDomains int
+}
+
+// Optimize removes rule items which never change the result of their rule,
+// so that the compiled domain matcher stays small.
+// Domains are only pruned since version 2, as legacy domain_suffix items match differently.
+func Optimize(rules []option.HeadlessRule, version uint8) OptimizeStatistics {
+	var statistics OptimizeStatistics
+	for i := range rules {
+		optimizeRule(&rules[i], version, &statistics)
+	}
+	return statistics
+}
+
+func optimizeRule(rule *option.HeadlessRule, version uint8, statistics *OptimizeStatistics) {
+	switch rule.Type {
+	case C.RuleTypeDefault:
+		options := &rule.DefaultOptions
+		statistics.DuplicateItems += deduplicate(&options.Domain) +
+			deduplicate(&options.DomainSuffix) +
+			deduplicate(&options.DomainKeyword) +
+			deduplicate(&options.DomainRegex) +
+			deduplicate(&options.SourceIPCIDR) +
+			deduplicate(&options.IPCIDR) +
+			deduplicate(&options.SourcePort) +
+			deduplicate(&options.SourcePortRange) +
+			deduplicate(&options.Port) +
+			deduplicate(&options.PortRange) +
+			deduplicate(&options.ProcessName) +
+			deduplicate(&options.ProcessPath) +
+			deduplicate(&options.ProcessPathRegex) +
+			deduplicate(&options.PackageName)
+		if version >= C.RuleSetVersion2 {
+			statistics.RedundantDomains += pruneDomains(options)
+		}
+	case C.RuleTypeLogical:
+		for i := range rule.LogicalOptions.Rules {
+			optimizeRule(&rule.LogicalOptions.Rules[i], version, statistics)
+		}
+	}
+}
+
+func deduplicate[T comparable](items *badoption.Listable[T]) int {
+	if len(*items) < 2 {
+		return 0
+	}
+	seen := make(map[T]bool, len(*items))
+	result := (*items)[:0]
+	for _, item := range *items {
+		if seen[item] {
+			continue
+		}
+		seen[item] = true
+		result = append(result, item)
+	}
+	removed := len(*items) - len(result)
+	*items = result
+	return removed
+}
+
+// pruneDomains removes domains and domain suffixes covered by another domain suffix.
+// A domain_suffix example.org matches example.org and its subdomains,
+// while .example.org only matches subdomains.
+func pruneDomains(options *option.DefaultHeadlessRule) int {
+	if len(options.DomainSuffix) == 0 {
+		return 0
+	}
+	var (
+		exactDomains      = make(map[string]bool, len(options.Domain))
+		suffixes          = make(map[string]bool)
+		subdomainSuffixes = make(map[string]bool)
+		mergedDomains     = make(map[string]bool)
+		removed           int
+		domains           []string
+		domainSuffix      []string
+	)
+	for _, domain := range options.Domain {
+		exactDomains[domain] = true
+	}
+	for _, suffix := range options.DomainSuffix {
+		if subdomain, isSubdomain := strings.CutPrefix(suffix, "."); isSubdomain {
+			subdomainSuffixes[subdomain] = true
+		} else {
+			suffixes[suffix] = true
+		}
+	}
+	// a domain together with its subdomains is a single domain_suffix
+	for subdomain := range subdomainSuffixes {
+		if exactDomains[subdomain] && !suffixes[subdomain] {
+			suffixes[subdomain] = true
+			mergedDomains[subdomain] = true
+			delete(subdomainSuffixes, subdomain)
+			delete(exactDomains, subdomain)
+			removed++
+		}
+	}
+	parentCovered := func(domain string) bool {
+		for {
+			dotIndex := strings.IndexByte(domain, '.')
+			if dotIndex == -1 {
+				return false
+			}
+			domain = domain[dotIndex+1:]
+			if suffixes[domain] || subdomainSuffixes[domain] {
+				return true
+			}
+		}
+	}
+	for _, suffix := range options.DomainSuffix {
+		subdomain, isSubdomain := strings.CutPrefix(suffix, ".")
+		if isSubdomain && mergedDomains[subdomain] {
+			suffix = subdomain
+		} else if isSubdomain && suffixes[subdomain] {
+			removed++
+			continue
+		}
+		if parentCovered(subdomain) {
+			removed++
+			continue
+		}
+		domainSuffix = append(domainSuffix, suffix)
+	}
+	for _, domain := range options.Domain {
+		if !exactDomains[domain] {
+			continue
+		}
+		if suffixes[domain] || parentCovered(domain) {
+			removed++
+			continue
+		}
+		domains = append(domains, domain)
+	}
+	options.Domain = domains
+	options.DomainSuffix = domainSuffix
+	return removed
+}
@@ -0,0 +1,35 @@
+package srs
+
+import (
+	"testing"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptimize(t *testing.T) {
+	t.Parallel()
+	rules := []option.HeadlessRule{{
+		Type: C.RuleTypeDefault,
+		DefaultOptions: option.DefaultHeadlessRule{
+			Domain:       []string{"a.example", "www.a.example", "b.example", "b.example", "c.example", "d.example"},
+			DomainSuffix: []string{"a.example", ".b.example", "sub.a.example", ".c.example"},
+		},
+	}}
+	statistics := Optimize(rules, C.RuleSetVersion2)
+	require.Equal(t, 1, statistics.DuplicateItems)
+	require.Equal(t, 5, statistics.RedundantDomains)
+	require.Equal(t, []string{"d.example"}, []string(rules[0].DefaultOptions.Domain))
+	require.Equal(t, []string{"a.example", "b.example", "c.example"}, []string(rules[0].DefaultOptions.DomainSuffix))
+
+	legacyRules := []option.HeadlessRule{{
+		Type: C.RuleTypeDefault,
+		DefaultOptions: option.DefaultHeadlessRule{
+			Domain:       []string{"www.a.example"},
+			DomainSuffix: []string{"a.example"},
+		},
+	}}
+	require.Zero(t, Optimize(legacyRules, C.RuleSetVersion1).RedundantDomains)
+}
@@ -0,0 +1,115 @@
+package srs
+
+import (
+	"net/netip"
+	"unsafe"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/domain"
+
+	"go4.org/netipx"
+)
+
+type Statistics struct {
+	Rules          int
+	Domains        int
+	DomainSuffixes int
+	DomainKeywords int
+	DomainRegexes  int
+	IPCIDRs        int
+	SourceIPCIDRs  int
+	// IPRanges counts the merged ranges of ip_cidr and source_ip_cidr items.
+	IPRanges int
+	// DomainMatcherSize is the size of the succinct tries built from domain and domain_suffix items,
+	// which are kept in memory as stored in binary rule-sets.
+	DomainMatcherSize int
+	// IPSetSize is the memory used by merged IP ranges.
+	IPSetSize int
+}
+
+// Analyze reports item counts and the estimated memory cost of rules once loaded.
+func Analyze(rules []option.HeadlessRule, version uint8) (Statistics, error) {
+	var statistics Statistics
+	for _, rule := range rules {
+		err := analyzeRule(rule, version, &statistics)
+		if err != nil {
+			return Statistics{}, err
+		}
+	}
+	return statistics, nil
+}
+
+func analyzeRule(rule option.HeadlessRule, version uint8, statistics *Statistics) error {
+	statistics.Rules++
+	switch rule.Type {
+	case C.RuleTypeDefault:
+		options := rule.DefaultOptions
+		statistics.Domains += len(options.Domain)
+		statistics.DomainSuffixes += len(options.DomainSuffix)
+		statistics.DomainKeywords += len(options.DomainKeyword)
+		statistics.DomainRegexes += len(options.DomainRegex)
+		statistics.IPCIDRs += len(options.IPCIDR)
+		statistics.SourceIPCIDRs += len(options.SourceIPCIDR)
+		if len(options.Domain) > 0 || len(options.DomainSuffix) > 0 {
+			var writer countWriter
+			err := domain.NewMatcher(options.Domain, options.DomainSuffix, version == C.RuleSetVersion1).Write(&writer)
+			if err != nil {
+				return err
+			}
+			statistics.DomainMatcherSize += int(writer)
+		}
+		for _, prefixes := range [][]string{options.IPCIDR, options.SourceIPCIDR} {
+			if len(prefixes) == 0 {
+				continue
+			}
+			ranges, err := countIPRanges(prefixes)
+			if err != nil {
+				return err
+			}
+			statistics.IPRanges += ranges
+			statistics.IPSetSize += ranges * int(unsafe.Sizeof(netipx.IPRange{}))
+		}
+	case C.RuleTypeLogical:
+		for _, subRule := range rule.LogicalOptions.Rules {
+			err := analyzeRule(subRule, version, statistics)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func countIPRanges(prefixes []string) (int, error) {
+	var builder netipx.IPSetBuilder
+	for _, prefixString := range prefixes {
+		prefix, err := netip.ParsePrefix(prefixString)
+		if err == nil {
+			builder.AddPrefix(prefix)
+			continue
+		}
+		addr, addrErr := netip.ParseAddr(prefixString)
+		if addrErr != nil {
+			return 0, err
+		}
+		builder.Add(addr)
+	}
+	ipSet, err := builder.IPSet()
+	if err != nil {
+		return 0, err
+	}
+	return len(ipSet.Ranges()), nil
+}
+
+type countWriter int
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	*w += countWriter(len(p))
+	return len(p), nil
+}
+
+func (w *countWriter) WriteByte(byte) error {
+	*w++
+	return nil
+}
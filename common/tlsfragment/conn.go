@@ -20,23 +20,27 @@ type Conn struct {
 	tcpConn            *net.TCPConn
 	ctx                context.Context
 	firstPacketWritten bool
-	splitPacket        bool
-	splitRecord        bool
-	fallbackDelay      time.Duration
+	options            Options
 }
 
 func NewConn(conn net.Conn, ctx context.Context, splitPacket bool, splitRecord bool, fallbackDelay time.Duration) *Conn {
-	if fallbackDelay == 0 {
-		fallbackDelay = C.TLSFragmentFallbackDelay
+	return NewConnWithOptions(conn, ctx, Options{
+		SplitPacket:   splitPacket,
+		SplitRecord:   splitRecord,
+		FallbackDelay: fallbackDelay,
+	})
+}
+
+func NewConnWithOptions(conn net.Conn, ctx context.Context, options Options) *Conn {
+	if options.FallbackDelay == 0 {
+		options.FallbackDelay = C.TLSFragmentFallbackDelay
 	}
 	tcpConn, _ := N.UnwrapReader(conn).(*net.TCPConn)
 	return &Conn{
-		Conn:          conn,
-		tcpConn:       tcpConn,
-		ctx:           ctx,
-		splitPacket:   splitPacket,
-		splitRecord:   splitRecord,
-		fallbackDelay: fallbackDelay,
+		Conn:    conn,
+		tcpConn: tcpConn,
+		ctx:     ctx,
+		options: options,
 	}
 }
 
@@ -45,92 +49,132 @@ func (c *Conn) Write(b []byte) (n int, err error) {
 		defer func() {
 			c.firstPacketWritten = true
 		}()
-		serverName := IndexTLSServerName(b)
-		if serverName != nil {
-			if c.splitPacket {
-				if c.tcpConn != nil {
-					err = c.tcpConn.SetNoDelay(true)
-					if err != nil {
-						return
-					}
-				}
+		splitIndexes := c.splitIndexes(b)
+		if len(splitIndexes) > 0 {
+			err = c.writeFragments(b, splitIndexes)
+			if err != nil {
+				return
 			}
-			splits := strings.Split(serverName.ServerName, ".")
-			currentIndex := serverName.Index
-			if publicSuffix := publicsuffix.List.PublicSuffix(serverName.ServerName); publicSuffix != "" {
-				splits = splits[:len(splits)-strings.Count(serverName.ServerName, ".")]
+			return len(b), nil
+		}
+	}
+	return c.Conn.Write(b)
+}
+
+func (c *Conn) splitIndexes(b []byte) []int {
+	if c.options.Strategy == C.TLSFragmentStrategyRandom {
+		if !isClientHello(b) {
+			return nil
+		}
+		return c.options.randomSplitIndexes(len(b))
+	}
+	serverName := IndexTLSServerName(b)
+	if serverName == nil || serverName.ServerName == "" {
+		return nil
+	}
+	splits := strings.Split(serverName.ServerName, ".")
+	currentIndex := serverName.Index
+	if publicSuffix := publicsuffix.List.PublicSuffix(serverName.ServerName); publicSuffix != "" {
+		splits = splits[:len(splits)-strings.Count(serverName.ServerName, ".")]
+	}
+	if len(splits) > 1 && splits[0] == "..." {
+		currentIndex += len(splits[0]) + 1
+		splits = splits[1:]
+	}
+	var splitIndexes []int
+	for i, split := range splits {
+		if split == "" {
+			currentIndex++
+			continue
+		}
+		splitAt := rand.Intn(len(split))
+		splitIndexes = append(splitIndexes, currentIndex+splitAt)
+		currentIndex += len(split)
+		if i != len(splits)-1 {
+			currentIndex++
+		}
+	}
+	return splitIndexes
+}
+
+func (c *Conn) writeFragments(b []byte, splitIndexes []int) (err error) {
+	splitPacket := c.options.SplitPacket
+	splitRecord := c.options.SplitRecord
+	if splitPacket {
+		if c.tcpConn != nil {
+			err = c.tcpConn.SetNoDelay(true)
+			if err != nil {
+				return
 			}
-			if len(splits) > 1 && splits[0] == "..." {
-				currentIndex += len(splits[0]) + 1
-				splits = splits[1:]
+		}
+	}
+	var buffer bytes.Buffer
+	for i := 0; i <= len(splitIndexes); i++ {
+		var payload []byte
+		if i == 0 {
+			payload = b[:splitIndexes[i]]
+			if splitRecord {
+				payload = payload[recordLayerHeaderLen:]
 			}
-			var splitIndexes []int
-			for i, split := range splits {
-				splitAt := rand.Intn(len(split))
-				splitIndexes = append(splitIndexes, currentIndex+splitAt)
-				currentIndex += len(split)
-				if i != len(splits)-1 {
-					currentIndex++
-				}
+		} else if i == len(splitIndexes) {
+			payload = b[splitIndexes[i-1]:]
+		} else {
+			payload = b[splitIndexes[i-1]:splitIndexes[i]]
+		}
+		if splitRecord {
+			if splitPacket {
+				buffer.Reset()
 			}
-			var buffer bytes.Buffer
-			for i := 0; i <= len(splitIndexes); i++ {
-				var payload []byte
-				if i == 0 {
-					payload = b[:splitIndexes[i]]
-					if c.splitRecord {
-						payload = payload[recordLayerHeaderLen:]
-					}
-				} else if i == len(splitIndexes) {
-					payload = b[splitIndexes[i-1]:]
-				} else {
-					payload = b[splitIndexes[i-1]:splitIndexes[i]]
-				}
-				if c.splitRecord {
-					if c.splitPacket {
-						buffer.Reset()
-					}
-					payloadLen := uint16(len(payload))
-					buffer.Write(b[:3])
-					binary.Write(&buffer, binary.BigEndian, payloadLen)
-					buffer.Write(payload)
-					if c.splitPacket {
-						payload = buffer.Bytes()
-					}
-				}
-				if c.splitPacket {
-					if c.tcpConn != nil && i != len(splitIndexes) {
-						err = writeAndWaitAck(c.ctx, c.tcpConn, payload, c.fallbackDelay)
-						if err != nil {
-							return
-						}
-					} else {
-						_, err = c.Conn.Write(payload)
-						if err != nil {
-							return
-						}
-						if i != len(splitIndexes) {
-							time.Sleep(c.fallbackDelay)
-						}
-					}
-				}
+			payloadLen := uint16(len(payload))
+			buffer.Write(b[:3])
+			binary.Write(&buffer, binary.BigEndian, payloadLen)
+			buffer.Write(payload)
+			if splitPacket {
+				payload = buffer.Bytes()
 			}
-			if c.splitRecord && !c.splitPacket {
-				_, err = c.Conn.Write(buffer.Bytes())
+		}
+		if splitPacket {
+			if c.tcpConn != nil && i != len(splitIndexes) {
+				err = writeAndWaitAck(c.ctx, c.tcpConn, payload, c.options.FallbackDelay)
 				if err != nil {
 					return
 				}
-			}
-			if c.tcpConn != nil {
-				err = c.tcpConn.SetNoDelay(false)
+			} else {
+				_, err = c.Conn.Write(payload)
 				if err != nil {
 					return
 				}
+				if i != len(splitIndexes) && c.options.MaxDelay == 0 && c.options.MinDelay == 0 {
+					time.Sleep(c.options.FallbackDelay)
+				}
+			}
+			if i != len(splitIndexes) {
+				if delay := c.options.delay(); delay > 0 {
+					time.Sleep(delay)
+				}
 			}
-			return len(b), nil
 		}
 	}
-	return c.Conn.Write(b)
+	if splitRecord && !splitPacket {
+		_, err = c.Conn.Write(buffer.Bytes())
+		if err != nil {
+			return
+		}
+	}
+	if c.tcpConn != nil {
+		err = c.tcpConn.SetNoDelay(false)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func isClientHello(payload []byte) bool {
+	if len(payload) < recordLayerHeaderLen+1 || payload[0] != contentType || payload[recordLayerHeaderLen] != handshakeType {
+		return false
+	}
+	return len(payload) >= recordLayerHeaderLen+int(binary.BigEndian.Uint16(payload[3:5]))
 }
 
 func (c *Conn) ReaderReplaceable() bool {
@@ -0,0 +1,108 @@
+package tf
+
+import (
+	"math/rand"
+	"slices"
+	"time"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const defaultRandomCount = 3
+
+type Options struct {
+	SplitPacket   bool
+	SplitRecord   bool
+	FallbackDelay time.Duration
+	Strategy      string
+	Count         int
+	MinSize       int
+	MaxSize       int
+	MinDelay      time.Duration
+	MaxDelay      time.Duration
+}
+
+// NewOptions returns nil if fragmentation is not enabled.
+func NewOptions(options *option.TLSFragmentOptions) (*Options, error) {
+	if options == nil || !options.Enabled {
+		return nil, nil
+	}
+	fragmentOptions := &Options{
+		FallbackDelay: time.Duration(options.FallbackDelay),
+		Strategy:      options.Strategy,
+		Count:         options.Count,
+		MinSize:       options.MinSize,
+		MaxSize:       options.MaxSize,
+		MinDelay:      time.Duration(options.MinDelay),
+		MaxDelay:      time.Duration(options.MaxDelay),
+	}
+	switch options.Mode {
+	case "", C.TLSFragmentModePacket:
+		fragmentOptions.SplitPacket = true
+	case C.TLSFragmentModeRecord:
+		fragmentOptions.SplitRecord = true
+	case C.TLSFragmentModeRecordPacket:
+		fragmentOptions.SplitPacket = true
+		fragmentOptions.SplitRecord = true
+	default:
+		return nil, E.New("unknown tls fragment mode: ", options.Mode)
+	}
+	switch options.Strategy {
+	case "", C.TLSFragmentStrategySNI:
+		if options.Count != 0 || options.MinSize != 0 || options.MaxSize != 0 {
+			return nil, E.New("`count`, `min_size` and `max_size` are only supported by the random strategy")
+		}
+	case C.TLSFragmentStrategyRandom:
+		if options.Count < 0 || options.Count == 1 {
+			return nil, E.New("invalid count: ", options.Count)
+		}
+		if options.MinSize < 0 || options.MaxSize < options.MinSize {
+			return nil, E.New("invalid size range: ", options.MinSize, "-", options.MaxSize)
+		}
+	default:
+		return nil, E.New("unknown tls fragment strategy: ", options.Strategy)
+	}
+	if fragmentOptions.MinDelay < 0 || fragmentOptions.MaxDelay != 0 && fragmentOptions.MaxDelay < fragmentOptions.MinDelay {
+		return nil, E.New("invalid delay range: ", fragmentOptions.MinDelay, "-", fragmentOptions.MaxDelay)
+	}
+	return fragmentOptions, nil
+}
+
+// randomSplitIndexes splits the record after its header,
+// into chunks of random sizes if a size range is set, or into count chunks at random positions otherwise.
+func (o *Options) randomSplitIndexes(payloadLen int) []int {
+	var splitIndexes []int
+	if o.MaxSize > 0 {
+		minSize := max(o.MinSize, 1)
+		for currentIndex := recordLayerHeaderLen; ; {
+			currentIndex += minSize + rand.Intn(o.MaxSize-minSize+1)
+			if currentIndex >= payloadLen || o.Count > 0 && len(splitIndexes) == o.Count-1 {
+				break
+			}
+			splitIndexes = append(splitIndexes, currentIndex)
+		}
+		return splitIndexes
+	}
+	count := o.Count
+	if count == 0 {
+		count = defaultRandomCount
+	}
+	available := payloadLen - recordLayerHeaderLen - 1
+	if available <= 0 {
+		return nil
+	}
+	for _, offset := range rand.Perm(available)[:min(count-1, available)] {
+		splitIndexes = append(splitIndexes, recordLayerHeaderLen+1+offset)
+	}
+	slices.Sort(splitIndexes)
+	return splitIndexes
+}
+
+func (o *Options) delay() time.Duration {
+	if o.MaxDelay <= o.MinDelay {
+		return o.MinDelay
+	}
+	return o.MinDelay + time.Duration(rand.Int63n(int64(o.MaxDelay-o.MinDelay)+1))
+}
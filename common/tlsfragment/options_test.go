@@ -0,0 +1,173 @@
+package tf
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json/badoption"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOptions(t *testing.T) {
+	t.Parallel()
+	fragmentOptions, err := NewOptions(nil)
+	require.NoError(t, err)
+	require.Nil(t, fragmentOptions)
+	fragmentOptions, err = NewOptions(&option.TLSFragmentOptions{Mode: C.TLSFragmentModeRecord})
+	require.NoError(t, err)
+	require.Nil(t, fragmentOptions, "disabled")
+	for _, testCase := range []struct {
+		name        string
+		options     option.TLSFragmentOptions
+		splitPacket bool
+		splitRecord bool
+	}{
+		{"default", option.TLSFragmentOptions{}, true, false},
+		{"packet", option.TLSFragmentOptions{Mode: C.TLSFragmentModePacket}, true, false},
+		{"record", option.TLSFragmentOptions{Mode: C.TLSFragmentModeRecord}, false, true},
+		{"record packet", option.TLSFragmentOptions{Mode: C.TLSFragmentModeRecordPacket}, true, true},
+		{"random count", option.TLSFragmentOptions{Strategy: C.TLSFragmentStrategyRandom, Count: 4}, true, false},
+		{"random size", option.TLSFragmentOptions{Strategy: C.TLSFragmentStrategyRandom, MinSize: 10, MaxSize: 20}, true, false},
+		{"delay", option.TLSFragmentOptions{MinDelay: badoption.Duration(time.Millisecond), MaxDelay: badoption.Duration(2 * time.Millisecond)}, true, false},
+		{"fixed delay", option.TLSFragmentOptions{MinDelay: badoption.Duration(time.Millisecond)}, true, false},
+	} {
+		testCase.options.Enabled = true
+		fragmentOptions, err = NewOptions(&testCase.options)
+		require.NoError(t, err, testCase.name)
+		require.Equal(t, testCase.splitPacket, fragmentOptions.SplitPacket, testCase.name)
+		require.Equal(t, testCase.splitRecord, fragmentOptions.SplitRecord, testCase.name)
+	}
+	for _, testCase := range []struct {
+		name    string
+		options option.TLSFragmentOptions
+	}{
+		{"unknown mode", option.TLSFragmentOptions{Mode: "stream"}},
+		{"unknown strategy", option.TLSFragmentOptions{Strategy: "middle"}},
+		{"count with sni strategy", option.TLSFragmentOptions{Count: 3}},
+		{"size with sni strategy", option.TLSFragmentOptions{MaxSize: 10}},
+		{"single chunk", option.TLSFragmentOptions{Strategy: C.TLSFragmentStrategyRandom, Count: 1}},
+		{"negative count", option.TLSFragmentOptions{Strategy: C.TLSFragmentStrategyRandom, Count: -1}},
+		{"negative size", option.TLSFragmentOptions{Strategy: C.TLSFragmentStrategyRandom, MinSize: -1, MaxSize: 10}},
+		{"inverted size", option.TLSFragmentOptions{Strategy: C.TLSFragmentStrategyRandom, MinSize: 20, MaxSize: 10}},
+		{"negative delay", option.TLSFragmentOptions{MinDelay: badoption.Duration(-time.Millisecond)}},
+		{"inverted delay", option.TLSFragmentOptions{MinDelay: badoption.Duration(2 * time.Millisecond), MaxDelay: badoption.Duration(time.Millisecond)}},
+	} {
+		testCase.options.Enabled = true
+		_, err = NewOptions(&testCase.options)
+		require.Error(t, err, testCase.name)
+	}
+}
+
+func TestRandomSplitIndexesCount(t *testing.T) {
+	t.Parallel()
+	for _, count := range []int{0, 2, 5} {
+		options := &Options{Strategy: C.TLSFragmentStrategyRandom, Count: count}
+		expected := count
+		if expected == 0 {
+			expected = defaultRandomCount
+		}
+		for range 100 {
+			splitIndexes := options.randomSplitIndexes(64)
+			require.Len(t, splitIndexes, expected-1)
+			require.True(t, slices.IsSorted(splitIndexes))
+			require.Len(t, slices.Compact(slices.Clone(splitIndexes)), expected-1, "duplicate index")
+			for _, index := range splitIndexes {
+				require.Greater(t, index, recordLayerHeaderLen, "the record header and handshake type must not be split")
+				require.Less(t, index, 64)
+			}
+		}
+	}
+	options := &Options{Strategy: C.TLSFragmentStrategyRandom, Count: 10}
+	require.Len(t, options.randomSplitIndexes(recordLayerHeaderLen+4), 3, "count is capped by the payload length")
+	require.Empty(t, options.randomSplitIndexes(recordLayerHeaderLen+1))
+}
+
+func TestRandomSplitIndexesSize(t *testing.T) {
+	t.Parallel()
+	options := &Options{Strategy: C.TLSFragmentStrategyRandom, MinSize: 10, MaxSize: 20}
+	for range 100 {
+		splitIndexes := options.randomSplitIndexes(500)
+		require.NotEmpty(t, splitIndexes)
+		lastIndex := recordLayerHeaderLen
+		for _, index := range splitIndexes {
+			require.GreaterOrEqual(t, index-lastIndex, 10)
+			require.LessOrEqual(t, index-lastIndex, 20)
+			lastIndex = index
+		}
+		require.Less(t, lastIndex, 500)
+		require.LessOrEqual(t, 500-lastIndex, 20, "the remaining chunk must not exceed max_size")
+	}
+	options.Count = 3
+	for range 100 {
+		require.Len(t, options.randomSplitIndexes(500), 2, "count caps the number of chunks")
+	}
+	options = &Options{Strategy: C.TLSFragmentStrategyRandom, MaxSize: 1}
+	require.Len(t, options.randomSplitIndexes(recordLayerHeaderLen+4), 3, "min_size defaults to one byte")
+}
+
+func TestDelay(t *testing.T) {
+	t.Parallel()
+	require.Zero(t, (&Options{}).delay())
+	require.Equal(t, 5*time.Millisecond, (&Options{MinDelay: 5 * time.Millisecond}).delay())
+	require.Equal(t, 5*time.Millisecond, (&Options{MinDelay: 5 * time.Millisecond, MaxDelay: 5 * time.Millisecond}).delay())
+	options := &Options{MinDelay: time.Millisecond, MaxDelay: 3 * time.Millisecond}
+	for range 1000 {
+		delay := options.delay()
+		require.GreaterOrEqual(t, delay, time.Millisecond)
+		require.LessOrEqual(t, delay, 3*time.Millisecond)
+	}
+}
+
+func TestRandomRecordFragmentWrite(t *testing.T) {
+	t.Parallel()
+	clientHello := make([]byte, recordLayerHeaderLen+300)
+	copy(clientHello, []byte{contentType, 3, 1})
+	binary.BigEndian.PutUint16(clientHello[3:5], 300)
+	clientHello[recordLayerHeaderLen] = handshakeType
+	for i := recordLayerHeaderLen + 1; i < len(clientHello); i++ {
+		clientHello[i] = byte(i)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	conn := NewConnWithOptions(clientConn, context.Background(), Options{
+		SplitPacket: true,
+		SplitRecord: true,
+		Strategy:    C.TLSFragmentStrategyRandom,
+		Count:       3,
+		MinDelay:    20 * time.Millisecond,
+		MaxDelay:    20 * time.Millisecond,
+	})
+	writeDone := make(chan error, 1)
+	go func() {
+		_, err := conn.Write(clientHello)
+		writeDone <- err
+	}()
+	startAt := time.Now()
+	var (
+		records int
+		payload bytes.Buffer
+	)
+	for payload.Len() < 300 {
+		header := make([]byte, recordLayerHeaderLen)
+		_, err := io.ReadFull(serverConn, header)
+		require.NoError(t, err)
+		require.Equal(t, clientHello[:3], header[:3])
+		_, err = io.CopyN(&payload, serverConn, int64(binary.BigEndian.Uint16(header[3:5])))
+		require.NoError(t, err)
+		records++
+	}
+	require.NoError(t, <-writeDone)
+	require.Equal(t, 3, records)
+	require.Equal(t, clientHello[recordLayerHeaderLen:], payload.Bytes())
+	require.GreaterOrEqual(t, time.Since(startAt), 40*time.Millisecond, "fragments must be delayed")
+}
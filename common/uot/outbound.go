@@ -0,0 +1,45 @@
+package uot
+
+import (
+	"context"
+	"net"
+
+	"github.com/sagernet/sing-box/adapter"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/uot"
+)
+
+var _ adapter.Outbound = (*Outbound)(nil)
+
+// Outbound tunnels UDP over TCP connections of an outbound without UDP support,
+// the server must be a sing-box inbound accepting UoT requests.
+type Outbound struct {
+	adapter.Outbound
+	client *uot.Client
+}
+
+func NewOutbound(outbound adapter.Outbound) *Outbound {
+	return &Outbound{
+		Outbound: outbound,
+		client: &uot.Client{
+			Dialer:  outbound,
+			Version: uot.Version,
+		},
+	}
+}
+
+func (o *Outbound) Network() []string {
+	return []string{N.NetworkTCP, N.NetworkUDP}
+}
+
+func (o *Outbound) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	if N.NetworkName(network) == N.NetworkUDP {
+		return o.client.DialContext(ctx, network, destination)
+	}
+	return o.Outbound.DialContext(ctx, network, destination)
+}
+
+func (o *Outbound) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	return o.client.ListenPacket(ctx, destination)
+}
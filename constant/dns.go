@@ -30,6 +30,11 @@ const (
 	DNSTypeTailscale   = "tailscale"
 )
 
+const (
+	DNSHTTP3Discover = "discover"
+	DNSHTTP3Race     = "race"
+)
+
 const (
 	DNSProviderAliDNS     = "alidns"
 	DNSProviderCloudflare = "cloudflare"
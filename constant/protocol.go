@@ -11,12 +11,21 @@ const (
 	ProtocolSSH        = "ssh"
 	ProtocolRDP        = "rdp"
 	ProtocolNTP        = "ntp"
+	ProtocolWireGuard  = "wireguard"
+	ProtocolOpenVPN    = "openvpn"
+	ProtocolSMTP       = "smtp"
+	ProtocolIMAP       = "imap"
+	ProtocolPOP3       = "pop3"
+	ProtocolMQTT       = "mqtt"
+	ProtocolRTMP       = "rtmp"
+	ProtocolSOCKS      = "socks"
 )
 
 const (
-	ClientChromium = "chromium"
-	ClientSafari   = "safari"
-	ClientFirefox  = "firefox"
-	ClientQUICGo   = "quic-go"
-	ClientUnknown  = "unknown"
+	ClientChromium  = "chromium"
+	ClientSafari    = "safari"
+	ClientFirefox   = "firefox"
+	ClientQUICGo    = "quic-go"
+	ClientAmneziaWG = "amneziawg"
+	ClientUnknown   = "unknown"
 )
@@ -11,11 +11,17 @@ const (
 )
 
 const (
-	RuleSetTypeInline   = "inline"
-	RuleSetTypeLocal    = "local"
-	RuleSetTypeRemote   = "remote"
-	RuleSetFormatSource = "source"
-	RuleSetFormatBinary = "binary"
+	RuleSetTypeInline           = "inline"
+	RuleSetTypeLocal            = "local"
+	RuleSetTypeRemote           = "remote"
+	RuleSetFormatSource         = "source"
+	RuleSetFormatBinary         = "binary"
+	RuleSetFormatAdGuard        = "adguard"
+	RuleSetFormatHosts          = "hosts"
+	RuleSetFormatClashClassical = "clash-classical"
+	RuleSetFormatClashDomain    = "clash-domain"
+	RuleSetFormatClashIPCIDR    = "clash-ipcidr"
+	RuleSetFormatMRS            = "mrs"
 )
 
 const (
@@ -23,7 +29,8 @@ const (
 	RuleSetVersion2
 	RuleSetVersion3
 	RuleSetVersion4
-	RuleSetVersionCurrent = RuleSetVersion4
+	RuleSetVersion5
+	RuleSetVersionCurrent = RuleSetVersion5
 )
 
 const (
@@ -36,6 +43,8 @@ const (
 	RuleActionTypeSniff        = "sniff"
 	RuleActionTypeResolve      = "resolve"
 	RuleActionTypePredefined   = "predefined"
+	RuleActionTypeRewrite      = "rewrite"
+	RuleActionTypeHTTPRewrite  = "http-rewrite"
 )
 
 const (
@@ -43,3 +52,14 @@ const (
 	RuleActionRejectMethodDrop    = "drop"
 	RuleActionRejectMethodReply   = "reply"
 )
+
+const (
+	HTTPRewriteActionRewrite  = "rewrite"
+	HTTPRewriteActionRedirect = "redirect"
+	HTTPRewriteActionReject   = "reject"
+)
+
+const (
+	RuleActionUDPFallbackUDPOverTCP = "udp_over_tcp"
+	RuleActionUDPFallbackRejectQUIC = "reject_quic"
+)
@@ -0,0 +1,12 @@
+package constant
+
+const (
+	TLSFragmentModePacket       = "packet"
+	TLSFragmentModeRecord       = "record"
+	TLSFragmentModeRecordPacket = "record_packet"
+)
+
+const (
+	TLSFragmentStrategySNI    = "sni"
+	TLSFragmentStrategyRandom = "random"
+)
@@ -0,0 +1,6 @@
+package constant
+
+const (
+	TorIsolationUser        = "user"
+	TorIsolationDestination = "destination"
+)
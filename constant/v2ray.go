@@ -6,4 +6,29 @@ const (
 	V2RayTransportTypeQUIC        = "quic"
 	V2RayTransportTypeGRPC        = "grpc"
 	V2RayTransportTypeHTTPUpgrade = "httpupgrade"
+	V2RayTransportTypeXHTTP       = "xhttp"
+	V2RayTransportTypeObfs        = "obfs"
+	V2RayTransportTypePlugin      = "v2ray-plugin"
+	V2RayTransportTypeKCP         = "kcp"
+)
+
+const (
+	ObfsModeHTTP = "http"
+	ObfsModeTLS  = "tls"
+)
+
+const (
+	KCPHeaderNone        = "none"
+	KCPHeaderSRTP        = "srtp"
+	KCPHeaderUTP         = "utp"
+	KCPHeaderWechatVideo = "wechat-video"
+	KCPHeaderDTLS        = "dtls"
+	KCPHeaderWireGuard   = "wireguard"
+)
+
+const (
+	XHTTPModeAuto      = "auto"
+	XHTTPModePacketUp  = "packet-up"
+	XHTTPModeStreamUp  = "stream-up"
+	XHTTPModeStreamOne = "stream-one"
 )
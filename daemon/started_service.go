@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"context"
+	"net/netip"
 	"os"
 	"runtime"
 	"sync"
@@ -10,10 +11,12 @@ import (
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/common/urltest"
 	"github.com/sagernet/sing-box/experimental/clashapi"
+	"github.com/sagernet/sing-box/experimental/clashapi/dnslog"
 	"github.com/sagernet/sing-box/experimental/clashapi/trafficontrol"
 	"github.com/sagernet/sing-box/experimental/deprecated"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/protocol/group"
+	"github.com/sagernet/sing-box/route"
 	"github.com/sagernet/sing/common"
 	"github.com/sagernet/sing/common/batch"
 	E "github.com/sagernet/sing/common/exceptions"
@@ -23,6 +26,7 @@ import (
 	"github.com/sagernet/sing/service"
 
 	"github.com/gofrs/uuid/v5"
+	mDNS "github.com/miekg/dns"
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
@@ -1030,6 +1034,129 @@ func (s *StartedService) GetStartedAt(ctx context.Context, empty *emptypb.Empty)
 	return &StartedAt{StartedAt: s.startedAt.UnixMilli()}, nil
 }
 
+func (s *StartedService) SubscribeDNSQueries(empty *emptypb.Empty, server grpc.ServerStreamingServer[DNSQueries]) error {
+	err := s.waitForStarted(server.Context())
+	if err != nil {
+		return err
+	}
+	s.serviceAccess.RLock()
+	boxService := s.instance
+	s.serviceAccess.RUnlock()
+
+	if boxService.clashServer == nil {
+		return E.New("clash server not available")
+	}
+
+	queryManager := boxService.clashServer.(*clashapi.Server).DNSQueryManager()
+
+	subscription, done, err := queryManager.Subscribe()
+	if err != nil {
+		return err
+	}
+	defer queryManager.UnSubscribe(subscription)
+
+	err = server.Send(&DNSQueries{
+		Queries: common.Map(queryManager.Records(), newDNSQuery),
+		Reset_:  true,
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-server.Context().Done():
+			return server.Context().Err()
+		case <-done:
+			return nil
+		case record := <-subscription:
+			queries := []*DNSQuery{newDNSQuery(record)}
+		drain:
+			for {
+				select {
+				case record = <-subscription:
+					queries = append(queries, newDNSQuery(record))
+				default:
+					break drain
+				}
+			}
+			err = server.Send(&DNSQueries{Queries: queries})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func newDNSQuery(record adapter.DNSQueryRecord) *DNSQuery {
+	query := &DNSQuery{
+		Time:        record.Time.UnixMilli(),
+		Inbound:     record.Inbound,
+		InboundType: record.InboundType,
+		Domain:      record.Domain,
+		RuleIndex:   int32(record.RuleIndex),
+		Rule:        record.Rule,
+		Action:      record.Action,
+		Transport:   record.Transport,
+		Rcode:       mDNS.RcodeToString[record.Rcode],
+		Answers:     common.Map(record.Answers, netip.Addr.String),
+		Latency:     record.Latency.Milliseconds(),
+		Cached:      record.Cached,
+		Blocked:     record.Blocked,
+		Error:       record.Error,
+	}
+	if record.Source.IsValid() {
+		query.Source = record.Source.String()
+	}
+	if record.QueryType != 0 {
+		query.QueryType = mDNS.Type(record.QueryType).String()
+	}
+	return query
+}
+
+func (s *StartedService) GetDNSStatistics(ctx context.Context, request *GetDNSStatisticsRequest) (*DNSStatistics, error) {
+	s.serviceAccess.RLock()
+	if s.serviceStatus.Status != ServiceStatus_STARTED {
+		s.serviceAccess.RUnlock()
+		return nil, os.ErrInvalid
+	}
+	boxService := s.instance
+	s.serviceAccess.RUnlock()
+	if boxService.clashServer == nil {
+		return nil, E.New("clash server not available")
+	}
+	limit := int(request.Limit)
+	if limit <= 0 {
+		limit = 10
+	}
+	statistics := boxService.clashServer.(*clashapi.Server).DNSQueryManager().Statistics(limit)
+	domainCount := func(it dnslog.DomainCount) *DNSDomainCount {
+		return &DNSDomainCount{
+			Domain: it.Domain,
+			Count:  it.Count,
+		}
+	}
+	return &DNSStatistics{
+		Total:      statistics.Total,
+		Cached:     statistics.Cached,
+		Blocked:    statistics.Blocked,
+		Failed:     statistics.Failed,
+		TopDomains: common.Map(statistics.TopDomains, domainCount),
+		TopBlocked: common.Map(statistics.TopBlocked, domainCount),
+		Upstreams: common.Map(statistics.Upstreams, func(it dnslog.UpstreamLatency) *DNSUpstreamLatency {
+			return &DNSUpstreamLatency{
+				Transport: it.Transport,
+				Count:     it.Count,
+				P50:       it.P50.Milliseconds(),
+				P90:       it.P90.Milliseconds(),
+				P99:       it.P99.Milliseconds(),
+			}
+		}),
+	}, nil
+}
+
 func (s *StartedService) mustEmbedUnimplementedStartedServiceServer() {
 }
 
@@ -1047,6 +1174,64 @@ func (s *StartedService) WriteMessage(level log.Level, message string) {
 	}
 }
 
+func (s *StartedService) ExplainRoute(ctx context.Context, request *ExplainRouteRequest) (*RouteExplanation, error) {
+	s.serviceAccess.RLock()
+	if s.serviceStatus.Status != ServiceStatus_STARTED {
+		s.serviceAccess.RUnlock()
+		return nil, os.ErrInvalid
+	}
+	boxService := s.instance
+	s.serviceAccess.RUnlock()
+	explanation, err := boxService.instance.Router().Explain(ctx, route.ExplainQuery{
+		Inbound:     request.Inbound,
+		Network:     request.Network,
+		Source:      request.Source,
+		Destination: request.Destination,
+		Domain:      request.Domain,
+		Protocol:    request.Protocol,
+		User:        request.User,
+		ProcessPath: request.ProcessPath,
+		PackageName: request.PackageName,
+	}.Metadata())
+	if err != nil {
+		return nil, err
+	}
+	response := &RouteExplanation{
+		Destination:          explanation.Metadata.Destination.String(),
+		DestinationAddresses: common.Map(explanation.Metadata.DestinationAddresses, netip.Addr.String),
+		Rules:                common.Map(explanation.Rules, newRuleExplanation),
+		RuleIndex:            int32(explanation.RuleIndex),
+		Rule:                 explanation.Rule,
+		Action:               explanation.Action,
+		Outbound:             explanation.Outbound,
+	}
+	if explanation.DNS != nil {
+		response.Dns = &DNSExplanation{
+			Rules:     common.Map(explanation.DNS.Rules, newRuleExplanation),
+			RuleIndex: int32(explanation.DNS.RuleIndex),
+			Rule:      explanation.DNS.Rule,
+			Action:    explanation.DNS.Action,
+			Server:    explanation.DNS.Server,
+		}
+	}
+	return response, nil
+}
+
+func newRuleExplanation(explanation adapter.RuleExplanation) *RuleExplanation {
+	return &RuleExplanation{
+		Index:   int32(explanation.Index),
+		Rule:    explanation.Rule,
+		Action:  explanation.Action,
+		Matched: explanation.Matched,
+		Items: common.Map(explanation.Items, func(it adapter.RuleItemExplanation) *RuleItemExplanation {
+			return &RuleItemExplanation{
+				Item:    it.Item,
+				Matched: it.Matched,
+			}
+		}),
+	}
+}
+
 func (s *StartedService) Instance() *Instance {
 	s.serviceAccess.RLock()
 	defer s.serviceAccess.RUnlock()
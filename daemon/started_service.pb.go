@@ -1722,6 +1722,898 @@ func (x *StartedAt) GetStartedAt() int64 {
 	return 0
 }
 
+type DNSQueries struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Queries       []*DNSQuery            `protobuf:"bytes,1,rep,name=queries,proto3" json:"queries,omitempty"`
+	Reset_        bool                   `protobuf:"varint,2,opt,name=reset,proto3" json:"reset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DNSQueries) Reset() {
+	*x = DNSQueries{}
+	mi := &file_daemon_started_service_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DNSQueries) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DNSQueries) ProtoMessage() {}
+
+func (x *DNSQueries) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_started_service_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DNSQueries.ProtoReflect.Descriptor instead.
+func (*DNSQueries) Descriptor() ([]byte, []int) {
+	return file_daemon_started_service_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *DNSQueries) GetQueries() []*DNSQuery {
+	if x != nil {
+		return x.Queries
+	}
+	return nil
+}
+
+func (x *DNSQueries) GetReset_() bool {
+	if x != nil {
+		return x.Reset_
+	}
+	return false
+}
+
+type DNSQuery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          int64                  `protobuf:"varint,1,opt,name=time,proto3" json:"time,omitempty"`
+	Inbound       string                 `protobuf:"bytes,2,opt,name=inbound,proto3" json:"inbound,omitempty"`
+	InboundType   string                 `protobuf:"bytes,3,opt,name=inboundType,proto3" json:"inboundType,omitempty"`
+	Source        string                 `protobuf:"bytes,4,opt,name=source,proto3" json:"source,omitempty"`
+	Domain        string                 `protobuf:"bytes,5,opt,name=domain,proto3" json:"domain,omitempty"`
+	QueryType     string                 `protobuf:"bytes,6,opt,name=queryType,proto3" json:"queryType,omitempty"`
+	RuleIndex     int32                  `protobuf:"varint,7,opt,name=ruleIndex,proto3" json:"ruleIndex,omitempty"`
+	Rule          string                 `protobuf:"bytes,8,opt,name=rule,proto3" json:"rule,omitempty"`
+	Action        string                 `protobuf:"bytes,9,opt,name=action,proto3" json:"action,omitempty"`
+	Transport     string                 `protobuf:"bytes,10,opt,name=transport,proto3" json:"transport,omitempty"`
+	Rcode         string                 `protobuf:"bytes,11,opt,name=rcode,proto3" json:"rcode,omitempty"`
+	Answers       []string               `protobuf:"bytes,12,rep,name=answers,proto3" json:"answers,omitempty"`
+	Latency       int64                  `protobuf:"varint,13,opt,name=latency,proto3" json:"latency,omitempty"`
+	Cached        bool                   `protobuf:"varint,14,opt,name=cached,proto3" json:"cached,omitempty"`
+	Blocked       bool                   `protobuf:"varint,15,opt,name=blocked,proto3" json:"blocked,omitempty"`
+	Error         string                 `protobuf:"bytes,16,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DNSQuery) Reset() {
+	*x = DNSQuery{}
+	mi := &file_daemon_started_service_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DNSQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DNSQuery) ProtoMessage() {}
+
+func (x *DNSQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_started_service_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DNSQuery.ProtoReflect.Descriptor instead.
+func (*DNSQuery) Descriptor() ([]byte, []int) {
+	return file_daemon_started_service_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *DNSQuery) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *DNSQuery) GetInbound() string {
+	if x != nil {
+		return x.Inbound
+	}
+	return ""
+}
+
+func (x *DNSQuery) GetInboundType() string {
+	if x != nil {
+		return x.InboundType
+	}
+	return ""
+}
+
+func (x *DNSQuery) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *DNSQuery) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *DNSQuery) GetQueryType() string {
+	if x != nil {
+		return x.QueryType
+	}
+	return ""
+}
+
+func (x *DNSQuery) GetRuleIndex() int32 {
+	if x != nil {
+		return x.RuleIndex
+	}
+	return 0
+}
+
+func (x *DNSQuery) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *DNSQuery) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *DNSQuery) GetTransport() string {
+	if x != nil {
+		return x.Transport
+	}
+	return ""
+}
+
+func (x *DNSQuery) GetRcode() string {
+	if x != nil {
+		return x.Rcode
+	}
+	return ""
+}
+
+func (x *DNSQuery) GetAnswers() []string {
+	if x != nil {
+		return x.Answers
+	}
+	return nil
+}
+
+func (x *DNSQuery) GetLatency() int64 {
+	if x != nil {
+		return x.Latency
+	}
+	return 0
+}
+
+func (x *DNSQuery) GetCached() bool {
+	if x != nil {
+		return x.Cached
+	}
+	return false
+}
+
+func (x *DNSQuery) GetBlocked() bool {
+	if x != nil {
+		return x.Blocked
+	}
+	return false
+}
+
+func (x *DNSQuery) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type GetDNSStatisticsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDNSStatisticsRequest) Reset() {
+	*x = GetDNSStatisticsRequest{}
+	mi := &file_daemon_started_service_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDNSStatisticsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDNSStatisticsRequest) ProtoMessage() {}
+
+func (x *GetDNSStatisticsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_started_service_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDNSStatisticsRequest.ProtoReflect.Descriptor instead.
+func (*GetDNSStatisticsRequest) Descriptor() ([]byte, []int) {
+	return file_daemon_started_service_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *GetDNSStatisticsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type DNSStatistics struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         int64                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Cached        int64                  `protobuf:"varint,2,opt,name=cached,proto3" json:"cached,omitempty"`
+	Blocked       int64                  `protobuf:"varint,3,opt,name=blocked,proto3" json:"blocked,omitempty"`
+	Failed        int64                  `protobuf:"varint,4,opt,name=failed,proto3" json:"failed,omitempty"`
+	TopDomains    []*DNSDomainCount      `protobuf:"bytes,5,rep,name=topDomains,proto3" json:"topDomains,omitempty"`
+	TopBlocked    []*DNSDomainCount      `protobuf:"bytes,6,rep,name=topBlocked,proto3" json:"topBlocked,omitempty"`
+	Upstreams     []*DNSUpstreamLatency  `protobuf:"bytes,7,rep,name=upstreams,proto3" json:"upstreams,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DNSStatistics) Reset() {
+	*x = DNSStatistics{}
+	mi := &file_daemon_started_service_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DNSStatistics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DNSStatistics) ProtoMessage() {}
+
+func (x *DNSStatistics) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_started_service_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DNSStatistics.ProtoReflect.Descriptor instead.
+func (*DNSStatistics) Descriptor() ([]byte, []int) {
+	return file_daemon_started_service_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *DNSStatistics) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *DNSStatistics) GetCached() int64 {
+	if x != nil {
+		return x.Cached
+	}
+	return 0
+}
+
+func (x *DNSStatistics) GetBlocked() int64 {
+	if x != nil {
+		return x.Blocked
+	}
+	return 0
+}
+
+func (x *DNSStatistics) GetFailed() int64 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *DNSStatistics) GetTopDomains() []*DNSDomainCount {
+	if x != nil {
+		return x.TopDomains
+	}
+	return nil
+}
+
+func (x *DNSStatistics) GetTopBlocked() []*DNSDomainCount {
+	if x != nil {
+		return x.TopBlocked
+	}
+	return nil
+}
+
+func (x *DNSStatistics) GetUpstreams() []*DNSUpstreamLatency {
+	if x != nil {
+		return x.Upstreams
+	}
+	return nil
+}
+
+type DNSDomainCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Domain        string                 `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DNSDomainCount) Reset() {
+	*x = DNSDomainCount{}
+	mi := &file_daemon_started_service_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DNSDomainCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DNSDomainCount) ProtoMessage() {}
+
+func (x *DNSDomainCount) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_started_service_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DNSDomainCount.ProtoReflect.Descriptor instead.
+func (*DNSDomainCount) Descriptor() ([]byte, []int) {
+	return file_daemon_started_service_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *DNSDomainCount) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *DNSDomainCount) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type DNSUpstreamLatency struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transport     string                 `protobuf:"bytes,1,opt,name=transport,proto3" json:"transport,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	P50           int64                  `protobuf:"varint,3,opt,name=p50,proto3" json:"p50,omitempty"`
+	P90           int64                  `protobuf:"varint,4,opt,name=p90,proto3" json:"p90,omitempty"`
+	P99           int64                  `protobuf:"varint,5,opt,name=p99,proto3" json:"p99,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DNSUpstreamLatency) Reset() {
+	*x = DNSUpstreamLatency{}
+	mi := &file_daemon_started_service_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DNSUpstreamLatency) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DNSUpstreamLatency) ProtoMessage() {}
+
+func (x *DNSUpstreamLatency) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_started_service_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DNSUpstreamLatency.ProtoReflect.Descriptor instead.
+func (*DNSUpstreamLatency) Descriptor() ([]byte, []int) {
+	return file_daemon_started_service_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *DNSUpstreamLatency) GetTransport() string {
+	if x != nil {
+		return x.Transport
+	}
+	return ""
+}
+
+func (x *DNSUpstreamLatency) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *DNSUpstreamLatency) GetP50() int64 {
+	if x != nil {
+		return x.P50
+	}
+	return 0
+}
+
+func (x *DNSUpstreamLatency) GetP90() int64 {
+	if x != nil {
+		return x.P90
+	}
+	return 0
+}
+
+func (x *DNSUpstreamLatency) GetP99() int64 {
+	if x != nil {
+		return x.P99
+	}
+	return 0
+}
+
+type ExplainRouteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Inbound       string                 `protobuf:"bytes,1,opt,name=inbound,proto3" json:"inbound,omitempty"`
+	Network       string                 `protobuf:"bytes,2,opt,name=network,proto3" json:"network,omitempty"`
+	Source        string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	Destination   string                 `protobuf:"bytes,4,opt,name=destination,proto3" json:"destination,omitempty"`
+	Domain        string                 `protobuf:"bytes,5,opt,name=domain,proto3" json:"domain,omitempty"`
+	Protocol      string                 `protobuf:"bytes,6,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	ProcessPath   string                 `protobuf:"bytes,7,opt,name=processPath,proto3" json:"processPath,omitempty"`
+	PackageName   string                 `protobuf:"bytes,8,opt,name=packageName,proto3" json:"packageName,omitempty"`
+	User          string                 `protobuf:"bytes,9,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExplainRouteRequest) Reset() {
+	*x = ExplainRouteRequest{}
+	mi := &file_daemon_started_service_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExplainRouteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExplainRouteRequest) ProtoMessage() {}
+
+func (x *ExplainRouteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_started_service_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExplainRouteRequest.ProtoReflect.Descriptor instead.
+func (*ExplainRouteRequest) Descriptor() ([]byte, []int) {
+	return file_daemon_started_service_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ExplainRouteRequest) GetInbound() string {
+	if x != nil {
+		return x.Inbound
+	}
+	return ""
+}
+
+func (x *ExplainRouteRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *ExplainRouteRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ExplainRouteRequest) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *ExplainRouteRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *ExplainRouteRequest) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *ExplainRouteRequest) GetProcessPath() string {
+	if x != nil {
+		return x.ProcessPath
+	}
+	return ""
+}
+
+func (x *ExplainRouteRequest) GetPackageName() string {
+	if x != nil {
+		return x.PackageName
+	}
+	return ""
+}
+
+func (x *ExplainRouteRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+type RouteExplanation struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Destination          string                 `protobuf:"bytes,1,opt,name=destination,proto3" json:"destination,omitempty"`
+	DestinationAddresses []string               `protobuf:"bytes,2,rep,name=destinationAddresses,proto3" json:"destinationAddresses,omitempty"`
+	Rules                []*RuleExplanation     `protobuf:"bytes,3,rep,name=rules,proto3" json:"rules,omitempty"`
+	RuleIndex            int32                  `protobuf:"varint,4,opt,name=ruleIndex,proto3" json:"ruleIndex,omitempty"`
+	Rule                 string                 `protobuf:"bytes,5,opt,name=rule,proto3" json:"rule,omitempty"`
+	Action               string                 `protobuf:"bytes,6,opt,name=action,proto3" json:"action,omitempty"`
+	Outbound             string                 `protobuf:"bytes,7,opt,name=outbound,proto3" json:"outbound,omitempty"`
+	Dns                  *DNSExplanation        `protobuf:"bytes,8,opt,name=dns,proto3" json:"dns,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *RouteExplanation) Reset() {
+	*x = RouteExplanation{}
+	mi := &file_daemon_started_service_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RouteExplanation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteExplanation) ProtoMessage() {}
+
+func (x *RouteExplanation) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_started_service_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteExplanation.ProtoReflect.Descriptor instead.
+func (*RouteExplanation) Descriptor() ([]byte, []int) {
+	return file_daemon_started_service_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *RouteExplanation) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *RouteExplanation) GetDestinationAddresses() []string {
+	if x != nil {
+		return x.DestinationAddresses
+	}
+	return nil
+}
+
+func (x *RouteExplanation) GetRules() []*RuleExplanation {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *RouteExplanation) GetRuleIndex() int32 {
+	if x != nil {
+		return x.RuleIndex
+	}
+	return 0
+}
+
+func (x *RouteExplanation) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *RouteExplanation) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *RouteExplanation) GetOutbound() string {
+	if x != nil {
+		return x.Outbound
+	}
+	return ""
+}
+
+func (x *RouteExplanation) GetDns() *DNSExplanation {
+	if x != nil {
+		return x.Dns
+	}
+	return nil
+}
+
+type RuleExplanation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Rule          string                 `protobuf:"bytes,2,opt,name=rule,proto3" json:"rule,omitempty"`
+	Action        string                 `protobuf:"bytes,3,opt,name=action,proto3" json:"action,omitempty"`
+	Matched       bool                   `protobuf:"varint,4,opt,name=matched,proto3" json:"matched,omitempty"`
+	Items         []*RuleItemExplanation `protobuf:"bytes,5,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RuleExplanation) Reset() {
+	*x = RuleExplanation{}
+	mi := &file_daemon_started_service_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RuleExplanation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RuleExplanation) ProtoMessage() {}
+
+func (x *RuleExplanation) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_started_service_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RuleExplanation.ProtoReflect.Descriptor instead.
+func (*RuleExplanation) Descriptor() ([]byte, []int) {
+	return file_daemon_started_service_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *RuleExplanation) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *RuleExplanation) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *RuleExplanation) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *RuleExplanation) GetMatched() bool {
+	if x != nil {
+		return x.Matched
+	}
+	return false
+}
+
+func (x *RuleExplanation) GetItems() []*RuleItemExplanation {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type RuleItemExplanation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Item          string                 `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	Matched       bool                   `protobuf:"varint,2,opt,name=matched,proto3" json:"matched,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RuleItemExplanation) Reset() {
+	*x = RuleItemExplanation{}
+	mi := &file_daemon_started_service_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RuleItemExplanation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RuleItemExplanation) ProtoMessage() {}
+
+func (x *RuleItemExplanation) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_started_service_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RuleItemExplanation.ProtoReflect.Descriptor instead.
+func (*RuleItemExplanation) Descriptor() ([]byte, []int) {
+	return file_daemon_started_service_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *RuleItemExplanation) GetItem() string {
+	if x != nil {
+		return x.Item
+	}
+	return ""
+}
+
+func (x *RuleItemExplanation) GetMatched() bool {
+	if x != nil {
+		return x.Matched
+	}
+	return false
+}
+
+type DNSExplanation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rules         []*RuleExplanation     `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
+	RuleIndex     int32                  `protobuf:"varint,2,opt,name=ruleIndex,proto3" json:"ruleIndex,omitempty"`
+	Rule          string                 `protobuf:"bytes,3,opt,name=rule,proto3" json:"rule,omitempty"`
+	Action        string                 `protobuf:"bytes,4,opt,name=action,proto3" json:"action,omitempty"`
+	Server        string                 `protobuf:"bytes,5,opt,name=server,proto3" json:"server,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DNSExplanation) Reset() {
+	*x = DNSExplanation{}
+	mi := &file_daemon_started_service_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DNSExplanation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DNSExplanation) ProtoMessage() {}
+
+func (x *DNSExplanation) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_started_service_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DNSExplanation.ProtoReflect.Descriptor instead.
+func (*DNSExplanation) Descriptor() ([]byte, []int) {
+	return file_daemon_started_service_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *DNSExplanation) GetRules() []*RuleExplanation {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *DNSExplanation) GetRuleIndex() int32 {
+	if x != nil {
+		return x.RuleIndex
+	}
+	return 0
+}
+
+func (x *DNSExplanation) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *DNSExplanation) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *DNSExplanation) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
 type Log_Message struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Level         LogLevel               `protobuf:"varint,1,opt,name=level,proto3,enum=daemon.LogLevel" json:"level,omitempty"`
@@ -1732,7 +2624,7 @@ type Log_Message struct {
 
 func (x *Log_Message) Reset() {
 	*x = Log_Message{}
-	mi := &file_daemon_started_service_proto_msgTypes[25]
+	mi := &file_daemon_started_service_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1744,7 +2636,7 @@ func (x *Log_Message) String() string {
 func (*Log_Message) ProtoMessage() {}
 
 func (x *Log_Message) ProtoReflect() protoreflect.Message {
-	mi := &file_daemon_started_service_proto_msgTypes[25]
+	mi := &file_daemon_started_service_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1900,7 +2792,86 @@ const file_daemon_started_service_proto_rawDesc = "" +
 	"\timpending\x18\x02 \x01(\bR\timpending\x12$\n" +
 	"\rmigrationLink\x18\x03 \x01(\tR\rmigrationLink\")\n" +
 	"\tStartedAt\x12\x1c\n" +
-	"\tstartedAt\x18\x01 \x01(\x03R\tstartedAt*U\n" +
+	"\tstartedAt\x18\x01 \x01(\x03R\tstartedAt\"N\n" +
+	"\n" +
+	"DNSQueries\x12*\n" +
+	"\aqueries\x18\x01 \x03(\v2\x10.daemon.DNSQueryR\aqueries\x12\x14\n" +
+	"\x05reset\x18\x02 \x01(\bR\x05reset\"\xa2\x03\n" +
+	"\bDNSQuery\x12\x12\n" +
+	"\x04time\x18\x01 \x01(\x03R\x04time\x12\x18\n" +
+	"\ainbound\x18\x02 \x01(\tR\ainbound\x12 \n" +
+	"\vinboundType\x18\x03 \x01(\tR\vinboundType\x12\x16\n" +
+	"\x06source\x18\x04 \x01(\tR\x06source\x12\x16\n" +
+	"\x06domain\x18\x05 \x01(\tR\x06domain\x12\x1c\n" +
+	"\tqueryType\x18\x06 \x01(\tR\tqueryType\x12\x1c\n" +
+	"\truleIndex\x18\a \x01(\x05R\truleIndex\x12\x12\n" +
+	"\x04rule\x18\b \x01(\tR\x04rule\x12\x16\n" +
+	"\x06action\x18\t \x01(\tR\x06action\x12\x1c\n" +
+	"\ttransport\x18\n" +
+	" \x01(\tR\ttransport\x12\x14\n" +
+	"\x05rcode\x18\v \x01(\tR\x05rcode\x12\x18\n" +
+	"\aanswers\x18\f \x03(\tR\aanswers\x12\x18\n" +
+	"\alatency\x18\r \x01(\x03R\alatency\x12\x16\n" +
+	"\x06cached\x18\x0e \x01(\bR\x06cached\x12\x18\n" +
+	"\ablocked\x18\x0f \x01(\bR\ablocked\x12\x14\n" +
+	"\x05error\x18\x10 \x01(\tR\x05error\"/\n" +
+	"\x17GetDNSStatisticsRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\"\x99\x02\n" +
+	"\rDNSStatistics\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x03R\x05total\x12\x16\n" +
+	"\x06cached\x18\x02 \x01(\x03R\x06cached\x12\x18\n" +
+	"\ablocked\x18\x03 \x01(\x03R\ablocked\x12\x16\n" +
+	"\x06failed\x18\x04 \x01(\x03R\x06failed\x126\n" +
+	"\n" +
+	"topDomains\x18\x05 \x03(\v2\x16.daemon.DNSDomainCountR\n" +
+	"topDomains\x126\n" +
+	"\n" +
+	"topBlocked\x18\x06 \x03(\v2\x16.daemon.DNSDomainCountR\n" +
+	"topBlocked\x128\n" +
+	"\tupstreams\x18\a \x03(\v2\x1a.daemon.DNSUpstreamLatencyR\tupstreams\">\n" +
+	"\x0eDNSDomainCount\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\"~\n" +
+	"\x12DNSUpstreamLatency\x12\x1c\n" +
+	"\ttransport\x18\x01 \x01(\tR\ttransport\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\x12\x10\n" +
+	"\x03p50\x18\x03 \x01(\x03R\x03p50\x12\x10\n" +
+	"\x03p90\x18\x04 \x01(\x03R\x03p90\x12\x10\n" +
+	"\x03p99\x18\x05 \x01(\x03R\x03p99\"\x8f\x02\n" +
+	"\x13ExplainRouteRequest\x12\x18\n" +
+	"\ainbound\x18\x01 \x01(\tR\ainbound\x12\x18\n" +
+	"\anetwork\x18\x02 \x01(\tR\anetwork\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12 \n" +
+	"\vdestination\x18\x04 \x01(\tR\vdestination\x12\x16\n" +
+	"\x06domain\x18\x05 \x01(\tR\x06domain\x12\x1a\n" +
+	"\bprotocol\x18\x06 \x01(\tR\bprotocol\x12 \n" +
+	"\vprocessPath\x18\a \x01(\tR\vprocessPath\x12 \n" +
+	"\vpackageName\x18\b \x01(\tR\vpackageName\x12\x12\n" +
+	"\x04user\x18\t \x01(\tR\x04user\"\xa7\x02\n" +
+	"\x10RouteExplanation\x12 \n" +
+	"\vdestination\x18\x01 \x01(\tR\vdestination\x122\n" +
+	"\x14destinationAddresses\x18\x02 \x03(\tR\x14destinationAddresses\x12-\n" +
+	"\x05rules\x18\x03 \x03(\v2\x17.daemon.RuleExplanationR\x05rules\x12\x1c\n" +
+	"\truleIndex\x18\x04 \x01(\x05R\truleIndex\x12\x12\n" +
+	"\x04rule\x18\x05 \x01(\tR\x04rule\x12\x16\n" +
+	"\x06action\x18\x06 \x01(\tR\x06action\x12\x1a\n" +
+	"\boutbound\x18\a \x01(\tR\boutbound\x12(\n" +
+	"\x03dns\x18\b \x01(\v2\x16.daemon.DNSExplanationR\x03dns\"\xa0\x01\n" +
+	"\x0fRuleExplanation\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x12\n" +
+	"\x04rule\x18\x02 \x01(\tR\x04rule\x12\x16\n" +
+	"\x06action\x18\x03 \x01(\tR\x06action\x12\x18\n" +
+	"\amatched\x18\x04 \x01(\bR\amatched\x121\n" +
+	"\x05items\x18\x05 \x03(\v2\x1b.daemon.RuleItemExplanationR\x05items\"C\n" +
+	"\x13RuleItemExplanation\x12\x12\n" +
+	"\x04item\x18\x01 \x01(\tR\x04item\x12\x18\n" +
+	"\amatched\x18\x02 \x01(\bR\amatched\"\xa1\x01\n" +
+	"\x0eDNSExplanation\x12-\n" +
+	"\x05rules\x18\x01 \x03(\v2\x17.daemon.RuleExplanationR\x05rules\x12\x1c\n" +
+	"\truleIndex\x18\x02 \x01(\x05R\truleIndex\x12\x12\n" +
+	"\x04rule\x18\x03 \x01(\tR\x04rule\x12\x16\n" +
+	"\x06action\x18\x04 \x01(\tR\x06action\x12\x16\n" +
+	"\x06server\x18\x05 \x01(\tR\x06server*U\n" +
 	"\bLogLevel\x12\t\n" +
 	"\x05PANIC\x10\x00\x12\t\n" +
 	"\x05FATAL\x10\x01\x12\t\n" +
@@ -1912,7 +2883,7 @@ const file_daemon_started_service_proto_rawDesc = "" +
 	"\x13ConnectionEventType\x12\x18\n" +
 	"\x14CONNECTION_EVENT_NEW\x10\x00\x12\x1b\n" +
 	"\x17CONNECTION_EVENT_UPDATE\x10\x01\x12\x1b\n" +
-	"\x17CONNECTION_EVENT_CLOSED\x10\x022\xe5\v\n" +
+	"\x17CONNECTION_EVENT_CLOSED\x10\x022\xc3\r\n" +
 	"\x0eStartedService\x12=\n" +
 	"\vStopService\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\x12?\n" +
 	"\rReloadService\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\x12K\n" +
@@ -1934,7 +2905,10 @@ const file_daemon_started_service_proto_rawDesc = "" +
 	"\x0fCloseConnection\x12\x1e.daemon.CloseConnectionRequest\x1a\x16.google.protobuf.Empty\"\x00\x12G\n" +
 	"\x13CloseAllConnections\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\"\x00\x12M\n" +
 	"\x15GetDeprecatedWarnings\x12\x16.google.protobuf.Empty\x1a\x1a.daemon.DeprecatedWarnings\"\x00\x12;\n" +
-	"\fGetStartedAt\x12\x16.google.protobuf.Empty\x1a\x11.daemon.StartedAt\"\x00B%Z#github.com/sagernet/sing-box/daemonb\x06proto3"
+	"\fGetStartedAt\x12\x16.google.protobuf.Empty\x1a\x11.daemon.StartedAt\"\x00\x12E\n" +
+	"\x13SubscribeDNSQueries\x12\x16.google.protobuf.Empty\x1a\x12.daemon.DNSQueries\"\x000\x01\x12L\n" +
+	"\x10GetDNSStatistics\x12\x1f.daemon.GetDNSStatisticsRequest\x1a\x15.daemon.DNSStatistics\"\x00\x12G\n" +
+	"\fExplainRoute\x12\x1b.daemon.ExplainRouteRequest\x1a\x18.daemon.RouteExplanation\"\x00B%Z#github.com/sagernet/sing-box/daemonb\x06proto3"
 
 var (
 	file_daemon_started_service_proto_rawDescOnce sync.Once
@@ -1950,7 +2924,7 @@ func file_daemon_started_service_proto_rawDescGZIP() []byte {
 
 var (
 	file_daemon_started_service_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-	file_daemon_started_service_proto_msgTypes  = make([]protoimpl.MessageInfo, 26)
+	file_daemon_started_service_proto_msgTypes  = make([]protoimpl.MessageInfo, 37)
 	file_daemon_started_service_proto_goTypes   = []any{
 		(LogLevel)(0),                        // 0: daemon.LogLevel
 		(ConnectionEventType)(0),             // 1: daemon.ConnectionEventType
@@ -1980,14 +2954,25 @@ var (
 		(*DeprecatedWarnings)(nil),           // 25: daemon.DeprecatedWarnings
 		(*DeprecatedWarning)(nil),            // 26: daemon.DeprecatedWarning
 		(*StartedAt)(nil),                    // 27: daemon.StartedAt
-		(*Log_Message)(nil),                  // 28: daemon.Log.Message
-		(*emptypb.Empty)(nil),                // 29: google.protobuf.Empty
+		(*DNSQueries)(nil),                   // 28: daemon.DNSQueries
+		(*DNSQuery)(nil),                     // 29: daemon.DNSQuery
+		(*GetDNSStatisticsRequest)(nil),      // 30: daemon.GetDNSStatisticsRequest
+		(*DNSStatistics)(nil),                // 31: daemon.DNSStatistics
+		(*DNSDomainCount)(nil),               // 32: daemon.DNSDomainCount
+		(*DNSUpstreamLatency)(nil),           // 33: daemon.DNSUpstreamLatency
+		(*ExplainRouteRequest)(nil),          // 34: daemon.ExplainRouteRequest
+		(*RouteExplanation)(nil),             // 35: daemon.RouteExplanation
+		(*RuleExplanation)(nil),              // 36: daemon.RuleExplanation
+		(*RuleItemExplanation)(nil),          // 37: daemon.RuleItemExplanation
+		(*DNSExplanation)(nil),               // 38: daemon.DNSExplanation
+		(*Log_Message)(nil),                  // 39: daemon.Log.Message
+		(*emptypb.Empty)(nil),                // 40: google.protobuf.Empty
 	}
 )
 
 var file_daemon_started_service_proto_depIdxs = []int32{
 	2,  // 0: daemon.ServiceStatus.status:type_name -> daemon.ServiceStatus.Type
-	28, // 1: daemon.Log.messages:type_name -> daemon.Log.Message
+	39, // 1: daemon.Log.messages:type_name -> daemon.Log.Message
 	0,  // 2: daemon.DefaultLogLevel.level:type_name -> daemon.LogLevel
 	10, // 3: daemon.Groups.group:type_name -> daemon.Group
 	11, // 4: daemon.Group.items:type_name -> daemon.GroupItem
@@ -1996,54 +2981,68 @@ var file_daemon_started_service_proto_depIdxs = []int32{
 	20, // 7: daemon.ConnectionEvents.events:type_name -> daemon.ConnectionEvent
 	23, // 8: daemon.Connection.processInfo:type_name -> daemon.ProcessInfo
 	26, // 9: daemon.DeprecatedWarnings.warnings:type_name -> daemon.DeprecatedWarning
-	0,  // 10: daemon.Log.Message.level:type_name -> daemon.LogLevel
-	29, // 11: daemon.StartedService.StopService:input_type -> google.protobuf.Empty
-	29, // 12: daemon.StartedService.ReloadService:input_type -> google.protobuf.Empty
-	29, // 13: daemon.StartedService.SubscribeServiceStatus:input_type -> google.protobuf.Empty
-	29, // 14: daemon.StartedService.SubscribeLog:input_type -> google.protobuf.Empty
-	29, // 15: daemon.StartedService.GetDefaultLogLevel:input_type -> google.protobuf.Empty
-	29, // 16: daemon.StartedService.ClearLogs:input_type -> google.protobuf.Empty
-	5,  // 17: daemon.StartedService.SubscribeStatus:input_type -> daemon.SubscribeStatusRequest
-	29, // 18: daemon.StartedService.SubscribeGroups:input_type -> google.protobuf.Empty
-	29, // 19: daemon.StartedService.GetClashModeStatus:input_type -> google.protobuf.Empty
-	29, // 20: daemon.StartedService.SubscribeClashMode:input_type -> google.protobuf.Empty
-	15, // 21: daemon.StartedService.SetClashMode:input_type -> daemon.ClashMode
-	12, // 22: daemon.StartedService.URLTest:input_type -> daemon.URLTestRequest
-	13, // 23: daemon.StartedService.SelectOutbound:input_type -> daemon.SelectOutboundRequest
-	14, // 24: daemon.StartedService.SetGroupExpand:input_type -> daemon.SetGroupExpandRequest
-	29, // 25: daemon.StartedService.GetSystemProxyStatus:input_type -> google.protobuf.Empty
-	18, // 26: daemon.StartedService.SetSystemProxyEnabled:input_type -> daemon.SetSystemProxyEnabledRequest
-	19, // 27: daemon.StartedService.SubscribeConnections:input_type -> daemon.SubscribeConnectionsRequest
-	24, // 28: daemon.StartedService.CloseConnection:input_type -> daemon.CloseConnectionRequest
-	29, // 29: daemon.StartedService.CloseAllConnections:input_type -> google.protobuf.Empty
-	29, // 30: daemon.StartedService.GetDeprecatedWarnings:input_type -> google.protobuf.Empty
-	29, // 31: daemon.StartedService.GetStartedAt:input_type -> google.protobuf.Empty
-	29, // 32: daemon.StartedService.StopService:output_type -> google.protobuf.Empty
-	29, // 33: daemon.StartedService.ReloadService:output_type -> google.protobuf.Empty
-	3,  // 34: daemon.StartedService.SubscribeServiceStatus:output_type -> daemon.ServiceStatus
-	6,  // 35: daemon.StartedService.SubscribeLog:output_type -> daemon.Log
-	7,  // 36: daemon.StartedService.GetDefaultLogLevel:output_type -> daemon.DefaultLogLevel
-	29, // 37: daemon.StartedService.ClearLogs:output_type -> google.protobuf.Empty
-	8,  // 38: daemon.StartedService.SubscribeStatus:output_type -> daemon.Status
-	9,  // 39: daemon.StartedService.SubscribeGroups:output_type -> daemon.Groups
-	16, // 40: daemon.StartedService.GetClashModeStatus:output_type -> daemon.ClashModeStatus
-	15, // 41: daemon.StartedService.SubscribeClashMode:output_type -> daemon.ClashMode
-	29, // 42: daemon.StartedService.SetClashMode:output_type -> google.protobuf.Empty
-	29, // 43: daemon.StartedService.URLTest:output_type -> google.protobuf.Empty
-	29, // 44: daemon.StartedService.SelectOutbound:output_type -> google.protobuf.Empty
-	29, // 45: daemon.StartedService.SetGroupExpand:output_type -> google.protobuf.Empty
-	17, // 46: daemon.StartedService.GetSystemProxyStatus:output_type -> daemon.SystemProxyStatus
-	29, // 47: daemon.StartedService.SetSystemProxyEnabled:output_type -> google.protobuf.Empty
-	21, // 48: daemon.StartedService.SubscribeConnections:output_type -> daemon.ConnectionEvents
-	29, // 49: daemon.StartedService.CloseConnection:output_type -> google.protobuf.Empty
-	29, // 50: daemon.StartedService.CloseAllConnections:output_type -> google.protobuf.Empty
-	25, // 51: daemon.StartedService.GetDeprecatedWarnings:output_type -> daemon.DeprecatedWarnings
-	27, // 52: daemon.StartedService.GetStartedAt:output_type -> daemon.StartedAt
-	32, // [32:53] is the sub-list for method output_type
-	11, // [11:32] is the sub-list for method input_type
-	11, // [11:11] is the sub-list for extension type_name
-	11, // [11:11] is the sub-list for extension extendee
-	0,  // [0:11] is the sub-list for field type_name
+	29, // 10: daemon.DNSQueries.queries:type_name -> daemon.DNSQuery
+	32, // 11: daemon.DNSStatistics.topDomains:type_name -> daemon.DNSDomainCount
+	32, // 12: daemon.DNSStatistics.topBlocked:type_name -> daemon.DNSDomainCount
+	33, // 13: daemon.DNSStatistics.upstreams:type_name -> daemon.DNSUpstreamLatency
+	36, // 14: daemon.RouteExplanation.rules:type_name -> daemon.RuleExplanation
+	38, // 15: daemon.RouteExplanation.dns:type_name -> daemon.DNSExplanation
+	37, // 16: daemon.RuleExplanation.items:type_name -> daemon.RuleItemExplanation
+	36, // 17: daemon.DNSExplanation.rules:type_name -> daemon.RuleExplanation
+	0,  // 18: daemon.Log.Message.level:type_name -> daemon.LogLevel
+	40, // 19: daemon.StartedService.StopService:input_type -> google.protobuf.Empty
+	40, // 20: daemon.StartedService.ReloadService:input_type -> google.protobuf.Empty
+	40, // 21: daemon.StartedService.SubscribeServiceStatus:input_type -> google.protobuf.Empty
+	40, // 22: daemon.StartedService.SubscribeLog:input_type -> google.protobuf.Empty
+	40, // 23: daemon.StartedService.GetDefaultLogLevel:input_type -> google.protobuf.Empty
+	40, // 24: daemon.StartedService.ClearLogs:input_type -> google.protobuf.Empty
+	5,  // 25: daemon.StartedService.SubscribeStatus:input_type -> daemon.SubscribeStatusRequest
+	40, // 26: daemon.StartedService.SubscribeGroups:input_type -> google.protobuf.Empty
+	40, // 27: daemon.StartedService.GetClashModeStatus:input_type -> google.protobuf.Empty
+	40, // 28: daemon.StartedService.SubscribeClashMode:input_type -> google.protobuf.Empty
+	15, // 29: daemon.StartedService.SetClashMode:input_type -> daemon.ClashMode
+	12, // 30: daemon.StartedService.URLTest:input_type -> daemon.URLTestRequest
+	13, // 31: daemon.StartedService.SelectOutbound:input_type -> daemon.SelectOutboundRequest
+	14, // 32: daemon.StartedService.SetGroupExpand:input_type -> daemon.SetGroupExpandRequest
+	40, // 33: daemon.StartedService.GetSystemProxyStatus:input_type -> google.protobuf.Empty
+	18, // 34: daemon.StartedService.SetSystemProxyEnabled:input_type -> daemon.SetSystemProxyEnabledRequest
+	19, // 35: daemon.StartedService.SubscribeConnections:input_type -> daemon.SubscribeConnectionsRequest
+	24, // 36: daemon.StartedService.CloseConnection:input_type -> daemon.CloseConnectionRequest
+	40, // 37: daemon.StartedService.CloseAllConnections:input_type -> google.protobuf.Empty
+	40, // 38: daemon.StartedService.GetDeprecatedWarnings:input_type -> google.protobuf.Empty
+	40, // 39: daemon.StartedService.GetStartedAt:input_type -> google.protobuf.Empty
+	40, // 40: daemon.StartedService.SubscribeDNSQueries:input_type -> google.protobuf.Empty
+	30, // 41: daemon.StartedService.GetDNSStatistics:input_type -> daemon.GetDNSStatisticsRequest
+	34, // 42: daemon.StartedService.ExplainRoute:input_type -> daemon.ExplainRouteRequest
+	40, // 43: daemon.StartedService.StopService:output_type -> google.protobuf.Empty
+	40, // 44: daemon.StartedService.ReloadService:output_type -> google.protobuf.Empty
+	3,  // 45: daemon.StartedService.SubscribeServiceStatus:output_type -> daemon.ServiceStatus
+	6,  // 46: daemon.StartedService.SubscribeLog:output_type -> daemon.Log
+	7,  // 47: daemon.StartedService.GetDefaultLogLevel:output_type -> daemon.DefaultLogLevel
+	40, // 48: daemon.StartedService.ClearLogs:output_type -> google.protobuf.Empty
+	8,  // 49: daemon.StartedService.SubscribeStatus:output_type -> daemon.Status
+	9,  // 50: daemon.StartedService.SubscribeGroups:output_type -> daemon.Groups
+	16, // 51: daemon.StartedService.GetClashModeStatus:output_type -> daemon.ClashModeStatus
+	15, // 52: daemon.StartedService.SubscribeClashMode:output_type -> daemon.ClashMode
+	40, // 53: daemon.StartedService.SetClashMode:output_type -> google.protobuf.Empty
+	40, // 54: daemon.StartedService.URLTest:output_type -> google.protobuf.Empty
+	40, // 55: daemon.StartedService.SelectOutbound:output_type -> google.protobuf.Empty
+	40, // 56: daemon.StartedService.SetGroupExpand:output_type -> google.protobuf.Empty
+	17, // 57: daemon.StartedService.GetSystemProxyStatus:output_type -> daemon.SystemProxyStatus
+	40, // 58: daemon.StartedService.SetSystemProxyEnabled:output_type -> google.protobuf.Empty
+	21, // 59: daemon.StartedService.SubscribeConnections:output_type -> daemon.ConnectionEvents
+	40, // 60: daemon.StartedService.CloseConnection:output_type -> google.protobuf.Empty
+	40, // 61: daemon.StartedService.CloseAllConnections:output_type -> google.protobuf.Empty
+	25, // 62: daemon.StartedService.GetDeprecatedWarnings:output_type -> daemon.DeprecatedWarnings
+	27, // 63: daemon.StartedService.GetStartedAt:output_type -> daemon.StartedAt
+	28, // 64: daemon.StartedService.SubscribeDNSQueries:output_type -> daemon.DNSQueries
+	31, // 65: daemon.StartedService.GetDNSStatistics:output_type -> daemon.DNSStatistics
+	35, // 66: daemon.StartedService.ExplainRoute:output_type -> daemon.RouteExplanation
+	43, // [43:67] is the sub-list for method output_type
+	19, // [19:43] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_daemon_started_service_proto_init() }
@@ -2057,7 +3056,7 @@ func file_daemon_started_service_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_daemon_started_service_proto_rawDesc), len(file_daemon_started_service_proto_rawDesc)),
 			NumEnums:      3,
-			NumMessages:   26,
+			NumMessages:   37,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -169,6 +169,7 @@ func (c *Client) Exchange(ctx context.Context, transport adapter.DNSTransport, m
 		}
 		response, ttl := c.loadResponse(question, transport)
 		if response != nil {
+			markCachedResponse(ctx)
 			logCachedResponse(c.logger, ctx, response, ttl)
 			response.Id = message.Id
 			return response, nil
@@ -414,6 +415,7 @@ func (c *Client) lookupToExchange(ctx context.Context, transport adapter.DNSTran
 	if !disableCache {
 		cachedAddresses, err := c.questionCache(question, transport)
 		if err != ErrNotCached {
+			markCachedResponse(ctx)
 			return cachedAddresses, err
 		}
 	}
@@ -0,0 +1,41 @@
+package dns
+
+import (
+	"net/netip"
+)
+
+var DefaultDNS64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// SynthesizeDNS64 embeds an IPv4 address into a NAT64 prefix as described in RFC 6052.
+func SynthesizeDNS64(prefix netip.Prefix, address netip.Addr) netip.Addr {
+	prefixBytes := prefix.Masked().Addr().As16()
+	addressBytes := address.Unmap().As4()
+	position := prefix.Bits() / 8
+	for _, b := range addressBytes {
+		// bits 64 to 71 are reserved and must be zero
+		if position == 8 {
+			position++
+		}
+		prefixBytes[position] = b
+		position++
+	}
+	return netip.AddrFrom16(prefixBytes)
+}
+
+// ExtractDNS64 recovers the IPv4 address embedded by SynthesizeDNS64.
+func ExtractDNS64(prefix netip.Prefix, address netip.Addr) (netip.Addr, bool) {
+	if !address.Is6() || address.Is4In6() || !prefix.Contains(address) {
+		return netip.Addr{}, false
+	}
+	addressBytes := address.As16()
+	var embedded [4]byte
+	position := prefix.Bits() / 8
+	for i := range embedded {
+		if position == 8 {
+			position++
+		}
+		embedded[i] = addressBytes[position]
+		position++
+	}
+	return netip.AddrFrom4(embedded), true
+}
@@ -0,0 +1,35 @@
+package dns
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNS64(t *testing.T) {
+	t.Parallel()
+	// examples from RFC 6052 section 2.4
+	address := netip.MustParseAddr("192.0.2.33")
+	for _, testCase := range []struct {
+		prefix      string
+		synthesized string
+	}{
+		{"2001:db8::/32", "2001:db8:c000:221::"},
+		{"2001:db8:100::/40", "2001:db8:1c0:2:21::"},
+		{"2001:db8:122::/48", "2001:db8:122:c000:2:2100::"},
+		{"2001:db8:122:300::/56", "2001:db8:122:3c0:0:221::"},
+		{"2001:db8:122:344::/64", "2001:db8:122:344:c0:2:2100:0"},
+		{"2001:db8:122:344::/96", "2001:db8:122:344::192.0.2.33"},
+		{"64:ff9b::/96", "64:ff9b::192.0.2.33"},
+	} {
+		prefix := netip.MustParsePrefix(testCase.prefix)
+		synthesized := SynthesizeDNS64(prefix, address)
+		require.Equal(t, netip.MustParseAddr(testCase.synthesized), synthesized, testCase.prefix)
+		extracted, loaded := ExtractDNS64(prefix, synthesized)
+		require.True(t, loaded, testCase.prefix)
+		require.Equal(t, address, extracted, testCase.prefix)
+	}
+	_, loaded := ExtractDNS64(DefaultDNS64Prefix, netip.MustParseAddr("2001:db8::1"))
+	require.False(t, loaded)
+}
@@ -38,6 +38,8 @@ type Router struct {
 	defaultDomainStrategy C.DomainStrategy
 	dnsReverseMapping     freelru.Cache[netip.Addr, string]
 	platformInterface     adapter.PlatformInterface
+	trackers              []adapter.DNSQueryTracker
+	dns64Prefixes         []netip.Prefix
 }
 
 func NewRouter(ctx context.Context, logFactory log.Factory, options option.DNSOptions) *Router {
@@ -81,6 +83,7 @@ func (r *Router) Initialize(rules []option.DNSRule) error {
 		}
 		r.rules = append(r.rules, dnsRule)
 	}
+	r.initializeDNS64()
 	return nil
 }
 
@@ -117,11 +120,12 @@ func (r *Router) Close() error {
 	return err
 }
 
-func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int, isAddressQuery bool, options *adapter.DNSQueryOptions) (adapter.DNSTransport, adapter.DNSRule, int) {
+func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int, isAddressQuery bool, options *adapter.DNSQueryOptions, rewrites *[]*R.RuleActionDNSRewrite) (adapter.DNSTransport, adapter.DNSRule, int) {
 	metadata := adapter.ContextFrom(ctx)
 	if metadata == nil {
 		panic("no context")
 	}
+	explainer := R.DNSExplainerFromContext(ctx)
 	var currentRuleIndex int
 	if ruleIndex != -1 {
 		currentRuleIndex = ruleIndex + 1
@@ -132,7 +136,9 @@ func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int,
 			continue
 		}
 		metadata.ResetRuleCache()
-		if currentRule.Match(metadata) {
+		matched := currentRule.Match(metadata)
+		explainer.Record(currentRuleIndex, currentRule, metadata, matched)
+		if matched {
 			displayRuleIndex := currentRuleIndex
 			if displayRuleIndex != -1 {
 				displayRuleIndex += displayRuleIndex + 1
@@ -166,6 +172,9 @@ func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int,
 				if action.ClientSubnet.IsValid() {
 					options.ClientSubnet = action.ClientSubnet
 				}
+				if prefix := dns64Prefix(&action.RuleActionDNSRouteOptions); prefix.IsValid() {
+					options.DNS64Prefix = prefix
+				}
 				if legacyTransport, isLegacy := transport.(adapter.LegacyDNSTransport); isLegacy {
 					if options.Strategy == C.DomainStrategyAsIS {
 						options.Strategy = legacyTransport.LegacyStrategy()
@@ -188,6 +197,17 @@ func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int,
 				if action.ClientSubnet.IsValid() {
 					options.ClientSubnet = action.ClientSubnet
 				}
+				if prefix := dns64Prefix(action); prefix.IsValid() {
+					options.DNS64Prefix = prefix
+				}
+			case *R.RuleActionDNSRewrite:
+				if action.ClientSubnet.IsValid() {
+					options.ClientSubnet = action.ClientSubnet
+				}
+				*rewrites = append(*rewrites, action)
+				if action.CNAME != "" {
+					return nil, currentRule, currentRuleIndex
+				}
 			case *R.RuleActionReject:
 				return nil, currentRule, currentRuleIndex
 			case *R.RuleActionPredefined:
@@ -207,7 +227,7 @@ func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int,
 	return transport, nil, -1
 }
 
-func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg, options adapter.DNSQueryOptions) (*mDNS.Msg, error) {
+func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg, options adapter.DNSQueryOptions) (response *mDNS.Msg, err error) {
 	if len(message.Question) != 1 {
 		r.logger.WarnContext(ctx, "bad question size: ", len(message.Question))
 		responseMessage := mDNS.Msg{
@@ -221,11 +241,7 @@ func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg, options adapte
 		return &responseMessage, nil
 	}
 	r.logger.DebugContext(ctx, "exchange ", FormatQuestion(message.Question[0].String()))
-	var (
-		response  *mDNS.Msg
-		transport adapter.DNSTransport
-		err       error
-	)
+	var transport adapter.DNSTransport
 	var metadata *adapter.InboundContext
 	ctx, metadata = adapter.ExtendContext(ctx)
 	metadata.Destination = M.Socksaddr{}
@@ -237,8 +253,20 @@ func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg, options adapte
 		metadata.IPVersion = 6
 	}
 	metadata.Domain = FqdnToDomain(message.Question[0].Name)
+	var (
+		rewrites       []*R.RuleActionDNSRewrite
+		rewrittenCNAME bool
+	)
+	tracking := r.newQueryTracking(metadata, metadata.QueryType)
+	if tracking != nil {
+		ctx = contextWithQueryTracking(ctx, tracking)
+		defer func() {
+			r.emitExchange(ctx, tracking, response, err)
+		}()
+	}
 	if options.Transport != nil {
 		transport = options.Transport
+		tracking.match(transport, nil, -1)
 		if legacyTransport, isLegacy := transport.(adapter.LegacyDNSTransport); isLegacy {
 			if options.Strategy == C.DomainStrategyAsIS {
 				options.Strategy = legacyTransport.LegacyStrategy()
@@ -257,10 +285,13 @@ func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg, options adapte
 			ruleIndex int
 		)
 		ruleIndex = -1
+	match:
 		for {
 			dnsCtx := adapter.OverrideContext(ctx)
 			dnsOptions := options
-			transport, rule, ruleIndex = r.matchDNS(ctx, true, ruleIndex, isAddressQuery(message), &dnsOptions)
+			rewrites = rewrites[:0]
+			transport, rule, ruleIndex = r.matchDNS(ctx, true, ruleIndex, isAddressQuery(message), &dnsOptions, &rewrites)
+			tracking.match(transport, rule, ruleIndex)
 			if rule != nil {
 				switch action := rule.Action().(type) {
 				case *R.RuleActionReject:
@@ -279,13 +310,23 @@ func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg, options adapte
 					}
 				case *R.RuleActionPredefined:
 					return action.Response(message), nil
+				case *R.RuleActionDNSRewrite:
+					response, err = r.exchangeRewriteCNAME(ctx, message, action.CNAME, options)
+					rewrittenCNAME = true
+					break match
 				}
 			}
 			responseCheck := addressLimitResponseCheck(rule, metadata)
 			if dnsOptions.Strategy == C.DomainStrategyAsIS {
 				dnsOptions.Strategy = r.defaultDomainStrategy
 			}
+			if r.exchangeStripAAAA(dnsCtx, transport, message, dnsOptions, rewrites) {
+				return emptyResponse(message), nil
+			}
 			response, err = r.client.Exchange(dnsCtx, transport, message, dnsOptions, responseCheck)
+			if err == nil {
+				response = r.exchangeDNS64(dnsCtx, transport, message, response, dnsOptions)
+			}
 			var rejected bool
 			if err != nil {
 				if errors.Is(err, ErrResponseRejectedCached) {
@@ -309,14 +350,20 @@ func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg, options adapte
 	if err != nil {
 		return nil, err
 	}
+	response = rewriteResponse(response, rewrites)
 	if r.dnsReverseMapping != nil && len(message.Question) > 0 && response != nil && len(response.Answer) > 0 {
 		if transport == nil || transport.Type() != C.DNSTypeFakeIP {
 			for _, answer := range response.Answer {
+				name := answer.Header().Name
+				if rewrittenCNAME {
+					// map to the queried name rather than the CNAME target, so that its domain rules keep matching
+					name = message.Question[0].Name
+				}
 				switch record := answer.(type) {
 				case *mDNS.A:
-					r.dnsReverseMapping.AddWithLifetime(M.AddrFromIP(record.A), FqdnToDomain(record.Hdr.Name), time.Duration(record.Hdr.Ttl)*time.Second)
+					r.dnsReverseMapping.AddWithLifetime(M.AddrFromIP(record.A), FqdnToDomain(name), time.Duration(record.Hdr.Ttl)*time.Second)
 				case *mDNS.AAAA:
-					r.dnsReverseMapping.AddWithLifetime(M.AddrFromIP(record.AAAA), FqdnToDomain(record.Hdr.Name), time.Duration(record.Hdr.Ttl)*time.Second)
+					r.dnsReverseMapping.AddWithLifetime(M.AddrFromIP(record.AAAA), FqdnToDomain(name), time.Duration(record.Hdr.Ttl)*time.Second)
 				}
 			}
 		}
@@ -324,11 +371,7 @@ func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg, options adapte
 	return response, nil
 }
 
-func (r *Router) Lookup(ctx context.Context, domain string, options adapter.DNSQueryOptions) ([]netip.Addr, error) {
-	var (
-		responseAddrs []netip.Addr
-		err           error
-	)
+func (r *Router) Lookup(ctx context.Context, domain string, options adapter.DNSQueryOptions) (responseAddrs []netip.Addr, err error) {
 	printResult := func() {
 		if err == nil && len(responseAddrs) == 0 {
 			err = E.New("empty result")
@@ -350,8 +393,17 @@ func (r *Router) Lookup(ctx context.Context, domain string, options adapter.DNSQ
 	ctx, metadata := adapter.ExtendContext(ctx)
 	metadata.Destination = M.Socksaddr{}
 	metadata.Domain = FqdnToDomain(domain)
+	var rewrites []*R.RuleActionDNSRewrite
+	tracking := r.newQueryTracking(metadata, 0)
+	if tracking != nil {
+		ctx = contextWithQueryTracking(ctx, tracking)
+		defer func() {
+			r.emitLookup(ctx, tracking, responseAddrs, err)
+		}()
+	}
 	if options.Transport != nil {
 		transport := options.Transport
+		tracking.match(transport, nil, -1)
 		if legacyTransport, isLegacy := transport.(adapter.LegacyDNSTransport); isLegacy {
 			if options.Strategy == C.DomainStrategyAsIS {
 				options.Strategy = legacyTransport.LegacyStrategy()
@@ -374,7 +426,9 @@ func (r *Router) Lookup(ctx context.Context, domain string, options adapter.DNSQ
 		for {
 			dnsCtx := adapter.OverrideContext(ctx)
 			dnsOptions := options
-			transport, rule, ruleIndex = r.matchDNS(ctx, false, ruleIndex, true, &dnsOptions)
+			rewrites = rewrites[:0]
+			transport, rule, ruleIndex = r.matchDNS(ctx, false, ruleIndex, true, &dnsOptions, &rewrites)
+			tracking.match(transport, rule, ruleIndex)
 			if rule != nil {
 				switch action := rule.Action().(type) {
 				case *R.RuleActionReject:
@@ -395,6 +449,9 @@ func (r *Router) Lookup(ctx context.Context, domain string, options adapter.DNSQ
 						}
 					}
 					goto response
+				case *R.RuleActionDNSRewrite:
+					responseAddrs, err = r.lookupRewriteCNAME(ctx, domain, action.CNAME, options)
+					goto response
 				}
 			}
 			responseCheck := addressLimitResponseCheck(rule, metadata)
@@ -409,6 +466,7 @@ func (r *Router) Lookup(ctx context.Context, domain string, options adapter.DNSQ
 		}
 	}
 response:
+	responseAddrs = rewriteAddresses(responseAddrs, rewrites)
 	printResult()
 	if len(responseAddrs) > 0 {
 		r.logger.InfoContext(ctx, "lookup succeed for ", domain, ": ", strings.Join(F.MapToString(responseAddrs), " "))
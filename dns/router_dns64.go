@@ -0,0 +1,96 @@
+package dns
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/sagernet/sing-box/adapter"
+	R "github.com/sagernet/sing-box/route/rule"
+	"github.com/sagernet/sing/common"
+	M "github.com/sagernet/sing/common/metadata"
+
+	mDNS "github.com/miekg/dns"
+)
+
+func dns64Prefix(options *R.RuleActionDNSRouteOptions) netip.Prefix {
+	if !options.DNS64 {
+		return netip.Prefix{}
+	}
+	if options.DNS64Prefix.IsValid() {
+		return options.DNS64Prefix
+	}
+	return DefaultDNS64Prefix
+}
+
+func (r *Router) initializeDNS64() {
+	for _, rule := range r.rules {
+		var prefix netip.Prefix
+		switch action := rule.Action().(type) {
+		case *R.RuleActionDNSRoute:
+			prefix = dns64Prefix(&action.RuleActionDNSRouteOptions)
+		case *R.RuleActionDNSRouteOptions:
+			prefix = dns64Prefix(action)
+		}
+		if prefix.IsValid() && !common.Contains(r.dns64Prefixes, prefix) {
+			r.dns64Prefixes = append(r.dns64Prefixes, prefix)
+		}
+	}
+}
+
+func (r *Router) LookupDNS64(ip netip.Addr) (netip.Addr, bool) {
+	for _, prefix := range r.dns64Prefixes {
+		address, loaded := ExtractDNS64(prefix, ip)
+		if loaded {
+			return address, true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// exchangeDNS64 synthesizes AAAA records from A records when the AAAA response has no addresses.
+func (r *Router) exchangeDNS64(ctx context.Context, transport adapter.DNSTransport, message *mDNS.Msg, response *mDNS.Msg, options adapter.DNSQueryOptions) *mDNS.Msg {
+	if !options.DNS64Prefix.IsValid() || message.Question[0].Qtype != mDNS.TypeAAAA || response == nil {
+		return response
+	}
+	if response.Rcode != mDNS.RcodeSuccess || common.Any(response.Answer, func(it mDNS.RR) bool {
+		_, isAAAA := it.(*mDNS.AAAA)
+		return isAAAA
+	}) {
+		return response
+	}
+	prefix := options.DNS64Prefix
+	options.DNS64Prefix = netip.Prefix{}
+	aMessage := message.Copy()
+	aMessage.Question[0].Qtype = mDNS.TypeA
+	aResponse, err := r.client.Exchange(ctx, transport, aMessage, options, nil)
+	if err != nil {
+		r.logger.DebugContext(ctx, "DNS64: exchange A record for ", FormatQuestion(message.Question[0].String()), ": ", err)
+		return response
+	}
+	if aResponse.Rcode != mDNS.RcodeSuccess {
+		return response
+	}
+	var synthesized bool
+	answers := make([]mDNS.RR, 0, len(aResponse.Answer))
+	for _, answer := range aResponse.Answer {
+		switch record := answer.(type) {
+		case *mDNS.A:
+			header := record.Hdr
+			header.Rrtype = mDNS.TypeAAAA
+			answers = append(answers, &mDNS.AAAA{
+				Hdr:  header,
+				AAAA: SynthesizeDNS64(prefix, M.AddrFromIP(record.A)).AsSlice(),
+			})
+			synthesized = true
+		default:
+			answers = append(answers, answer)
+		}
+	}
+	if !synthesized {
+		return response
+	}
+	r.logger.DebugContext(ctx, "DNS64: synthesized AAAA records for ", FormatQuestion(message.Question[0].String()))
+	response = response.Copy()
+	response.Answer = answers
+	return response
+}
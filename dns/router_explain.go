@@ -0,0 +1,35 @@
+package dns
+
+import (
+	"context"
+
+	"github.com/sagernet/sing-box/adapter"
+	R "github.com/sagernet/sing-box/route/rule"
+	M "github.com/sagernet/sing/common/metadata"
+)
+
+// Explain matches the DNS rules the way an outbound resolving the destination domain would, without querying.
+func (r *Router) Explain(ctx context.Context, metadata adapter.InboundContext) *adapter.DNSExplanation {
+	explainer := &R.Explainer{}
+	ctx = R.ContextWithDNSExplainer(ctx, explainer)
+	metadata.Domain = FqdnToDomain(metadata.Destination.Fqdn)
+	metadata.Destination = M.Socksaddr{}
+	ctx = adapter.WithContext(ctx, &metadata)
+	var (
+		options  adapter.DNSQueryOptions
+		rewrites []*R.RuleActionDNSRewrite
+	)
+	transport, rule, ruleIndex := r.matchDNS(ctx, false, -1, true, &options, &rewrites)
+	explanation := &adapter.DNSExplanation{
+		Rules:     explainer.Rules,
+		RuleIndex: ruleIndex,
+	}
+	if rule != nil {
+		explanation.Rule = rule.String()
+		explanation.Action = rule.Action().String()
+	}
+	if transport != nil {
+		explanation.Server = transport.Tag()
+	}
+	return explanation
+}
@@ -0,0 +1,149 @@
+package dns
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/sagernet/sing-box/adapter"
+	R "github.com/sagernet/sing-box/route/rule"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+
+	mDNS "github.com/miekg/dns"
+)
+
+const (
+	rewriteCNAMEMaxDepth   = 8
+	rewriteCNAMEDefaultTTL = 60
+)
+
+type rewriteDepthKey struct{}
+
+func contextWithRewriteDepth(ctx context.Context) (context.Context, error) {
+	depth, _ := ctx.Value(rewriteDepthKey{}).(int)
+	if depth >= rewriteCNAMEMaxDepth {
+		return nil, E.New("too many CNAME rewrites")
+	}
+	return context.WithValue(ctx, rewriteDepthKey{}, depth+1), nil
+}
+
+func (r *Router) exchangeRewriteCNAME(ctx context.Context, message *mDNS.Msg, target string, options adapter.DNSQueryOptions) (*mDNS.Msg, error) {
+	ctx, err := contextWithRewriteDepth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	question := message.Question[0]
+	target = mDNS.Fqdn(target)
+	r.logger.DebugContext(ctx, "rewrite ", FqdnToDomain(question.Name), " => ", FqdnToDomain(target))
+	targetMessage := message.Copy()
+	targetMessage.Question[0].Name = target
+	response, err := r.Exchange(ctx, targetMessage, options)
+	if err != nil {
+		return nil, err
+	}
+	response = response.Copy()
+	response.Id = message.Id
+	response.Question = []mDNS.Question{question}
+	ttl := uint32(rewriteCNAMEDefaultTTL)
+	for i, answer := range response.Answer {
+		if i == 0 || answer.Header().Ttl < ttl {
+			ttl = answer.Header().Ttl
+		}
+	}
+	response.Answer = append([]mDNS.RR{&mDNS.CNAME{
+		Hdr: mDNS.RR_Header{
+			Name:   question.Name,
+			Rrtype: mDNS.TypeCNAME,
+			Class:  mDNS.ClassINET,
+			Ttl:    ttl,
+		},
+		Target: target,
+	}}, response.Answer...)
+	return response, nil
+}
+
+func (r *Router) lookupRewriteCNAME(ctx context.Context, domain string, target string, options adapter.DNSQueryOptions) ([]netip.Addr, error) {
+	ctx, err := contextWithRewriteDepth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.logger.DebugContext(ctx, "rewrite ", domain, " => ", FqdnToDomain(target))
+	return r.Lookup(ctx, FqdnToDomain(target), options)
+}
+
+// exchangeStripAAAA reports whether an AAAA query should be answered empty
+// because the name has IPv4 addresses.
+func (r *Router) exchangeStripAAAA(ctx context.Context, transport adapter.DNSTransport, message *mDNS.Msg, options adapter.DNSQueryOptions, rewrites []*R.RuleActionDNSRewrite) bool {
+	if message.Question[0].Qtype != mDNS.TypeAAAA || !common.Any(rewrites, func(it *R.RuleActionDNSRewrite) bool {
+		return it.StripAAAA
+	}) {
+		return false
+	}
+	aMessage := message.Copy()
+	aMessage.Question[0].Qtype = mDNS.TypeA
+	response, err := r.client.Exchange(ctx, transport, aMessage, options, nil)
+	if err != nil || response.Rcode != mDNS.RcodeSuccess {
+		return false
+	}
+	return common.Any(response.Answer, func(it mDNS.RR) bool {
+		_, isA := it.(*mDNS.A)
+		return isA
+	})
+}
+
+func emptyResponse(request *mDNS.Msg) *mDNS.Msg {
+	return &mDNS.Msg{
+		MsgHdr: mDNS.MsgHdr{
+			Id:                 request.Id,
+			Response:           true,
+			RecursionDesired:   true,
+			RecursionAvailable: true,
+			Rcode:              mDNS.RcodeSuccess,
+		},
+		Question: []mDNS.Question{request.Question[0]},
+	}
+}
+
+func rewriteResponse(response *mDNS.Msg, rewrites []*R.RuleActionDNSRewrite) *mDNS.Msg {
+	if response == nil || len(rewrites) == 0 {
+		return response
+	}
+	answers := common.Filter(response.Answer, func(it mDNS.RR) bool {
+		switch record := it.(type) {
+		case *mDNS.A:
+			return !filterRewriteAddress(rewrites, M.AddrFromIP(record.A))
+		case *mDNS.AAAA:
+			return !filterRewriteAddress(rewrites, M.AddrFromIP(record.AAAA))
+		default:
+			return true
+		}
+	})
+	if len(answers) == len(response.Answer) {
+		return response
+	}
+	response = response.Copy()
+	response.Answer = answers
+	return response
+}
+
+func rewriteAddresses(addresses []netip.Addr, rewrites []*R.RuleActionDNSRewrite) []netip.Addr {
+	if len(rewrites) == 0 {
+		return addresses
+	}
+	addresses = common.Filter(addresses, func(it netip.Addr) bool {
+		return !filterRewriteAddress(rewrites, it)
+	})
+	if common.Any(rewrites, func(it *R.RuleActionDNSRewrite) bool {
+		return it.StripAAAA
+	}) && common.Any(addresses, netip.Addr.Is4) {
+		addresses = common.Filter(addresses, netip.Addr.Is4)
+	}
+	return addresses
+}
+
+func filterRewriteAddress(rewrites []*R.RuleActionDNSRewrite, addr netip.Addr) bool {
+	return common.Any(rewrites, func(it *R.RuleActionDNSRewrite) bool {
+		return it.FilterAddress(addr)
+	})
+}
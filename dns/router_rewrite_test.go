@@ -0,0 +1,183 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	R "github.com/sagernet/sing-box/route/rule"
+	"github.com/sagernet/sing/common/json/badoption"
+	"github.com/sagernet/sing/service"
+
+	mDNS "github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRewrite(t *testing.T, options option.DNSRouteActionRewrite) *R.RuleActionDNSRewrite {
+	t.Helper()
+	rewrite, err := R.NewRuleActionDNSRewrite(context.Background(), options)
+	require.NoError(t, err)
+	return rewrite
+}
+
+func newTestResponse(answers ...mDNS.RR) *mDNS.Msg {
+	response := new(mDNS.Msg)
+	response.SetQuestion("www.example.com.", mDNS.TypeA)
+	response.Response = true
+	response.Answer = answers
+	return response
+}
+
+func testA(address string) *mDNS.A {
+	return &mDNS.A{
+		Hdr: mDNS.RR_Header{Name: "www.example.com.", Rrtype: mDNS.TypeA, Class: mDNS.ClassINET, Ttl: 60},
+		A:   net.ParseIP(address).To4(),
+	}
+}
+
+func testAAAA(address string) *mDNS.AAAA {
+	return &mDNS.AAAA{
+		Hdr:  mDNS.RR_Header{Name: "www.example.com.", Rrtype: mDNS.TypeAAAA, Class: mDNS.ClassINET, Ttl: 60},
+		AAAA: net.ParseIP(address),
+	}
+}
+
+func TestRewriteResponse(t *testing.T) {
+	t.Parallel()
+	rewrites := []*R.RuleActionDNSRewrite{
+		newTestRewrite(t, option.DNSRouteActionRewrite{FilterIPCIDR: []string{"10.0.0.0/8", "fd00::/8"}}),
+	}
+	cname := &mDNS.CNAME{
+		Hdr:    mDNS.RR_Header{Name: "www.example.com.", Rrtype: mDNS.TypeCNAME, Class: mDNS.ClassINET, Ttl: 60},
+		Target: "cdn.example.com.",
+	}
+	response := newTestResponse(cname, testA("10.0.0.1"), testA("1.1.1.1"), testAAAA("fd00::1"), testAAAA("2606:4700::1111"))
+	rewritten := rewriteResponse(response, rewrites)
+	require.NotSame(t, response, rewritten)
+	require.Len(t, response.Answer, 5, "the original response must not be modified")
+	require.Len(t, rewritten.Answer, 3)
+	require.Equal(t, cname.String(), rewritten.Answer[0].String())
+	require.Equal(t, "1.1.1.1", rewritten.Answer[1].(*mDNS.A).A.String())
+	require.Equal(t, "2606:4700::1111", rewritten.Answer[2].(*mDNS.AAAA).AAAA.String())
+	require.Equal(t, response.Id, rewritten.Id)
+	require.Equal(t, response.Question, rewritten.Question)
+}
+
+func TestRewriteResponseNoMatch(t *testing.T) {
+	t.Parallel()
+	response := newTestResponse(testA("1.1.1.1"), testAAAA("2606:4700::1111"))
+	filterRewrite := newTestRewrite(t, option.DNSRouteActionRewrite{FilterIPCIDR: []string{"10.0.0.0/8"}})
+	for _, rewrites := range [][]*R.RuleActionDNSRewrite{
+		nil,
+		{newTestRewrite(t, option.DNSRouteActionRewrite{CNAME: "cdn.example.com"})},
+		{filterRewrite},
+	} {
+		require.Same(t, response, rewriteResponse(response, rewrites))
+	}
+	require.Nil(t, rewriteResponse(nil, []*R.RuleActionDNSRewrite{filterRewrite}))
+	empty := newTestResponse()
+	require.Same(t, empty, rewriteResponse(empty, []*R.RuleActionDNSRewrite{filterRewrite}))
+}
+
+func TestRewriteAddresses(t *testing.T) {
+	t.Parallel()
+	addresses := []netip.Addr{
+		netip.MustParseAddr("10.0.0.1"),
+		netip.MustParseAddr("1.1.1.1"),
+		netip.MustParseAddr("2606:4700::1111"),
+	}
+	filterRewrite := newTestRewrite(t, option.DNSRouteActionRewrite{FilterIPCIDR: []string{"10.0.0.0/8"}})
+	stripRewrite := newTestRewrite(t, option.DNSRouteActionRewrite{StripAAAA: true})
+	require.Equal(t, addresses, rewriteAddresses(addresses, nil))
+	require.Equal(t, addresses[1:], rewriteAddresses(append([]netip.Addr(nil), addresses...), []*R.RuleActionDNSRewrite{filterRewrite}))
+	require.Equal(t, addresses[:2], rewriteAddresses(append([]netip.Addr(nil), addresses...), []*R.RuleActionDNSRewrite{stripRewrite}))
+	require.Equal(t, addresses[1:2], rewriteAddresses(append([]netip.Addr(nil), addresses...), []*R.RuleActionDNSRewrite{filterRewrite, stripRewrite}))
+	onlyIPv6 := []netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("2606:4700::1111")}
+	require.Equal(t, onlyIPv6[1:], rewriteAddresses(onlyIPv6, []*R.RuleActionDNSRewrite{filterRewrite, stripRewrite}), "AAAA records are kept without IPv4 addresses")
+}
+
+func TestRewriteCNAMEDepth(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	var err error
+	for range rewriteCNAMEMaxDepth {
+		ctx, err = contextWithRewriteDepth(ctx)
+		require.NoError(t, err)
+	}
+	_, err = contextWithRewriteDepth(ctx)
+	require.ErrorContains(t, err, "too many CNAME rewrites")
+}
+
+type testTransport struct {
+	adapter.DNSTransport
+	address string
+}
+
+func (t *testTransport) Type() string {
+	return C.DNSTypeUDP
+}
+
+func (t *testTransport) Tag() string {
+	return "test"
+}
+
+func (t *testTransport) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	response := new(mDNS.Msg)
+	response.SetReply(message)
+	answer := testA(t.address)
+	answer.Hdr.Name = message.Question[0].Name
+	response.Answer = []mDNS.RR{answer}
+	return response, nil
+}
+
+type testTransportManager struct {
+	adapter.DNSTransportManager
+	transport adapter.DNSTransport
+}
+
+func (m *testTransportManager) Transport(tag string) (adapter.DNSTransport, bool) {
+	return m.transport, tag == m.transport.Tag()
+}
+
+func (m *testTransportManager) Default() adapter.DNSTransport {
+	return m.transport
+}
+
+func (m *testTransportManager) FakeIP() adapter.FakeIPTransport {
+	return nil
+}
+
+func TestRewriteCNAMEReverseMapping(t *testing.T) {
+	t.Parallel()
+	ctx := service.ContextWith[adapter.DNSTransportManager](context.Background(), &testTransportManager{
+		transport: &testTransport{address: "10.0.0.1"},
+	})
+	router := NewRouter(ctx, log.NewNOPFactory(), option.DNSOptions{RawDNSOptions: option.RawDNSOptions{ReverseMapping: true}})
+	require.NoError(t, router.Initialize([]option.DNSRule{{
+		Type: C.RuleTypeDefault,
+		DefaultOptions: option.DefaultDNSRule{
+			RawDefaultDNSRule: option.RawDefaultDNSRule{
+				Domain: badoption.Listable[string]{"www.example.com"},
+			},
+			DNSRuleAction: option.DNSRuleAction{
+				Action:         C.RuleActionTypeRewrite,
+				RewriteOptions: option.DNSRouteActionRewrite{CNAME: "cdn.example.net"},
+			},
+		},
+	}}))
+	require.NoError(t, router.Start(adapter.StartStateStart))
+	message := new(mDNS.Msg)
+	message.SetQuestion("www.example.com.", mDNS.TypeA)
+	response, err := router.Exchange(ctx, message, adapter.DNSQueryOptions{})
+	require.NoError(t, err)
+	require.Len(t, response.Answer, 2)
+	require.Equal(t, "cdn.example.net.", response.Answer[0].(*mDNS.CNAME).Target)
+	domain, loaded := router.LookupReverseMapping(netip.MustParseAddr("10.0.0.1"))
+	require.True(t, loaded)
+	require.Equal(t, "www.example.com", domain, "addresses of a rewritten CNAME must map to the queried name")
+}
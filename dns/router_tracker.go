@@ -0,0 +1,117 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	R "github.com/sagernet/sing-box/route/rule"
+
+	mDNS "github.com/miekg/dns"
+)
+
+type queryTracking struct {
+	record adapter.DNSQueryRecord
+	cached atomic.Bool
+}
+
+type queryTrackingKey struct{}
+
+func contextWithQueryTracking(ctx context.Context, tracking *queryTracking) context.Context {
+	return context.WithValue(ctx, queryTrackingKey{}, tracking)
+}
+
+func markCachedResponse(ctx context.Context) {
+	tracking, loaded := ctx.Value(queryTrackingKey{}).(*queryTracking)
+	if loaded {
+		tracking.cached.Store(true)
+	}
+}
+
+func (r *Router) AppendQueryTracker(tracker adapter.DNSQueryTracker) {
+	r.trackers = append(r.trackers, tracker)
+}
+
+func (r *Router) newQueryTracking(metadata *adapter.InboundContext, queryType uint16) *queryTracking {
+	if len(r.trackers) == 0 {
+		return nil
+	}
+	return &queryTracking{
+		record: adapter.DNSQueryRecord{
+			Time:        time.Now(),
+			Inbound:     metadata.Inbound,
+			InboundType: metadata.InboundType,
+			Source:      metadata.Source,
+			Domain:      metadata.Domain,
+			QueryType:   queryType,
+			RuleIndex:   -1,
+		},
+	}
+}
+
+func (t *queryTracking) match(transport adapter.DNSTransport, rule adapter.DNSRule, ruleIndex int) {
+	if t == nil {
+		return
+	}
+	t.record.RuleIndex = ruleIndex
+	if rule != nil {
+		t.record.Rule = rule.String()
+		t.record.Action = rule.Action().Type()
+		switch action := rule.Action().(type) {
+		case *R.RuleActionReject:
+			t.record.Blocked = true
+		case *R.RuleActionPredefined:
+			t.record.Blocked = action.Rcode != mDNS.RcodeSuccess
+		}
+	} else {
+		t.record.Rule = ""
+		t.record.Action = ""
+	}
+	if transport != nil {
+		t.record.Transport = transport.Tag()
+	} else {
+		t.record.Transport = ""
+	}
+}
+
+func (r *Router) emitExchange(ctx context.Context, tracking *queryTracking, response *mDNS.Msg, err error) {
+	if tracking == nil {
+		return
+	}
+	if response != nil {
+		tracking.record.Rcode = response.Rcode
+		tracking.record.Answers = MessageToAddresses(response)
+	}
+	r.emitQuery(ctx, tracking, err)
+}
+
+func (r *Router) emitLookup(ctx context.Context, tracking *queryTracking, addresses []netip.Addr, err error) {
+	if tracking == nil {
+		return
+	}
+	var rcodeError RcodeError
+	if errors.As(err, &rcodeError) {
+		tracking.record.Rcode = int(rcodeError)
+	} else if err == nil {
+		tracking.record.Rcode = mDNS.RcodeSuccess
+	} else {
+		tracking.record.Rcode = mDNS.RcodeServerFailure
+	}
+	tracking.record.Answers = addresses
+	r.emitQuery(ctx, tracking, err)
+}
+
+func (r *Router) emitQuery(ctx context.Context, tracking *queryTracking, err error) {
+	record := tracking.record
+	record.Latency = time.Since(record.Time)
+	record.Cached = tracking.cached.Load()
+	if err != nil {
+		record.Error = err.Error()
+	}
+	for _, tracker := range r.trackers {
+		tracker.RoutedDNSQuery(ctx, record)
+	}
+}
@@ -48,6 +48,7 @@ type HTTPSTransport struct {
 	transportAccess  sync.Mutex
 	transport        *HTTPSTransportWrapper
 	transportResetAt time.Time
+	http3            *httpsHTTP3
 }
 
 func NewHTTPS(ctx context.Context, logger log.ContextLogger, tag string, options option.RemoteHTTPSDNSServerOptions) (adapter.DNSTransport, error) {
@@ -100,7 +101,11 @@ func NewHTTPS(ctx context.Context, logger log.ContextLogger, tag string, options
 	if !serverAddr.IsValid() {
 		return nil, E.New("invalid server address: ", serverAddr)
 	}
-	return NewHTTPSRaw(
+	http3, err := newHTTPSHTTP3(ctx, options.HTTP3, transportDialer, serverAddr, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	transport := NewHTTPSRaw(
 		dns.NewTransportAdapterWithRemoteOptions(C.DNSTypeHTTPS, tag, options.RemoteDNSServerOptions),
 		logger,
 		transportDialer,
@@ -108,7 +113,9 @@ func NewHTTPS(ctx context.Context, logger log.ContextLogger, tag string, options
 		headers,
 		serverAddr,
 		tlsConfig,
-	), nil
+	)
+	transport.http3 = http3
+	return transport, nil
 }
 
 func NewHTTPSRaw(
@@ -134,6 +141,9 @@ func (t *HTTPSTransport) Start(stage adapter.StartStage) error {
 	if stage != adapter.StartStateStart {
 		return nil
 	}
+	if t.http3 != nil {
+		t.startHTTP3()
+	}
 	return dialer.InitializeDetour(t.dialer)
 }
 
@@ -142,6 +152,9 @@ func (t *HTTPSTransport) Close() error {
 	defer t.transportAccess.Unlock()
 	t.transport.CloseIdleConnections()
 	t.transport = t.transport.Clone()
+	if t.http3 != nil {
+		t.closeHTTP3()
+	}
 	return nil
 }
 
@@ -150,6 +163,9 @@ func (t *HTTPSTransport) Reset() {
 	defer t.transportAccess.Unlock()
 	t.transport.CloseIdleConnections()
 	t.transport = t.transport.Clone()
+	if t.http3 != nil {
+		t.closeHTTP3()
+	}
 }
 
 func (t *HTTPSTransport) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
@@ -172,6 +188,21 @@ func (t *HTTPSTransport) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS
 }
 
 func (t *HTTPSTransport) exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	if t.http3 != nil {
+		return t.exchangeNegotiated(ctx, message)
+	}
+	response, _, err := t.exchangeHTTP2(ctx, message)
+	return response, err
+}
+
+func (t *HTTPSTransport) exchangeHTTP2(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, http.Header, error) {
+	t.transportAccess.Lock()
+	currentTransport := t.transport
+	t.transportAccess.Unlock()
+	return t.exchangeWith(ctx, currentTransport, message)
+}
+
+func (t *HTTPSTransport) exchangeWith(ctx context.Context, roundTripper http.RoundTripper, message *mDNS.Msg) (*mDNS.Msg, http.Header, error) {
 	exMessage := *message
 	exMessage.Id = 0
 	exMessage.Compress = true
@@ -179,27 +210,24 @@ func (t *HTTPSTransport) exchange(ctx context.Context, message *mDNS.Msg) (*mDNS
 	rawMessage, err := exMessage.PackBuffer(requestBuffer.FreeBytes())
 	if err != nil {
 		requestBuffer.Release()
-		return nil, err
+		return nil, nil, err
 	}
 	request, err := http.NewRequestWithContext(ctx, http.MethodPost, t.destination.String(), bytes.NewReader(rawMessage))
 	if err != nil {
 		requestBuffer.Release()
-		return nil, err
+		return nil, nil, err
 	}
 	request.Header = t.headers.Clone()
 	request.Header.Set("Content-Type", MimeType)
 	request.Header.Set("Accept", MimeType)
-	t.transportAccess.Lock()
-	currentTransport := t.transport
-	t.transportAccess.Unlock()
-	response, err := currentTransport.RoundTrip(request)
+	response, err := roundTripper.RoundTrip(request)
 	requestBuffer.Release()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer response.Body.Close()
 	if response.StatusCode != http.StatusOK {
-		return nil, E.New("unexpected status: ", response.Status)
+		return nil, nil, E.New("unexpected status: ", response.Status)
 	}
 	var responseMessage mDNS.Msg
 	if response.ContentLength > 0 {
@@ -207,18 +235,18 @@ func (t *HTTPSTransport) exchange(ctx context.Context, message *mDNS.Msg) (*mDNS
 		defer responseBuffer.Release()
 		_, err = responseBuffer.ReadFullFrom(response.Body, int(response.ContentLength))
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		err = responseMessage.Unpack(responseBuffer.Bytes())
 	} else {
 		rawMessage, err = io.ReadAll(response.Body)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		err = responseMessage.Unpack(rawMessage)
 	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return &responseMessage, nil
+	return &responseMessage, response.Header, nil
 }
@@ -0,0 +1,242 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+
+	mDNS "github.com/miekg/dns"
+)
+
+const (
+	dnsProtocolHTTP2 = "h2"
+	dnsProtocolHTTP3 = "h3"
+
+	http3RetryInterval = 30 * time.Minute
+)
+
+type HTTP3RoundTripper interface {
+	http.RoundTripper
+	Close() error
+}
+
+type HTTP3RoundTripperConstructor func(dialer N.Dialer, serverAddr M.Socksaddr, tlsConfig tls.Config) (HTTP3RoundTripper, error)
+
+var http3RoundTripperConstructor HTTP3RoundTripperConstructor
+
+func RegisterHTTP3RoundTripper(constructor HTTP3RoundTripperConstructor) {
+	http3RoundTripperConstructor = constructor
+}
+
+type httpsHTTP3 struct {
+	ctx          context.Context
+	mode         string
+	newTransport func() (HTTP3RoundTripper, error)
+	cacheFile    adapter.CacheFile
+	access       sync.Mutex
+	transport    HTTP3RoundTripper
+	failedAt     time.Time
+	enabled      atomic.Bool
+	decided      atomic.Bool
+	discoverOnce sync.Once
+}
+
+func newHTTPSHTTP3(ctx context.Context, mode string, dialer N.Dialer, serverAddr M.Socksaddr, tlsConfig tls.Config) (*httpsHTTP3, error) {
+	switch mode {
+	case "":
+		return nil, nil
+	case C.DNSHTTP3Discover, C.DNSHTTP3Race:
+	default:
+		return nil, E.New("unknown http3 mode: ", mode)
+	}
+	if http3RoundTripperConstructor == nil {
+		return nil, C.ErrQUICNotIncluded
+	}
+	return &httpsHTTP3{
+		ctx:  ctx,
+		mode: mode,
+		newTransport: func() (HTTP3RoundTripper, error) {
+			return http3RoundTripperConstructor(dialer, serverAddr, tlsConfig)
+		},
+	}, nil
+}
+
+func (t *HTTPSTransport) startHTTP3() {
+	t.http3.cacheFile = service.FromContext[adapter.CacheFile](t.http3.ctx)
+	if t.http3.cacheFile == nil {
+		return
+	}
+	switch t.http3.cacheFile.LoadDNSProtocol(t.Tag()) {
+	case dnsProtocolHTTP3:
+		t.http3.enabled.Store(true)
+		t.http3.decided.Store(true)
+	case dnsProtocolHTTP2:
+		t.http3.decided.Store(true)
+	}
+}
+
+func (t *HTTPSTransport) closeHTTP3() {
+	t.http3.access.Lock()
+	defer t.http3.access.Unlock()
+	if t.http3.transport != nil {
+		t.http3.transport.Close()
+		t.http3.transport = nil
+	}
+}
+
+func (t *HTTPSTransport) exchangeNegotiated(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	if t.http3.enabled.Load() {
+		response, err := t.exchangeHTTP3(ctx, message)
+		if err == nil || ctx.Err() != nil {
+			return response, err
+		}
+		t.logger.DebugContext(ctx, "HTTP/3 exchange failed, fallback to HTTP/2: ", err)
+		t.http3.access.Lock()
+		t.http3.failedAt = time.Now()
+		t.http3.access.Unlock()
+		t.selectProtocol(false)
+	} else if t.http3.mode == C.DNSHTTP3Race && !t.http3.decided.Load() {
+		return t.exchangeRace(ctx, message)
+	}
+	response, header, err := t.exchangeHTTP2(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	if altSvcSupportsHTTP3(header) {
+		t.upgradeHTTP3(ctx, "Alt-Svc")
+	} else {
+		t.http3.discoverOnce.Do(func() {
+			go t.discoverHTTPSRecord()
+		})
+	}
+	return response, nil
+}
+
+func (t *HTTPSTransport) exchangeHTTP3(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	t.http3.access.Lock()
+	if t.http3.transport == nil {
+		roundTripper, err := t.http3.newTransport()
+		if err != nil {
+			t.http3.access.Unlock()
+			return nil, err
+		}
+		t.http3.transport = roundTripper
+	}
+	roundTripper := t.http3.transport
+	t.http3.access.Unlock()
+	response, _, err := t.exchangeWith(ctx, roundTripper, message)
+	return response, err
+}
+
+func (t *HTTPSTransport) exchangeRace(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	type raceResult struct {
+		response *mDNS.Msg
+		err      error
+		isHTTP3  bool
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan raceResult, 2)
+	go func() {
+		response, err := t.exchangeHTTP3(ctx, message)
+		results <- raceResult{response, err, true}
+	}()
+	go func() {
+		response, _, err := t.exchangeHTTP2(ctx, message)
+		results <- raceResult{response, err, false}
+	}()
+	var errors []error
+	for range 2 {
+		result := <-results
+		if result.err == nil {
+			t.selectProtocol(result.isHTTP3)
+			return result.response, nil
+		}
+		errors = append(errors, result.err)
+	}
+	return nil, E.Errors(errors...)
+}
+
+func (t *HTTPSTransport) upgradeHTTP3(ctx context.Context, source string) {
+	if t.http3.enabled.Load() {
+		return
+	}
+	t.http3.access.Lock()
+	failedAt := t.http3.failedAt
+	t.http3.access.Unlock()
+	if !failedAt.IsZero() && time.Since(failedAt) < http3RetryInterval {
+		return
+	}
+	t.logger.DebugContext(ctx, "upgrade to HTTP/3 advertised by ", source)
+	t.selectProtocol(true)
+}
+
+func (t *HTTPSTransport) selectProtocol(isHTTP3 bool) {
+	t.http3.enabled.Store(isHTTP3)
+	t.http3.decided.Store(true)
+	if t.http3.cacheFile == nil {
+		return
+	}
+	protocol := dnsProtocolHTTP2
+	if isHTTP3 {
+		protocol = dnsProtocolHTTP3
+	}
+	err := t.http3.cacheFile.StoreDNSProtocol(t.Tag(), protocol)
+	if err != nil {
+		t.logger.Warn(E.Cause(err, "save DNS protocol"))
+	}
+}
+
+// discoverHTTPSRecord looks up the HTTPS record of the server name through the server itself.
+func (t *HTTPSTransport) discoverHTTPSRecord() {
+	serverName := t.destination.Hostname()
+	if M.ParseAddr(serverName).IsValid() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(t.http3.ctx, C.DNSTimeout)
+	defer cancel()
+	message := new(mDNS.Msg)
+	message.SetQuestion(mDNS.Fqdn(serverName), mDNS.TypeHTTPS)
+	response, _, err := t.exchangeHTTP2(ctx, message)
+	if err != nil {
+		t.logger.DebugContext(ctx, "discover HTTPS record: ", err)
+		return
+	}
+	for _, answer := range response.Answer {
+		record, isHTTPS := answer.(*mDNS.HTTPS)
+		if !isHTTPS {
+			continue
+		}
+		for _, value := range record.Value {
+			alpn, isALPN := value.(*mDNS.SVCBAlpn)
+			if isALPN && common.Contains(alpn.Alpn, dnsProtocolHTTP3) {
+				t.upgradeHTTP3(ctx, "HTTPS record")
+				return
+			}
+		}
+	}
+}
+
+func altSvcSupportsHTTP3(header http.Header) bool {
+	for _, value := range header.Values("Alt-Svc") {
+		for service := range strings.SplitSeq(value, ",") {
+			protocol, _, _ := strings.Cut(strings.TrimSpace(service), "=")
+			if protocol == dnsProtocolHTTP3 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -34,6 +34,16 @@ import (
 
 var _ adapter.DNSTransport = (*HTTP3Transport)(nil)
 
+func init() {
+	transport.RegisterHTTP3RoundTripper(func(dialer N.Dialer, serverAddr M.Socksaddr, tlsConfig tls.Config) (transport.HTTP3RoundTripper, error) {
+		stdConfig, err := tlsConfig.STDConfig()
+		if err != nil {
+			return nil, err
+		}
+		return newHTTP3Transport(dialer, serverAddr, stdConfig), nil
+	})
+}
+
 func RegisterHTTP3Transport(registry *dns.TransportRegistry) {
 	dns.RegisterTransport[option.RemoteHTTPSDNSServerOptions](registry, C.DNSTypeHTTP3, NewHTTP3)
 }
@@ -115,9 +125,13 @@ func NewHTTP3(ctx context.Context, logger log.ContextLogger, tag string, options
 }
 
 func (t *HTTP3Transport) newTransport() *http3.Transport {
+	return newHTTP3Transport(t.dialer, t.serverAddr, t.tlsConfig)
+}
+
+func newHTTP3Transport(dialer N.Dialer, serverAddr M.Socksaddr, tlsConfig *tls.STDConfig) *http3.Transport {
 	return &http3.Transport{
 		Dial: func(ctx context.Context, addr string, tlsCfg *tls.STDConfig, cfg *quic.Config) (*quic.Conn, error) {
-			conn, dialErr := t.dialer.DialContext(ctx, N.NetworkUDP, t.serverAddr)
+			conn, dialErr := dialer.DialContext(ctx, N.NetworkUDP, serverAddr)
 			if dialErr != nil {
 				return nil, dialErr
 			}
@@ -128,7 +142,7 @@ func (t *HTTP3Transport) newTransport() *http3.Transport {
 			}
 			return quicConn, nil
 		},
-		TLSClientConfig: t.tlsConfig,
+		TLSClientConfig: tlsConfig,
 	}
 }
 
@@ -19,10 +19,11 @@ import (
 )
 
 var (
-	bucketSelected = []byte("selected")
-	bucketExpand   = []byte("group_expand")
-	bucketMode     = []byte("clash_mode")
-	bucketRuleSet  = []byte("rule_set")
+	bucketSelected    = []byte("selected")
+	bucketExpand      = []byte("group_expand")
+	bucketMode        = []byte("clash_mode")
+	bucketRuleSet     = []byte("rule_set")
+	bucketDNSProtocol = []byte("dns_protocol")
 
 	bucketNameList = []string{
 		string(bucketSelected),
@@ -30,6 +31,7 @@ var (
 		string(bucketMode),
 		string(bucketRuleSet),
 		string(bucketRDRC),
+		string(bucketDNSProtocol),
 	}
 
 	cacheIDDefault = []byte("default")
@@ -359,3 +361,29 @@ func (c *CacheFile) SaveRuleSet(tag string, set *adapter.SavedBinary) error {
 		return bucket.Put([]byte(tag), setBinary)
 	})
 }
+
+func (c *CacheFile) LoadDNSProtocol(server string) string {
+	var protocol string
+	c.view(func(t *bbolt.Tx) error {
+		bucket := c.bucket(t, bucketDNSProtocol)
+		if bucket == nil {
+			return nil
+		}
+		protocolBytes := bucket.Get([]byte(server))
+		if len(protocolBytes) > 0 {
+			protocol = string(protocolBytes)
+		}
+		return nil
+	})
+	return protocol
+}
+
+func (c *CacheFile) StoreDNSProtocol(server string, protocol string) error {
+	return c.batch(func(t *bbolt.Tx) error {
+		bucket, err := c.createBucket(t, bucketDNSProtocol)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(server), []byte(protocol))
+	})
+}
@@ -1,21 +1,31 @@
 package clashapi
 
 import (
+	"bytes"
 	"context"
 	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
 
 	"github.com/sagernet/sing-box/adapter"
 	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/experimental/clashapi/dnslog"
 	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/ws"
+	"github.com/sagernet/ws/wsutil"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"github.com/miekg/dns"
 )
 
-func dnsRouter(router adapter.DNSRouter) http.Handler {
+func dnsRouter(ctx context.Context, router adapter.DNSRouter, queryManager *dnslog.Manager) http.Handler {
 	r := chi.NewRouter()
 	r.Get("/query", queryDNS(router))
+	r.Get("/queries", getDNSQueries(ctx, queryManager))
+	r.Get("/stats", getDNSStatistics(queryManager))
 	return r
 }
 
@@ -80,3 +90,132 @@ func queryDNS(router adapter.DNSRouter) func(w http.ResponseWriter, r *http.Requ
 		render.JSON(w, r, responseData)
 	}
 }
+
+type DNSQuery struct {
+	Time        time.Time `json:"time"`
+	Inbound     string    `json:"inbound"`
+	InboundType string    `json:"inboundType"`
+	Source      string    `json:"source"`
+	Domain      string    `json:"domain"`
+	QueryType   string    `json:"queryType"`
+	RuleIndex   int       `json:"ruleIndex"`
+	Rule        string    `json:"rule"`
+	Action      string    `json:"action"`
+	Transport   string    `json:"transport"`
+	Rcode       string    `json:"rcode"`
+	Answers     []string  `json:"answers"`
+	Latency     int64     `json:"latency"`
+	Cached      bool      `json:"cached"`
+	Blocked     bool      `json:"blocked"`
+	Error       string    `json:"error,omitempty"`
+}
+
+func newDNSQuery(record adapter.DNSQueryRecord) DNSQuery {
+	query := DNSQuery{
+		Time:        record.Time,
+		Inbound:     record.Inbound,
+		InboundType: record.InboundType,
+		Domain:      record.Domain,
+		RuleIndex:   record.RuleIndex,
+		Rule:        record.Rule,
+		Action:      record.Action,
+		Transport:   record.Transport,
+		Rcode:       dns.RcodeToString[record.Rcode],
+		Answers:     common.Map(record.Answers, netip.Addr.String),
+		Latency:     record.Latency.Milliseconds(),
+		Cached:      record.Cached,
+		Blocked:     record.Blocked,
+		Error:       record.Error,
+	}
+	if record.Source.IsValid() {
+		query.Source = record.Source.String()
+	}
+	if record.QueryType != 0 {
+		query.QueryType = dns.Type(record.QueryType).String()
+	}
+	return query
+}
+
+func getDNSQueries(ctx context.Context, queryManager *dnslog.Manager) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			render.JSON(w, r, render.M{
+				"queries": common.Map(queryManager.Records(), newDNSQuery),
+			})
+			return
+		}
+
+		subscription, done, err := queryManager.Subscribe()
+		if err != nil {
+			render.Status(r, http.StatusNoContent)
+			return
+		}
+		defer queryManager.UnSubscribe(subscription)
+
+		conn, _, _, err := ws.UpgradeHTTP(r, w)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		buf := &bytes.Buffer{}
+		var record adapter.DNSQueryRecord
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case record = <-subscription:
+			}
+			buf.Reset()
+			err = json.NewEncoder(buf).Encode(newDNSQuery(record))
+			if err != nil {
+				break
+			}
+			err = wsutil.WriteServerText(conn, buf.Bytes())
+			if err != nil {
+				break
+			}
+		}
+	}
+}
+
+func getDNSStatistics(queryManager *dnslog.Manager) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := 10
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			var err error
+			limit, err = strconv.Atoi(limitStr)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, ErrBadRequest)
+				return
+			}
+		}
+		statistics := queryManager.Statistics(limit)
+		domainCount := func(it dnslog.DomainCount) render.M {
+			return render.M{
+				"domain": it.Domain,
+				"count":  it.Count,
+			}
+		}
+		render.JSON(w, r, render.M{
+			"total":      statistics.Total,
+			"cached":     statistics.Cached,
+			"blocked":    statistics.Blocked,
+			"failed":     statistics.Failed,
+			"topDomains": common.Map(statistics.TopDomains, domainCount),
+			"topBlocked": common.Map(statistics.TopBlocked, domainCount),
+			"upstreams": common.Map(statistics.Upstreams, func(it dnslog.UpstreamLatency) render.M {
+				return render.M{
+					"transport": it.Transport,
+					"count":     it.Count,
+					"p50":       it.P50.Milliseconds(),
+					"p90":       it.P90.Milliseconds(),
+					"p99":       it.P99.Milliseconds(),
+				}
+			}),
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package dnslog
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/observable"
+	"github.com/sagernet/sing/common/x/list"
+)
+
+const recordsLimit = 1000
+
+type Manager struct {
+	total   atomic.Int64
+	cached  atomic.Int64
+	blocked atomic.Int64
+	failed  atomic.Int64
+
+	access  sync.Mutex
+	records list.List[adapter.DNSQueryRecord]
+
+	observer *observable.Observer[adapter.DNSQueryRecord]
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		observer: observable.NewObserver(observable.NewSubscriber[adapter.DNSQueryRecord](128), 64),
+	}
+}
+
+func (m *Manager) Record(record adapter.DNSQueryRecord) {
+	m.total.Add(1)
+	if record.Cached {
+		m.cached.Add(1)
+	}
+	if record.Blocked {
+		m.blocked.Add(1)
+	} else if record.Error != "" {
+		m.failed.Add(1)
+	}
+	m.access.Lock()
+	if m.records.Len() >= recordsLimit {
+		m.records.PopFront()
+	}
+	m.records.PushBack(record)
+	m.access.Unlock()
+	m.observer.Emit(record)
+}
+
+func (m *Manager) Records() []adapter.DNSQueryRecord {
+	m.access.Lock()
+	defer m.access.Unlock()
+	return m.records.Array()
+}
+
+func (m *Manager) Subscribe() (subscription observable.Subscription[adapter.DNSQueryRecord], done <-chan struct{}, err error) {
+	return m.observer.Subscribe()
+}
+
+func (m *Manager) UnSubscribe(subscription observable.Subscription[adapter.DNSQueryRecord]) {
+	m.observer.UnSubscribe(subscription)
+}
+
+type Statistics struct {
+	Total      int64
+	Cached     int64
+	Blocked    int64
+	Failed     int64
+	TopDomains []DomainCount
+	TopBlocked []DomainCount
+	Upstreams  []UpstreamLatency
+}
+
+type DomainCount struct {
+	Domain string
+	Count  int64
+}
+
+type UpstreamLatency struct {
+	Transport string
+	Count     int64
+	P50       time.Duration
+	P90       time.Duration
+	P99       time.Duration
+}
+
+// Statistics returns the lifetime counters together with the rankings and
+// latency percentiles computed over the retained records.
+func (m *Manager) Statistics(limit int) Statistics {
+	records := m.Records()
+	domains := make(map[string]int64)
+	blocked := make(map[string]int64)
+	latencies := make(map[string][]time.Duration)
+	for _, record := range records {
+		if record.Domain == "" {
+			continue
+		}
+		if record.Blocked {
+			blocked[record.Domain]++
+			continue
+		}
+		domains[record.Domain]++
+		if !record.Cached && record.Error == "" && record.Transport != "" {
+			latencies[record.Transport] = append(latencies[record.Transport], record.Latency)
+		}
+	}
+	statistics := Statistics{
+		Total:      m.total.Load(),
+		Cached:     m.cached.Load(),
+		Blocked:    m.blocked.Load(),
+		Failed:     m.failed.Load(),
+		TopDomains: topDomains(domains, limit),
+		TopBlocked: topDomains(blocked, limit),
+	}
+	for transport, samples := range latencies {
+		slices.Sort(samples)
+		statistics.Upstreams = append(statistics.Upstreams, UpstreamLatency{
+			Transport: transport,
+			Count:     int64(len(samples)),
+			P50:       percentile(samples, 50),
+			P90:       percentile(samples, 90),
+			P99:       percentile(samples, 99),
+		})
+	}
+	slices.SortFunc(statistics.Upstreams, func(a, b UpstreamLatency) int {
+		return cmp.Compare(a.Transport, b.Transport)
+	})
+	return statistics
+}
+
+func (m *Manager) Close() error {
+	return common.Close(m.observer)
+}
+
+func topDomains(counts map[string]int64, limit int) []DomainCount {
+	result := make([]DomainCount, 0, len(counts))
+	for domain, count := range counts {
+		result = append(result, DomainCount{Domain: domain, Count: count})
+	}
+	slices.SortFunc(result, func(a, b DomainCount) int {
+		if a.Count != b.Count {
+			return cmp.Compare(b.Count, a.Count)
+		}
+		return cmp.Compare(a.Domain, b.Domain)
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	index := (len(sorted)*p+99)/100 - 1
+	return sorted[max(index, 0)]
+}
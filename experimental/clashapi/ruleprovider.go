@@ -1,58 +1,237 @@
 package clashapi
 
 import (
+	"bytes"
+	"context"
 	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/observable"
+	"github.com/sagernet/sing/common/x/list"
+	"github.com/sagernet/ws"
+	"github.com/sagernet/ws/wsutil"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 )
 
-func ruleProviderRouter() http.Handler {
+const ruleSetEventsLimit = 100
+
+func ruleProviderRouter(router adapter.Router) http.Handler {
 	r := chi.NewRouter()
-	r.Get("/", getRuleProviders)
+	r.Get("/", getRuleProviders(router))
 
 	r.Route("/{name}", func(r chi.Router) {
-		r.Use(parseProviderName, findRuleProviderByName)
+		r.Use(parseProviderName, findRuleProviderByName(router))
 		r.Get("/", getRuleProvider)
 		r.Put("/", updateRuleProvider)
 	})
 	return r
 }
 
-func getRuleProviders(w http.ResponseWriter, r *http.Request) {
-	render.JSON(w, r, render.M{
-		"providers": []string{},
-	})
+func ruleProviderInfo(ruleSet adapter.RuleSet) *RuleProvider {
+	info := &RuleProvider{
+		Name:     ruleSet.Name(),
+		Type:     "Rule",
+		Behavior: "Classical",
+	}
+	if statisticsRuleSet, isStatistics := ruleSet.(adapter.RuleSetWithStatistics); isStatistics {
+		statistics := statisticsRuleSet.Statistics()
+		switch statistics.Type {
+		case C.RuleSetTypeRemote:
+			info.VehicleType = "HTTP"
+		case C.RuleSetTypeLocal:
+			info.VehicleType = "File"
+		default:
+			info.VehicleType = "Inline"
+		}
+		info.Format = statistics.Format
+		info.RuleCount = statistics.RuleCount
+		info.IgnoredCount = statistics.IgnoredCount
+		info.UpdatedAt = statistics.LastUpdated
+		info.Version = statistics.Version
+		info.Checksum = statistics.Checksum
+		info.ETag = statistics.ETag
+	}
+	return info
+}
+
+type RuleProvider struct {
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`
+	VehicleType  string    `json:"vehicleType"`
+	Behavior     string    `json:"behavior"`
+	Format       string    `json:"format,omitempty"`
+	RuleCount    int       `json:"ruleCount"`
+	IgnoredCount int       `json:"ignoredCount"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+	Version      uint8     `json:"version,omitempty"`
+	Checksum     string    `json:"checksum,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+}
+
+func getRuleProviders(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		providers := make(map[string]*RuleProvider)
+		for _, ruleSet := range router.RuleSets() {
+			providers[ruleSet.Name()] = ruleProviderInfo(ruleSet)
+		}
+		render.JSON(w, r, render.M{
+			"providers": providers,
+		})
+	}
 }
 
 func getRuleProvider(w http.ResponseWriter, r *http.Request) {
-	// provider := r.Context().Value(CtxKeyProvider).(provider.RuleProvider)
-	// render.JSON(w, r, provider)
-	render.NoContent(w, r)
+	ruleSet := r.Context().Value(CtxKeyProvider).(adapter.RuleSet)
+	render.JSON(w, r, ruleProviderInfo(ruleSet))
 }
 
 func updateRuleProvider(w http.ResponseWriter, r *http.Request) {
-	/*provider := r.Context().Value(CtxKeyProvider).(provider.RuleProvider)
-	if err := provider.Update(); err != nil {
-		render.Status(r, http.StatusServiceUnavailable)
-		render.JSON(w, r, newError(err.Error()))
-		return
-	}*/
+	ruleSet := r.Context().Value(CtxKeyProvider).(adapter.RuleSet)
+	updater, isUpdater := ruleSet.(adapter.RuleSetWithUpdate)
+	if isUpdater {
+		err := updater.Update(r.Context())
+		if err != nil {
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+	}
 	render.NoContent(w, r)
 }
 
-func findRuleProviderByName(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		/*name := r.Context().Value(CtxKeyProviderName).(string)
-		providers := tunnel.RuleProviders()
-		provider, exist := providers[name]
-		if !exist {*/
-		render.Status(r, http.StatusNotFound)
-		render.JSON(w, r, ErrNotFound)
-		//return
-		//}
+func findRuleProviderByName(router adapter.Router) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := r.Context().Value(CtxKeyProviderName).(string)
+			ruleSet, exist := router.RuleSet(name)
+			if !exist {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, ErrNotFound)
+				return
+			}
+			ctx := context.WithValue(r.Context(), CtxKeyProvider, ruleSet)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
 
-		// ctx := context.WithValue(r.Context(), CtxKeyProvider, provider)
-		// next.ServeHTTP(w, r.WithContext(ctx))
-	})
+type RuleSetReloadEvent struct {
+	Time              time.Time `json:"time"`
+	Name              string    `json:"name"`
+	VehicleType       string    `json:"vehicleType"`
+	RuleCount         int       `json:"ruleCount"`
+	PreviousRuleCount int       `json:"previousRuleCount"`
+	Delta             int       `json:"delta"`
+	Error             string    `json:"error,omitempty"`
+}
+
+func newRuleSetReloadEvent(event adapter.RuleSetReloadEvent) RuleSetReloadEvent {
+	reloadEvent := RuleSetReloadEvent{
+		Time:              event.Time,
+		Name:              event.Tag,
+		RuleCount:         event.RuleCount,
+		PreviousRuleCount: event.PreviousRuleCount,
+		Delta:             event.RuleCount - event.PreviousRuleCount,
+		Error:             event.Error,
+	}
+	switch event.Type {
+	case C.RuleSetTypeRemote:
+		reloadEvent.VehicleType = "HTTP"
+	case C.RuleSetTypeLocal:
+		reloadEvent.VehicleType = "File"
+	default:
+		reloadEvent.VehicleType = "Inline"
+	}
+	return reloadEvent
+}
+
+// ruleSetEventManager keeps recent rule-set reload events and streams new ones to subscribers.
+type ruleSetEventManager struct {
+	access   sync.Mutex
+	events   list.List[adapter.RuleSetReloadEvent]
+	observer *observable.Observer[adapter.RuleSetReloadEvent]
+}
+
+func newRuleSetEventManager() *ruleSetEventManager {
+	return &ruleSetEventManager{
+		observer: observable.NewObserver(observable.NewSubscriber[adapter.RuleSetReloadEvent](16), 16),
+	}
+}
+
+func (m *ruleSetEventManager) Record(event adapter.RuleSetReloadEvent) {
+	m.access.Lock()
+	if m.events.Len() >= ruleSetEventsLimit {
+		m.events.PopFront()
+	}
+	m.events.PushBack(event)
+	m.access.Unlock()
+	m.observer.Emit(event)
+}
+
+func (m *ruleSetEventManager) Events() []adapter.RuleSetReloadEvent {
+	m.access.Lock()
+	defer m.access.Unlock()
+	return m.events.Array()
+}
+
+func (m *ruleSetEventManager) Close() error {
+	return common.Close(m.observer)
+}
+
+func ruleSetRouter(ctx context.Context, eventManager *ruleSetEventManager) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/events", getRuleSetEvents(ctx, eventManager))
+	return r
+}
+
+func getRuleSetEvents(ctx context.Context, eventManager *ruleSetEventManager) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			render.JSON(w, r, render.M{
+				"events": common.Map(eventManager.Events(), newRuleSetReloadEvent),
+			})
+			return
+		}
+
+		subscription, done, err := eventManager.observer.Subscribe()
+		if err != nil {
+			render.Status(r, http.StatusNoContent)
+			return
+		}
+		defer eventManager.observer.UnSubscribe(subscription)
+
+		conn, _, _, err := ws.UpgradeHTTP(r, w)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		buf := &bytes.Buffer{}
+		var event adapter.RuleSetReloadEvent
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case event = <-subscription:
+			}
+			buf.Reset()
+			err = json.NewEncoder(buf).Encode(newRuleSetReloadEvent(event))
+			if err != nil {
+				break
+			}
+			err = wsutil.WriteServerText(conn, buf.Bytes())
+			if err != nil {
+				break
+			}
+		}
+	}
 }
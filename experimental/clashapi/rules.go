@@ -2,8 +2,11 @@ package clashapi
 
 import (
 	"net/http"
+	"net/netip"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/route"
+	"github.com/sagernet/sing/common"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -12,6 +15,7 @@ import (
 func ruleRouter(router adapter.Router) http.Handler {
 	r := chi.NewRouter()
 	r.Get("/", getRules(router))
+	r.Get("/explain", explainRoute(router))
 	return r
 }
 
@@ -38,3 +42,88 @@ func getRules(router adapter.Router) func(w http.ResponseWriter, r *http.Request
 		})
 	}
 }
+
+type RuleExplanation struct {
+	Index   int                   `json:"index"`
+	Rule    string                `json:"rule"`
+	Action  string                `json:"action"`
+	Matched bool                  `json:"matched"`
+	Items   []RuleItemExplanation `json:"items,omitempty"`
+}
+
+type RuleItemExplanation struct {
+	Item    string `json:"item"`
+	Matched bool   `json:"matched"`
+}
+
+func newRuleExplanation(explanation adapter.RuleExplanation) RuleExplanation {
+	return RuleExplanation{
+		Index:   explanation.Index,
+		Rule:    explanation.Rule,
+		Action:  explanation.Action,
+		Matched: explanation.Matched,
+		Items: common.Map(explanation.Items, func(it adapter.RuleItemExplanation) RuleItemExplanation {
+			return RuleItemExplanation{
+				Item:    it.Item,
+				Matched: it.Matched,
+			}
+		}),
+	}
+}
+
+// explainRoute reports how a hypothetical connection described by the query would be routed:
+// inbound, network, source, destination (host or host:port), domain and protocol (as if sniffed),
+// process (name or path), package and user (the authenticated inbound user).
+func explainRoute(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		explanation, err := router.Explain(r.Context(), route.ExplainQuery{
+			Inbound:     query.Get("inbound"),
+			Network:     query.Get("network"),
+			Source:      query.Get("source"),
+			Destination: query.Get("destination"),
+			Domain:      query.Get("domain"),
+			Protocol:    query.Get("protocol"),
+			User:        query.Get("user"),
+			ProcessPath: query.Get("process"),
+			PackageName: query.Get("package"),
+		}.Metadata())
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		metadata := explanation.Metadata
+		metadataInfo := render.M{
+			"network":              metadata.Network,
+			"inbound":              metadata.Inbound,
+			"inboundType":          metadata.InboundType,
+			"destination":          metadata.Destination.String(),
+			"domain":               metadata.Domain,
+			"protocol":             metadata.Protocol,
+			"user":                 metadata.User,
+			"destinationAddresses": common.Map(metadata.DestinationAddresses, netip.Addr.String),
+		}
+		if metadata.Source.IsValid() {
+			metadataInfo["source"] = metadata.Source.String()
+		}
+		response := render.M{
+			"metadata":  metadataInfo,
+			"rules":     common.Map(explanation.Rules, newRuleExplanation),
+			"ruleIndex": explanation.RuleIndex,
+			"rule":      explanation.Rule,
+			"action":    explanation.Action,
+			"outbound":  explanation.Outbound,
+		}
+		if explanation.DNS != nil {
+			response["dns"] = render.M{
+				"rules":     common.Map(explanation.DNS.Rules, newRuleExplanation),
+				"ruleIndex": explanation.DNS.RuleIndex,
+				"rule":      explanation.DNS.Rule,
+				"action":    explanation.DNS.Action,
+				"server":    explanation.DNS.Server,
+			}
+		}
+		render.JSON(w, r, response)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/sagernet/sing-box/common/urltest"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/experimental"
+	"github.com/sagernet/sing-box/experimental/clashapi/dnslog"
 	"github.com/sagernet/sing-box/experimental/clashapi/trafficontrol"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
@@ -49,6 +50,8 @@ type Server struct {
 	logger         log.Logger
 	httpServer     *http.Server
 	trafficManager *trafficontrol.Manager
+	queryManager   *dnslog.Manager
+	ruleSetEvents  *ruleSetEventManager
 	urlTestHistory adapter.URLTestHistoryStorage
 	logDebug       bool
 
@@ -77,6 +80,8 @@ func NewServer(ctx context.Context, logFactory log.ObservableFactory, options op
 			Handler: chiRouter,
 		},
 		trafficManager:           trafficManager,
+		queryManager:             dnslog.NewManager(),
+		ruleSetEvents:            newRuleSetEventManager(),
 		logDebug:                 logFactory.Level() >= log.LevelDebug,
 		modeList:                 options.ModeList,
 		externalController:       options.ExternalController != "",
@@ -123,11 +128,12 @@ func NewServer(ctx context.Context, logFactory log.ObservableFactory, options op
 		r.Mount("/rules", ruleRouter(s.router))
 		r.Mount("/connections", connectionRouter(s.ctx, s.router, trafficManager))
 		r.Mount("/providers/proxies", proxyProviderRouter())
-		r.Mount("/providers/rules", ruleProviderRouter())
+		r.Mount("/providers/rules", ruleProviderRouter(s.router))
+		r.Mount("/rule-sets", ruleSetRouter(s.ctx, s.ruleSetEvents))
 		r.Mount("/script", scriptRouter())
 		r.Mount("/profile", profileRouter())
 		r.Mount("/cache", cacheRouter(ctx))
-		r.Mount("/dns", dnsRouter(s.dnsRouter))
+		r.Mount("/dns", dnsRouter(s.ctx, s.dnsRouter, s.queryManager))
 
 		s.setupMetaAPI(r)
 	})
@@ -192,6 +198,8 @@ func (s *Server) Close() error {
 	return common.Close(
 		common.PtrOrNil(s.httpServer),
 		s.trafficManager,
+		s.queryManager,
+		s.ruleSetEvents,
 		s.urlTestHistory,
 	)
 }
@@ -243,6 +251,10 @@ func (s *Server) TrafficManager() *trafficontrol.Manager {
 	return s.trafficManager
 }
 
+func (s *Server) DNSQueryManager() *dnslog.Manager {
+	return s.queryManager
+}
+
 func (s *Server) RoutedConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, matchedRule adapter.Rule, matchOutbound adapter.Outbound) net.Conn {
 	return trafficontrol.NewTCPTracker(conn, s.trafficManager, metadata, s.outbound, matchedRule, matchOutbound)
 }
@@ -251,6 +263,14 @@ func (s *Server) RoutedPacketConnection(ctx context.Context, conn N.PacketConn,
 	return trafficontrol.NewUDPTracker(conn, s.trafficManager, metadata, s.outbound, matchedRule, matchOutbound)
 }
 
+func (s *Server) RoutedDNSQuery(ctx context.Context, record adapter.DNSQueryRecord) {
+	s.queryManager.Record(record)
+}
+
+func (s *Server) RuleSetReloaded(event adapter.RuleSetReloadEvent) {
+	s.ruleSetEvents.Record(event)
+}
+
 func authentication(serverSecret string) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
@@ -396,6 +396,7 @@ type RemoteHTTPSDNSServerOptions struct {
 	Path    string               `json:"path,omitempty"`
 	Method  string               `json:"method,omitempty"`
 	Headers badoption.HTTPHeader `json:"headers,omitempty"`
+	HTTP3   string               `json:"http3,omitempty"`
 }
 
 type FakeIPDNSServerOptions struct {
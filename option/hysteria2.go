@@ -12,11 +12,12 @@ import (
 
 type Hysteria2InboundOptions struct {
 	ListenOptions
-	UpMbps                int             `json:"up_mbps,omitempty"`
-	DownMbps              int             `json:"down_mbps,omitempty"`
-	Obfs                  *Hysteria2Obfs  `json:"obfs,omitempty"`
-	Users                 []Hysteria2User `json:"users,omitempty"`
-	IgnoreClientBandwidth bool            `json:"ignore_client_bandwidth,omitempty"`
+	ListenPorts           badoption.Listable[string] `json:"listen_ports,omitempty"`
+	UpMbps                int                        `json:"up_mbps,omitempty"`
+	DownMbps              int                        `json:"down_mbps,omitempty"`
+	Obfs                  *Hysteria2Obfs             `json:"obfs,omitempty"`
+	Users                 []Hysteria2User            `json:"users,omitempty"`
+	IgnoreClientBandwidth bool                       `json:"ignore_client_bandwidth,omitempty"`
 	InboundTLSOptionsContainer
 	Masquerade  *Hysteria2Masquerade `json:"masquerade,omitempty"`
 	BrutalDebug bool                 `json:"brutal_debug,omitempty"`
@@ -30,6 +31,8 @@ type Hysteria2Obfs struct {
 type Hysteria2User struct {
 	Name     string `json:"name,omitempty"`
 	Password string `json:"password,omitempty"`
+	UpMbps   int    `json:"up_mbps,omitempty"`
+	DownMbps int    `json:"down_mbps,omitempty"`
 }
 
 type _Hysteria2Masquerade struct {
@@ -87,11 +87,24 @@ type DialerOptions struct {
 	NetworkType          badoption.Listable[InterfaceType] `json:"network_type,omitempty"`
 	FallbackNetworkType  badoption.Listable[InterfaceType] `json:"fallback_network_type,omitempty"`
 	FallbackDelay        badoption.Duration                `json:"fallback_delay,omitempty"`
+	TLSFragment          *TLSFragmentOptions               `json:"tls_fragment,omitempty"`
 
 	// Deprecated: migrated to domain resolver
 	DomainStrategy DomainStrategy `json:"domain_strategy,omitempty"`
 }
 
+type TLSFragmentOptions struct {
+	Enabled       bool               `json:"enabled,omitempty"`
+	Mode          string             `json:"mode,omitempty"`
+	Strategy      string             `json:"strategy,omitempty"`
+	Count         int                `json:"count,omitempty"`
+	MinSize       int                `json:"min_size,omitempty"`
+	MaxSize       int                `json:"max_size,omitempty"`
+	MinDelay      badoption.Duration `json:"min_delay,omitempty"`
+	MaxDelay      badoption.Duration `json:"max_delay,omitempty"`
+	FallbackDelay badoption.Duration `json:"fallback_delay,omitempty"`
+}
+
 type _DomainResolveOptions struct {
 	Server       string                `json:"server"`
 	Strategy     DomainStrategy        `json:"strategy,omitempty"`
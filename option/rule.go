@@ -73,6 +73,14 @@ type RawDefaultRule struct {
 	AuthUser                 badoption.Listable[string]                                                  `json:"auth_user,omitempty"`
 	Protocol                 badoption.Listable[string]                                                  `json:"protocol,omitempty"`
 	Client                   badoption.Listable[string]                                                  `json:"client,omitempty"`
+	HTTPMethod               badoption.Listable[string]                                                  `json:"http_method,omitempty"`
+	HTTPPathPrefix           badoption.Listable[string]                                                  `json:"http_path_prefix,omitempty"`
+	HTTPPathRegex            badoption.Listable[string]                                                  `json:"http_path_regex,omitempty"`
+	HTTPHeader               *badjson.TypedMap[string, badoption.Listable[string]]                       `json:"http_header,omitempty"`
+	TLSFingerprint           badoption.Listable[string]                                                  `json:"tls_fingerprint,omitempty"`
+	TLSJA4                   badoption.Listable[string]                                                  `json:"tls_ja4,omitempty"`
+	TLSALPN                  badoption.Listable[string]                                                  `json:"tls_alpn,omitempty"`
+	TLSVersion               badoption.Listable[string]                                                  `json:"tls_version,omitempty"`
 	Domain                   badoption.Listable[string]                                                  `json:"domain,omitempty"`
 	DomainSuffix             badoption.Listable[string]                                                  `json:"domain_suffix,omitempty"`
 	DomainKeyword            badoption.Listable[string]                                                  `json:"domain_keyword,omitempty"`
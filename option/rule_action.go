@@ -7,6 +7,7 @@ import (
 	"time"
 
 	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common/auth"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/json"
 	"github.com/sagernet/sing/common/json/badjson"
@@ -22,6 +23,7 @@ type _RuleAction struct {
 	RejectOptions       RejectActionOptions       `json:"-"`
 	SniffOptions        RouteActionSniff          `json:"-"`
 	ResolveOptions      RouteActionResolve        `json:"-"`
+	HTTPRewriteOptions  RouteActionHTTPRewrite    `json:"-"`
 }
 
 type RuleAction _RuleAction
@@ -49,6 +51,8 @@ func (r RuleAction) MarshalJSON() ([]byte, error) {
 		v = r.SniffOptions
 	case C.RuleActionTypeResolve:
 		v = r.ResolveOptions
+	case C.RuleActionTypeHTTPRewrite:
+		v = r.HTTPRewriteOptions
 	default:
 		return nil, E.New("unknown rule action: " + r.Action)
 	}
@@ -82,6 +86,8 @@ func (r *RuleAction) UnmarshalJSON(data []byte) error {
 		v = &r.SniffOptions
 	case C.RuleActionTypeResolve:
 		v = &r.ResolveOptions
+	case C.RuleActionTypeHTTPRewrite:
+		v = &r.HTTPRewriteOptions
 	default:
 		return E.New("unknown rule action: " + r.Action)
 	}
@@ -102,6 +108,7 @@ type _DNSRuleAction struct {
 	RouteOptionsOptions DNSRouteOptionsActionOptions `json:"-"`
 	RejectOptions       RejectActionOptions          `json:"-"`
 	PredefinedOptions   DNSRouteActionPredefined     `json:"-"`
+	RewriteOptions      DNSRouteActionRewrite        `json:"-"`
 }
 
 type DNSRuleAction _DNSRuleAction
@@ -121,6 +128,8 @@ func (r DNSRuleAction) MarshalJSON() ([]byte, error) {
 		v = r.RejectOptions
 	case C.RuleActionTypePredefined:
 		v = r.PredefinedOptions
+	case C.RuleActionTypeRewrite:
+		v = r.RewriteOptions
 	default:
 		return nil, E.New("unknown DNS rule action: " + r.Action)
 	}
@@ -143,6 +152,8 @@ func (r *DNSRuleAction) UnmarshalJSONContext(ctx context.Context, data []byte) e
 		v = &r.RejectOptions
 	case C.RuleActionTypePredefined:
 		v = &r.PredefinedOptions
+	case C.RuleActionTypeRewrite:
+		v = &r.RewriteOptions
 	default:
 		return E.New("unknown DNS rule action: " + r.Action)
 	}
@@ -164,10 +175,13 @@ type RawRouteOptionsActionOptions struct {
 	UDPDisableDomainUnmapping bool               `json:"udp_disable_domain_unmapping,omitempty"`
 	UDPConnect                bool               `json:"udp_connect,omitempty"`
 	UDPTimeout                badoption.Duration `json:"udp_timeout,omitempty"`
+	UDPFallback               string             `json:"udp_fallback,omitempty"`
 
 	TLSFragment              bool               `json:"tls_fragment,omitempty"`
 	TLSFragmentFallbackDelay badoption.Duration `json:"tls_fragment_fallback_delay,omitempty"`
 	TLSRecordFragment        bool               `json:"tls_record_fragment,omitempty"`
+
+	MITM bool `json:"mitm,omitempty"`
 }
 
 type RouteOptionsActionOptions RawRouteOptionsActionOptions
@@ -192,6 +206,8 @@ type DNSRouteActionOptions struct {
 	DisableCache bool                  `json:"disable_cache,omitempty"`
 	RewriteTTL   *uint32               `json:"rewrite_ttl,omitempty"`
 	ClientSubnet *badoption.Prefixable `json:"client_subnet,omitempty"`
+	DNS64        bool                  `json:"dns64,omitempty"`
+	DNS64Prefix  *badoption.Prefix     `json:"dns64_prefix,omitempty"`
 }
 
 type _DNSRouteOptionsActionOptions struct {
@@ -199,6 +215,8 @@ type _DNSRouteOptionsActionOptions struct {
 	DisableCache bool                  `json:"disable_cache,omitempty"`
 	RewriteTTL   *uint32               `json:"rewrite_ttl,omitempty"`
 	ClientSubnet *badoption.Prefixable `json:"client_subnet,omitempty"`
+	DNS64        bool                  `json:"dns64,omitempty"`
+	DNS64Prefix  *badoption.Prefix     `json:"dns64_prefix,omitempty"`
 }
 
 type DNSRouteOptionsActionOptions _DNSRouteOptionsActionOptions
@@ -302,8 +320,20 @@ func (r *RejectActionOptions) UnmarshalJSON(bytes []byte) error {
 }
 
 type RouteActionSniff struct {
-	Sniffer badoption.Listable[string] `json:"sniffer,omitempty"`
-	Timeout badoption.Duration         `json:"timeout,omitempty"`
+	Sniffer   badoption.Listable[string] `json:"sniffer,omitempty"`
+	Timeout   badoption.Duration         `json:"timeout,omitempty"`
+	AmneziaWG *SniffAmneziaWGOptions     `json:"amneziawg,omitempty"`
+}
+
+type SniffAmneziaWGOptions struct {
+	Jmin int    `json:"jmin,omitempty"`
+	Jmax int    `json:"jmax,omitempty"`
+	S1   int    `json:"s1,omitempty"`
+	S2   int    `json:"s2,omitempty"`
+	S3   int    `json:"s3,omitempty"`
+	H1   string `json:"h1,omitempty"`
+	H2   string `json:"h2,omitempty"`
+	H3   string `json:"h3,omitempty"`
 }
 
 type RouteActionResolve struct {
@@ -314,9 +344,46 @@ type RouteActionResolve struct {
 	ClientSubnet *badoption.Prefixable `json:"client_subnet,omitempty"`
 }
 
+type RouteActionHTTPRewrite struct {
+	Rules []HTTPRewriteRule `json:"rules,omitempty"`
+}
+
+type HTTPRewriteRule struct {
+	URLRegex           badoption.Listable[string] `json:"url_regex,omitempty"`
+	Action             string                     `json:"action,omitempty"`
+	AddHeader          badoption.HTTPHeader       `json:"add_header,omitempty"`
+	SetHeader          badoption.HTTPHeader       `json:"set_header,omitempty"`
+	RemoveHeader       badoption.Listable[string] `json:"remove_header,omitempty"`
+	ProxyAuthorization *auth.User                 `json:"proxy_authorization,omitempty"`
+	Location           string                     `json:"location,omitempty"`
+	StatusCode         int                        `json:"status_code,omitempty"`
+	Body               string                     `json:"body,omitempty"`
+}
+
 type DNSRouteActionPredefined struct {
 	Rcode  *DNSRCode                            `json:"rcode,omitempty"`
 	Answer badoption.Listable[DNSRecordOptions] `json:"answer,omitempty"`
 	Ns     badoption.Listable[DNSRecordOptions] `json:"ns,omitempty"`
 	Extra  badoption.Listable[DNSRecordOptions] `json:"extra,omitempty"`
 }
+
+type _DNSRouteActionRewrite struct {
+	CNAME         string                     `json:"cname,omitempty"`
+	StripAAAA     bool                       `json:"strip_aaaa,omitempty"`
+	FilterIPCIDR  badoption.Listable[string] `json:"filter_ip_cidr,omitempty"`
+	FilterRuleSet badoption.Listable[string] `json:"filter_rule_set,omitempty"`
+	ClientSubnet  *badoption.Prefixable      `json:"client_subnet,omitempty"`
+}
+
+type DNSRouteActionRewrite _DNSRouteActionRewrite
+
+func (r *DNSRouteActionRewrite) UnmarshalJSON(data []byte) error {
+	err := json.Unmarshal(data, (*_DNSRouteActionRewrite)(r))
+	if err != nil {
+		return err
+	}
+	if r.CNAME == "" && !r.StripAAAA && len(r.FilterIPCIDR) == 0 && len(r.FilterRuleSet) == 0 && r.ClientSubnet == nil {
+		return E.New("empty DNS rewrite action")
+	}
+	return nil
+}
@@ -93,6 +93,10 @@ func (r *RuleSet) UnmarshalJSON(bytes []byte) error {
 		case "":
 			return E.New("missing format")
 		case C.RuleSetFormatSource, C.RuleSetFormatBinary:
+		case C.RuleSetFormatAdGuard, C.RuleSetFormatHosts, C.RuleSetFormatClashClassical, C.RuleSetFormatClashDomain, C.RuleSetFormatClashIPCIDR, C.RuleSetFormatMRS:
+			if r.Type != C.RuleSetTypeRemote {
+				return E.New("rule-set format ", r.Format, " is only supported by remote rule-set")
+			}
 		default:
 			return E.New("unknown rule-set format: " + r.Format)
 		}
@@ -111,6 +115,8 @@ func ruleSetDefaultFormat(path string) string {
 		return C.RuleSetFormatSource
 	case ".srs":
 		return C.RuleSetFormatBinary
+	case ".mrs":
+		return C.RuleSetFormatMRS
 	default:
 		return ""
 	}
@@ -243,7 +249,7 @@ type PlainRuleSetCompat _PlainRuleSetCompat
 func (r PlainRuleSetCompat) MarshalJSON() ([]byte, error) {
 	var v any
 	switch r.Version {
-	case C.RuleSetVersion1, C.RuleSetVersion2, C.RuleSetVersion3, C.RuleSetVersion4:
+	case C.RuleSetVersion1, C.RuleSetVersion2, C.RuleSetVersion3, C.RuleSetVersion4, C.RuleSetVersion5:
 		v = r.Options
 	default:
 		return nil, E.New("unknown rule-set version: ", r.Version)
@@ -258,7 +264,7 @@ func (r *PlainRuleSetCompat) UnmarshalJSON(bytes []byte) error {
 	}
 	var v any
 	switch r.Version {
-	case C.RuleSetVersion1, C.RuleSetVersion2, C.RuleSetVersion3, C.RuleSetVersion4:
+	case C.RuleSetVersion1, C.RuleSetVersion2, C.RuleSetVersion3, C.RuleSetVersion4, C.RuleSetVersion5:
 		v = &r.Options
 	case 0:
 		return E.New("missing rule-set version")
@@ -275,7 +281,7 @@ func (r *PlainRuleSetCompat) UnmarshalJSON(bytes []byte) error {
 
 func (r PlainRuleSetCompat) Upgrade() (PlainRuleSet, error) {
 	switch r.Version {
-	case C.RuleSetVersion1, C.RuleSetVersion2, C.RuleSetVersion3, C.RuleSetVersion4:
+	case C.RuleSetVersion1, C.RuleSetVersion2, C.RuleSetVersion3, C.RuleSetVersion4, C.RuleSetVersion5:
 	default:
 		return PlainRuleSet{}, E.New("unknown rule-set version: " + F.ToString(r.Version))
 	}
@@ -7,8 +7,9 @@ import (
 
 type SocksInboundOptions struct {
 	ListenOptions
-	Users          []auth.User           `json:"users,omitempty"`
-	DomainResolver *DomainResolveOptions `json:"domain_resolver,omitempty"`
+	Users          []auth.User            `json:"users,omitempty"`
+	DomainResolver *DomainResolveOptions  `json:"domain_resolver,omitempty"`
+	Transport      *V2RayTransportOptions `json:"transport,omitempty"`
 }
 
 type HTTPMixedInboundOptions struct {
@@ -17,16 +18,19 @@ type HTTPMixedInboundOptions struct {
 	DomainResolver *DomainResolveOptions `json:"domain_resolver,omitempty"`
 	SetSystemProxy bool                  `json:"set_system_proxy,omitempty"`
 	InboundTLSOptionsContainer
+	Transport   *V2RayTransportOptions `json:"transport,omitempty"`
+	HTTPRewrite []HTTPRewriteRule      `json:"http_rewrite,omitempty"`
 }
 
 type SOCKSOutboundOptions struct {
 	DialerOptions
 	ServerOptions
-	Version    string             `json:"version,omitempty"`
-	Username   string             `json:"username,omitempty"`
-	Password   string             `json:"password,omitempty"`
-	Network    NetworkList        `json:"network,omitempty"`
-	UDPOverTCP *UDPOverTCPOptions `json:"udp_over_tcp,omitempty"`
+	Version    string                 `json:"version,omitempty"`
+	Username   string                 `json:"username,omitempty"`
+	Password   string                 `json:"password,omitempty"`
+	Network    NetworkList            `json:"network,omitempty"`
+	UDPOverTCP *UDPOverTCPOptions     `json:"udp_over_tcp,omitempty"`
+	Transport  *V2RayTransportOptions `json:"transport,omitempty"`
 }
 
 type HTTPOutboundOptions struct {
@@ -35,6 +39,7 @@ type HTTPOutboundOptions struct {
 	Username string `json:"username,omitempty"`
 	Password string `json:"password,omitempty"`
 	OutboundTLSOptionsContainer
-	Path    string               `json:"path,omitempty"`
-	Headers badoption.HTTPHeader `json:"headers,omitempty"`
+	Path      string                 `json:"path,omitempty"`
+	Headers   badoption.HTTPHeader   `json:"headers,omitempty"`
+	Transport *V2RayTransportOptions `json:"transport,omitempty"`
 }
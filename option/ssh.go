@@ -14,3 +14,19 @@ type SSHOutboundOptions struct {
 	HostKeyAlgorithms    badoption.Listable[string] `json:"host_key_algorithms,omitempty"`
 	ClientVersion        string                     `json:"client_version,omitempty"`
 }
+
+type SSHInboundOptions struct {
+	ListenOptions
+	Users             []SSHUser                  `json:"users,omitempty"`
+	HostKey           badoption.Listable[string] `json:"host_key,omitempty"`
+	HostKeyPath       string                     `json:"host_key_path,omitempty"`
+	HostKeyPassphrase string                     `json:"host_key_passphrase,omitempty"`
+	HostKeyAlgorithms badoption.Listable[string] `json:"host_key_algorithms,omitempty"`
+	ServerVersion     string                     `json:"server_version,omitempty"`
+}
+
+type SSHUser struct {
+	Name      string                     `json:"name,omitempty"`
+	Password  string                     `json:"password,omitempty"`
+	PublicKey badoption.Listable[string] `json:"public_key,omitempty"`
+}
@@ -1,9 +1,33 @@
 package option
 
+import "github.com/sagernet/sing/common/json/badoption"
+
+type TorOptions struct {
+	ExecutablePath      string                     `json:"executable_path,omitempty"`
+	ExtraArgs           []string                   `json:"extra_args,omitempty"`
+	DataDirectory       string                     `json:"data_directory,omitempty"`
+	Bridges             badoption.Listable[string] `json:"bridges,omitempty"`
+	PluggableTransports []TorPluggableTransport    `json:"pluggable_transports,omitempty"`
+	Options             map[string]string          `json:"torrc,omitempty"`
+}
+
+type TorPluggableTransport struct {
+	Transports     badoption.Listable[string] `json:"transports,omitempty"`
+	ExecutablePath string                     `json:"executable_path,omitempty"`
+	ExtraArgs      []string                   `json:"extra_args,omitempty"`
+}
+
 type TorOutboundOptions struct {
 	DialerOptions
-	ExecutablePath string            `json:"executable_path,omitempty"`
-	ExtraArgs      []string          `json:"extra_args,omitempty"`
-	DataDirectory  string            `json:"data_directory,omitempty"`
-	Options        map[string]string `json:"torrc,omitempty"`
+	TorOptions
+	Isolation string `json:"isolation,omitempty"`
+}
+
+type TorInboundOptions struct {
+	DialerOptions
+	TorOptions
+	PrivateKey      string                     `json:"private_key,omitempty"`
+	Ports           badoption.Listable[uint16] `json:"ports,omitempty"`
+	OverrideAddress string                     `json:"override_address,omitempty"`
+	OverridePort    uint16                     `json:"override_port,omitempty"`
 }
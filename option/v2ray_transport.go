@@ -15,6 +15,10 @@ type _V2RayTransportOptions struct {
 	QUICOptions        V2RayQUICOptions        `json:"-"`
 	GRPCOptions        V2RayGRPCOptions        `json:"-"`
 	HTTPUpgradeOptions V2RayHTTPUpgradeOptions `json:"-"`
+	XHTTPOptions       V2RayXHTTPOptions       `json:"-"`
+	ObfsOptions        V2RayObfsOptions        `json:"-"`
+	PluginOptions      V2RayPluginOptions      `json:"-"`
+	KCPOptions         V2RayKCPOptions         `json:"-"`
 }
 
 type V2RayTransportOptions _V2RayTransportOptions
@@ -32,6 +36,14 @@ func (o V2RayTransportOptions) MarshalJSON() ([]byte, error) {
 		v = o.GRPCOptions
 	case C.V2RayTransportTypeHTTPUpgrade:
 		v = o.HTTPUpgradeOptions
+	case C.V2RayTransportTypeXHTTP:
+		v = o.XHTTPOptions
+	case C.V2RayTransportTypeObfs:
+		v = o.ObfsOptions
+	case C.V2RayTransportTypePlugin:
+		v = o.PluginOptions
+	case C.V2RayTransportTypeKCP:
+		v = o.KCPOptions
 	case "":
 		return nil, E.New("missing transport type")
 	default:
@@ -57,6 +69,14 @@ func (o *V2RayTransportOptions) UnmarshalJSON(bytes []byte) error {
 		v = &o.GRPCOptions
 	case C.V2RayTransportTypeHTTPUpgrade:
 		v = &o.HTTPUpgradeOptions
+	case C.V2RayTransportTypeXHTTP:
+		v = &o.XHTTPOptions
+	case C.V2RayTransportTypeObfs:
+		v = &o.ObfsOptions
+	case C.V2RayTransportTypePlugin:
+		v = &o.PluginOptions
+	case C.V2RayTransportTypeKCP:
+		v = &o.KCPOptions
 	default:
 		return E.New("unknown transport type: " + o.Type)
 	}
@@ -98,3 +118,39 @@ type V2RayHTTPUpgradeOptions struct {
 	Path    string               `json:"path,omitempty"`
 	Headers badoption.HTTPHeader `json:"headers,omitempty"`
 }
+
+type V2RayXHTTPOptions struct {
+	Host               string               `json:"host,omitempty"`
+	Path               string               `json:"path,omitempty"`
+	Mode               string               `json:"mode,omitempty"`
+	Headers            badoption.HTTPHeader `json:"headers,omitempty"`
+	XPaddingBytes      string               `json:"x_padding_bytes,omitempty"`
+	NoGRPCHeader       bool                 `json:"no_grpc_header,omitempty"`
+	NoSSEHeader        bool                 `json:"no_sse_header,omitempty"`
+	MaxEachPostBytes   uint32               `json:"max_each_post_bytes,omitempty"`
+	MinPostsInterval   badoption.Duration   `json:"min_posts_interval,omitempty"`
+	MaxBufferedPosts   uint32               `json:"max_buffered_posts,omitempty"`
+	StreamUpServerSecs string               `json:"stream_up_server_secs,omitempty"`
+}
+
+type V2RayObfsOptions struct {
+	Mode string `json:"mode,omitempty"`
+	Host string `json:"host,omitempty"`
+}
+
+type V2RayPluginOptions struct {
+	Host       string `json:"host,omitempty"`
+	Path       string `json:"path,omitempty"`
+	DisableMux bool   `json:"disable_mux,omitempty"`
+}
+
+type V2RayKCPOptions struct {
+	MTU              uint32 `json:"mtu,omitempty"`
+	TTI              uint32 `json:"tti,omitempty"`
+	UplinkCapacity   uint32 `json:"uplink_capacity,omitempty"`
+	DownlinkCapacity uint32 `json:"downlink_capacity,omitempty"`
+	Congestion       bool   `json:"congestion,omitempty"`
+	WriteBufferSize  uint32 `json:"write_buffer_size,omitempty"`
+	HeaderType       string `json:"header_type,omitempty"`
+	Seed             string `json:"seed,omitempty"`
+}
@@ -12,7 +12,9 @@ import (
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/v2ray"
 	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/logger"
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
@@ -25,8 +27,9 @@ func RegisterOutbound(registry *outbound.Registry) {
 
 type Outbound struct {
 	outbound.Adapter
-	logger logger.ContextLogger
-	client *sHTTP.Client
+	logger    logger.ContextLogger
+	client    *sHTTP.Client
+	transport adapter.V2RayClientTransport
 }
 
 func NewOutbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.HTTPOutboundOptions) (adapter.Outbound, error) {
@@ -34,13 +37,33 @@ func NewOutbound(ctx context.Context, router adapter.Router, logger log.ContextL
 	if err != nil {
 		return nil, err
 	}
-	detour, err := tls.NewDialerFromOptions(ctx, logger, outboundDialer, options.Server, common.PtrValueOrDefault(options.TLS))
-	if err != nil {
-		return nil, err
+	var (
+		detour    N.Dialer
+		transport adapter.V2RayClientTransport
+	)
+	if options.Transport != nil {
+		var tlsConfig tls.Config
+		if options.TLS != nil {
+			tlsConfig, err = tls.NewClient(ctx, logger, options.Server, common.PtrValueOrDefault(options.TLS))
+			if err != nil {
+				return nil, err
+			}
+		}
+		transport, err = v2ray.NewClientTransport(ctx, outboundDialer, options.ServerOptions.Build(), common.PtrValueOrDefault(options.Transport), tlsConfig)
+		if err != nil {
+			return nil, E.Cause(err, "create client transport: ", options.Transport.Type)
+		}
+		detour = v2ray.NewTransportDialer(transport)
+	} else {
+		detour, err = tls.NewDialerFromOptions(ctx, logger, outboundDialer, options.Server, common.PtrValueOrDefault(options.TLS))
+		if err != nil {
+			return nil, err
+		}
 	}
 	return &Outbound{
-		Adapter: outbound.NewAdapterWithDialerOptions(C.TypeHTTP, tag, []string{N.NetworkTCP}, options.DialerOptions),
-		logger:  logger,
+		Adapter:   outbound.NewAdapterWithDialerOptions(C.TypeHTTP, tag, []string{N.NetworkTCP}, options.DialerOptions),
+		logger:    logger,
+		transport: transport,
 		client: sHTTP.NewClient(sHTTP.Options{
 			Dialer:   detour,
 			Server:   options.ServerOptions.Build(),
@@ -63,3 +86,13 @@ func (h *Outbound) DialContext(ctx context.Context, network string, destination
 func (h *Outbound) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
 	return nil, os.ErrInvalid
 }
+
+func (h *Outbound) InterfaceUpdated() {
+	if h.transport != nil {
+		h.transport.Close()
+	}
+}
+
+func (h *Outbound) Close() error {
+	return common.Close(h.transport)
+}
@@ -30,12 +30,15 @@ func RegisterInbound(registry *inbound.Registry) {
 
 type Inbound struct {
 	inbound.Adapter
-	router       adapter.Router
-	logger       log.ContextLogger
-	listener     *listener.Listener
-	tlsConfig    tls.ServerConfig
-	service      *hysteria2.Service[int]
-	userNameList []string
+	router            adapter.Router
+	logger            log.ContextLogger
+	listener          *listener.Listener
+	tlsConfig         tls.ServerConfig
+	portListeners     []*listener.Listener
+	service           *hysteria2.Service[int]
+	userNameList      []string
+	userBandwidthList []userBandwidth
+	connTracker       *connTracker
 }
 
 func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.Hysteria2InboundOptions) (adapter.Inbound, error) {
@@ -107,6 +110,55 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		}),
 		tlsConfig: tlsConfig,
 	}
+	if len(options.ListenPorts) > 0 {
+		listenPorts, err := hysteria.ParsePorts(options.ListenPorts)
+		if err != nil {
+			return nil, E.Cause(err, "parse listen_ports")
+		}
+		if len(listenPorts) > maxListenPorts {
+			return nil, E.New("too many listen_ports: ", len(listenPorts), " > ", maxListenPorts)
+		}
+		for _, port := range listenPorts {
+			if port == options.ListenPort {
+				continue
+			}
+			listenOptions := options.ListenOptions
+			listenOptions.ListenPort = port
+			inbound.portListeners = append(inbound.portListeners, listener.New(listener.Options{
+				Context: ctx,
+				Logger:  logger,
+				Listen:  listenOptions,
+			}))
+		}
+	}
+	userList := make([]int, 0, len(options.Users))
+	userNameList := make([]string, 0, len(options.Users))
+	userPasswordList := make([]string, 0, len(options.Users))
+	userBandwidthList := make([]userBandwidth, 0, len(options.Users))
+	var hasUserBandwidth bool
+	for index, user := range options.Users {
+		if user.UpMbps < 0 || user.DownMbps < 0 {
+			return nil, E.New("invalid bandwidth for user[", index, "]")
+		}
+		userList = append(userList, index)
+		userNameList = append(userNameList, user.Name)
+		userPasswordList = append(userPasswordList, user.Password)
+		userBandwidthList = append(userBandwidthList, userBandwidth{
+			sendBPS:    uint64(user.UpMbps * hysteria.MbpsToBps),
+			receiveBPS: uint64(user.DownMbps * hysteria.MbpsToBps),
+		})
+		if user.UpMbps > 0 || user.DownMbps > 0 {
+			hasUserBandwidth = true
+		}
+	}
+	var serviceTLSConfig tls.ServerConfig = tlsConfig
+	if hasUserBandwidth {
+		inbound.connTracker = newConnTracker()
+		serviceTLSConfig = &bandwidthTLSConfig{
+			ServerConfig: tlsConfig,
+			tracker:      inbound.connTracker,
+		}
+	}
 	var udpTimeout time.Duration
 	if options.UDPTimeout != 0 {
 		udpTimeout = time.Duration(options.UDPTimeout)
@@ -120,7 +172,7 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		SendBPS:               uint64(options.UpMbps * hysteria.MbpsToBps),
 		ReceiveBPS:            uint64(options.DownMbps * hysteria.MbpsToBps),
 		SalamanderPassword:    salamanderPassword,
-		TLSConfig:             tlsConfig,
+		TLSConfig:             serviceTLSConfig,
 		IgnoreClientBandwidth: options.IgnoreClientBandwidth,
 		UDPTimeout:            udpTimeout,
 		Handler:               inbound,
@@ -129,17 +181,10 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 	if err != nil {
 		return nil, err
 	}
-	userList := make([]int, 0, len(options.Users))
-	userNameList := make([]string, 0, len(options.Users))
-	userPasswordList := make([]string, 0, len(options.Users))
-	for index, user := range options.Users {
-		userList = append(userList, index)
-		userNameList = append(userNameList, user.Name)
-		userPasswordList = append(userPasswordList, user.Password)
-	}
 	service.UpdateUsers(userList, userPasswordList)
 	inbound.service = service
 	inbound.userNameList = userNameList
+	inbound.userBandwidthList = userBandwidthList
 	return inbound, nil
 }
 
@@ -162,6 +207,12 @@ func (h *Inbound) NewConnectionEx(ctx context.Context, conn net.Conn, source M.S
 	} else {
 		h.logger.InfoContext(ctx, "inbound connection to ", metadata.Destination)
 	}
+	if h.connTracker != nil {
+		sendLimiter, receiveLimiter := h.applyUserBandwidth(ctx, conn, source, userID)
+		if sendLimiter != nil || receiveLimiter != nil {
+			conn = &rateLimitedConn{Conn: conn, ctx: ctx, sendLimiter: sendLimiter, receiveLimiter: receiveLimiter}
+		}
+	}
 	h.router.RouteConnectionEx(ctx, conn, metadata, onClose)
 }
 
@@ -184,6 +235,12 @@ func (h *Inbound) NewPacketConnectionEx(ctx context.Context, conn N.PacketConn,
 	} else {
 		h.logger.InfoContext(ctx, "inbound packet connection to ", metadata.Destination)
 	}
+	if h.connTracker != nil {
+		sendLimiter, receiveLimiter := h.applyUserBandwidth(ctx, conn, source, userID)
+		if sendLimiter != nil || receiveLimiter != nil {
+			conn = &rateLimitedPacketConn{PacketConn: conn, ctx: ctx, sendLimiter: sendLimiter, receiveLimiter: receiveLimiter}
+		}
+	}
 	h.router.RoutePacketConnectionEx(ctx, conn, metadata, onClose)
 }
 
@@ -201,10 +258,24 @@ func (h *Inbound) Start(stage adapter.StartStage) error {
 	if err != nil {
 		return err
 	}
+	if len(h.portListeners) > 0 {
+		packetConns := []net.PacketConn{packetConn}
+		for _, portListener := range h.portListeners {
+			portConn, err := portListener.ListenUDP()
+			if err != nil {
+				return err
+			}
+			packetConns = append(packetConns, portConn)
+		}
+		packetConn = newMultiPortPacketConn(packetConns)
+	}
 	return h.service.Start(packetConn)
 }
 
 func (h *Inbound) Close() error {
+	for _, portListener := range h.portListeners {
+		portListener.Close()
+	}
 	return common.Close(
 		h.listener,
 		h.tlsConfig,
@@ -0,0 +1,231 @@
+package hysteria2
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/sagernet/quic-go"
+	"github.com/sagernet/sing-box/common/tls"
+	qtls "github.com/sagernet/sing-quic"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/buf"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"golang.org/x/time/rate"
+)
+
+type userBandwidth struct {
+	sendBPS    uint64
+	receiveBPS uint64
+}
+
+// bandwidthTLSConfig records accepted QUIC connections in their context,
+// so per-user bandwidth can be applied after the service authenticated them.
+type bandwidthTLSConfig struct {
+	tls.ServerConfig
+	tracker *connTracker
+}
+
+func (c *bandwidthTLSConfig) newTransport(conn net.PacketConn) *quic.Transport {
+	return &quic.Transport{
+		Conn: conn,
+		ConnContext: func(ctx context.Context, _ *quic.ClientInfo) (context.Context, error) {
+			return context.WithValue(ctx, trackedConnKey{}, &trackedConn{}), nil
+		},
+	}
+}
+
+func (c *bandwidthTLSConfig) Listen(conn net.PacketConn, config *quic.Config) (qtls.Listener, error) {
+	tlsConfig, err := c.STDConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport := c.newTransport(conn)
+	listener, err := transport.Listen(tlsConfig, config)
+	if err != nil {
+		return nil, qtls.WrapError(err)
+	}
+	return &trackedListener{Listener: listener, transport: transport, tracker: c.tracker}, nil
+}
+
+func (c *bandwidthTLSConfig) ListenEarly(conn net.PacketConn, config *quic.Config) (qtls.EarlyListener, error) {
+	tlsConfig, err := c.STDConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport := c.newTransport(conn)
+	listener, err := transport.ListenEarly(tlsConfig, config)
+	if err != nil {
+		return nil, qtls.WrapError(err)
+	}
+	return &trackedListener{Listener: listener, transport: transport, tracker: c.tracker}, nil
+}
+
+func (c *bandwidthTLSConfig) ConfigureHTTP3() {
+	_ = qtls.ConfigureHTTP3(c.ServerConfig)
+}
+
+type trackedListener struct {
+	qtls.Listener
+	transport *quic.Transport
+	tracker   *connTracker
+}
+
+func (l *trackedListener) Accept(ctx context.Context) (*quic.Conn, error) {
+	conn, err := l.Listener.Accept(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state := conn.Context().Value(trackedConnKey{}).(*trackedConn)
+	state.conn = conn
+	l.tracker.add(state)
+	return conn, nil
+}
+
+func (l *trackedListener) Close() error {
+	return common.Close(l.Listener, l.transport)
+}
+
+type trackedConnKey struct{}
+
+type connTracker struct {
+	access sync.Mutex
+	conns  map[*trackedConn]struct{}
+}
+
+type trackedConn struct {
+	conn           *quic.Conn
+	once           sync.Once
+	sendLimiter    *rate.Limiter
+	receiveLimiter *rate.Limiter
+}
+
+func newConnTracker() *connTracker {
+	return &connTracker{
+		conns: make(map[*trackedConn]struct{}),
+	}
+}
+
+func (t *connTracker) add(state *trackedConn) {
+	t.access.Lock()
+	t.conns[state] = struct{}{}
+	t.access.Unlock()
+	go func() {
+		<-state.conn.Context().Done()
+		t.access.Lock()
+		delete(t.conns, state)
+		t.access.Unlock()
+	}()
+}
+
+// lookup finds the QUIC connection a stream or UDP session belongs to.
+// Streams carry the connection in their context, while UDP sessions are only
+// reported with the remote address of the connection when they were created.
+func (t *connTracker) lookup(conn any, source M.Socksaddr) *trackedConn {
+	if contextConn, isContextConn := conn.(interface{ Context() context.Context }); isContextConn {
+		if state, loaded := contextConn.Context().Value(trackedConnKey{}).(*trackedConn); loaded {
+			return state
+		}
+	}
+	t.access.Lock()
+	defer t.access.Unlock()
+	for state := range t.conns {
+		if M.SocksaddrFromNet(state.conn.RemoteAddr()).Unwrap() == source {
+			return state
+		}
+	}
+	return nil
+}
+
+// applyUserBandwidth returns the limiters of data sent to and received from the user,
+// shared by all streams and UDP sessions of the connection.
+// The congestion controller negotiated with the client is kept: Brutal at the rate the client
+// declared, or BBR if it declared none, so the send limiter only lowers the rate further.
+func (h *Inbound) applyUserBandwidth(ctx context.Context, conn any, source M.Socksaddr, userID int) (sendLimiter *rate.Limiter, receiveLimiter *rate.Limiter) {
+	bandwidth := h.userBandwidthList[userID]
+	if bandwidth.sendBPS == 0 && bandwidth.receiveBPS == 0 {
+		return
+	}
+	state := h.connTracker.lookup(conn, source)
+	if state == nil {
+		h.logger.WarnContext(ctx, "connection for ", source, " not found, user bandwidth not applied")
+		return
+	}
+	state.once.Do(func() {
+		if bandwidth.sendBPS > 0 {
+			state.sendLimiter = rate.NewLimiter(rate.Limit(bandwidth.sendBPS), int(bandwidth.sendBPS))
+		}
+		if bandwidth.receiveBPS > 0 {
+			state.receiveLimiter = rate.NewLimiter(rate.Limit(bandwidth.receiveBPS), int(bandwidth.receiveBPS))
+		}
+	})
+	return state.sendLimiter, state.receiveLimiter
+}
+
+func waitLimiter(ctx context.Context, limiter *rate.Limiter, n int) error {
+	if limiter == nil {
+		return nil
+	}
+	for n > 0 {
+		chunk := min(n, limiter.Burst())
+		err := limiter.WaitN(ctx, chunk)
+		if err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+type rateLimitedConn struct {
+	net.Conn
+	ctx            context.Context
+	sendLimiter    *rate.Limiter
+	receiveLimiter *rate.Limiter
+}
+
+func (c *rateLimitedConn) Read(p []byte) (n int, err error) {
+	n, err = c.Conn.Read(p)
+	if n > 0 {
+		wErr := waitLimiter(c.ctx, c.receiveLimiter, n)
+		if err == nil {
+			err = wErr
+		}
+	}
+	return
+}
+
+func (c *rateLimitedConn) Write(p []byte) (n int, err error) {
+	err = waitLimiter(c.ctx, c.sendLimiter, len(p))
+	if err != nil {
+		return
+	}
+	return c.Conn.Write(p)
+}
+
+type rateLimitedPacketConn struct {
+	N.PacketConn
+	ctx            context.Context
+	sendLimiter    *rate.Limiter
+	receiveLimiter *rate.Limiter
+}
+
+func (c *rateLimitedPacketConn) ReadPacket(buffer *buf.Buffer) (destination M.Socksaddr, err error) {
+	destination, err = c.PacketConn.ReadPacket(buffer)
+	if err != nil {
+		return
+	}
+	err = waitLimiter(c.ctx, c.receiveLimiter, buffer.Len())
+	return
+}
+
+func (c *rateLimitedPacketConn) WritePacket(buffer *buf.Buffer, destination M.Socksaddr) error {
+	err := waitLimiter(c.ctx, c.sendLimiter, buffer.Len())
+	if err != nil {
+		buffer.Release()
+		return err
+	}
+	return c.PacketConn.WritePacket(buffer, destination)
+}
@@ -0,0 +1,211 @@
+package hysteria2
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/buf"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/sing/common/pipe"
+)
+
+// maxListenPorts caps listen_ports, since each port opens its own socket and receive loop.
+const maxListenPorts = 128
+
+const (
+	multiPortQueueSize    = 1024
+	multiPortBufferSize   = 2048
+	multiPortRouteTimeout = 5 * time.Minute
+)
+
+var _ net.PacketConn = (*multiPortPacketConn)(nil)
+
+// multiPortPacketConn merges sockets listening on different ports into one packet conn,
+// so clients hopping between server ports stay on the same QUIC connection.
+// Replies are sent from the socket that last received a packet from the peer.
+type multiPortPacketConn struct {
+	conns        []net.PacketConn
+	packetChan   chan *multiPortPacket
+	routeAccess  sync.RWMutex
+	routes       map[netip.AddrPort]*multiPortRoute
+	readDeadline pipe.Deadline
+	closeOnce    sync.Once
+	done         chan struct{}
+	err          error
+}
+
+type multiPortPacket struct {
+	buffer *buf.Buffer
+	addr   net.Addr
+}
+
+type multiPortRoute struct {
+	conn     net.PacketConn
+	lastSeen atomic.Int64
+}
+
+func newMultiPortPacketConn(conns []net.PacketConn) *multiPortPacketConn {
+	conn := &multiPortPacketConn{
+		conns:        conns,
+		packetChan:   make(chan *multiPortPacket, multiPortQueueSize),
+		routes:       make(map[netip.AddrPort]*multiPortRoute),
+		readDeadline: pipe.MakeDeadline(),
+		done:         make(chan struct{}),
+	}
+	for _, packetConn := range conns {
+		go conn.recvLoop(packetConn)
+	}
+	go conn.cleanupLoop()
+	return conn
+}
+
+func (c *multiPortPacketConn) recvLoop(conn net.PacketConn) {
+	for {
+		buffer := buf.NewSize(multiPortBufferSize)
+		n, addr, err := conn.ReadFrom(buffer.FreeBytes())
+		if err != nil {
+			buffer.Release()
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			c.closeWithError(err)
+			return
+		}
+		buffer.Truncate(n)
+		c.updateRoute(addr, conn)
+		select {
+		case c.packetChan <- &multiPortPacket{buffer: buffer, addr: addr}:
+		default:
+			buffer.Release()
+		}
+	}
+}
+
+func (c *multiPortPacketConn) updateRoute(addr net.Addr, conn net.PacketConn) {
+	addrPort := M.SocksaddrFromNet(addr).Unwrap().AddrPort()
+	now := time.Now().Unix()
+	c.routeAccess.RLock()
+	route, loaded := c.routes[addrPort]
+	c.routeAccess.RUnlock()
+	if loaded && route.conn == conn {
+		route.lastSeen.Store(now)
+		return
+	}
+	route = &multiPortRoute{conn: conn}
+	route.lastSeen.Store(now)
+	c.routeAccess.Lock()
+	c.routes[addrPort] = route
+	c.routeAccess.Unlock()
+}
+
+func (c *multiPortPacketConn) cleanupLoop() {
+	ticker := time.NewTicker(multiPortRouteTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.done:
+			return
+		}
+		deadline := time.Now().Add(-multiPortRouteTimeout).Unix()
+		c.routeAccess.Lock()
+		for addrPort, route := range c.routes {
+			if route.lastSeen.Load() < deadline {
+				delete(c.routes, addrPort)
+			}
+		}
+		c.routeAccess.Unlock()
+	}
+}
+
+func (c *multiPortPacketConn) ReadFrom(p []byte) (n int, addr net.Addr, err error) {
+	select {
+	case packet := <-c.packetChan:
+		n = copy(p, packet.buffer.Bytes())
+		packet.buffer.Release()
+		return n, packet.addr, nil
+	case <-c.done:
+		return 0, nil, c.err
+	case <-c.readDeadline.Wait():
+		return 0, nil, os.ErrDeadlineExceeded
+	}
+}
+
+func (c *multiPortPacketConn) WriteTo(p []byte, addr net.Addr) (n int, err error) {
+	conn := c.conns[0]
+	c.routeAccess.RLock()
+	route, loaded := c.routes[M.SocksaddrFromNet(addr).Unwrap().AddrPort()]
+	c.routeAccess.RUnlock()
+	if loaded {
+		conn = route.conn
+	}
+	return conn.WriteTo(p, addr)
+}
+
+func (c *multiPortPacketConn) closeWithError(err error) {
+	c.closeOnce.Do(func() {
+		if err == nil || E.IsClosed(err) {
+			err = net.ErrClosed
+		}
+		c.err = err
+		close(c.done)
+		for _, conn := range c.conns {
+			conn.Close()
+		}
+	})
+}
+
+func (c *multiPortPacketConn) Close() error {
+	c.closeWithError(nil)
+	return nil
+}
+
+func (c *multiPortPacketConn) LocalAddr() net.Addr {
+	return c.conns[0].LocalAddr()
+}
+
+func (c *multiPortPacketConn) SetDeadline(t time.Time) error {
+	c.readDeadline.Set(t)
+	return c.SetWriteDeadline(t)
+}
+
+func (c *multiPortPacketConn) SetReadDeadline(t time.Time) error {
+	c.readDeadline.Set(t)
+	return nil
+}
+
+func (c *multiPortPacketConn) SetWriteDeadline(t time.Time) error {
+	var errs []error
+	for _, conn := range c.conns {
+		errs = append(errs, conn.SetWriteDeadline(t))
+	}
+	return E.Errors(errs...)
+}
+
+func (c *multiPortPacketConn) SetReadBuffer(bytes int) error {
+	var errs []error
+	for _, conn := range c.conns {
+		if setter, isSetter := common.Cast[interface{ SetReadBuffer(bytes int) error }](conn); isSetter {
+			errs = append(errs, setter.SetReadBuffer(bytes))
+		}
+	}
+	return E.Errors(errs...)
+}
+
+func (c *multiPortPacketConn) SetWriteBuffer(bytes int) error {
+	var errs []error
+	for _, conn := range c.conns {
+		if setter, isSetter := common.Cast[interface{ SetWriteBuffer(bytes int) error }](conn); isSetter {
+			errs = append(errs, setter.SetWriteBuffer(bytes))
+		}
+	}
+	return E.Errors(errs...)
+}
@@ -0,0 +1,193 @@
+package hysteria2
+
+import (
+	"context"
+	stdTLS "crypto/tls"
+	"io"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/sagernet/quic-go"
+	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/json/badoption"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func newTestTLSOptions(t *testing.T) *option.InboundTLSOptions {
+	keyPEM, certificatePEM, err := tls.GenerateCertificate(nil, nil, time.Now, "example.org", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	return &option.InboundTLSOptions{
+		Enabled:     true,
+		ServerName:  "example.org",
+		Certificate: []string{string(certificatePEM)},
+		Key:         []string{string(keyPEM)},
+	}
+}
+
+func listenLoopbackUDP(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestMultiPortPacketConn(t *testing.T) {
+	t.Parallel()
+	serverConns := []net.PacketConn{listenLoopbackUDP(t), listenLoopbackUDP(t), listenLoopbackUDP(t)}
+	conn := newMultiPortPacketConn(serverConns)
+	defer conn.Close()
+	client := listenLoopbackUDP(t)
+	buffer := make([]byte, 64)
+	for _, serverConn := range []net.PacketConn{serverConns[1], serverConns[2], serverConns[0], serverConns[1]} {
+		_, err := client.WriteTo([]byte("ping"), serverConn.LocalAddr())
+		require.NoError(t, err)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		n, addr, err := conn.ReadFrom(buffer)
+		require.NoError(t, err)
+		require.Equal(t, "ping", string(buffer[:n]))
+		require.Equal(t, client.LocalAddr().String(), addr.String())
+		_, err = conn.WriteTo([]byte("pong"), addr)
+		require.NoError(t, err)
+		require.NoError(t, client.SetReadDeadline(time.Now().Add(5*time.Second)))
+		n, addr, err = client.ReadFrom(buffer)
+		require.NoError(t, err)
+		require.Equal(t, "pong", string(buffer[:n]))
+		require.Equal(t, serverConn.LocalAddr().String(), addr.String(), "reply must be sent from the port the client last sent to")
+	}
+	require.NoError(t, conn.Close())
+	_, _, err := conn.ReadFrom(buffer)
+	require.ErrorIs(t, err, net.ErrClosed)
+}
+
+func TestInboundListenPortsLimit(t *testing.T) {
+	t.Parallel()
+	newInbound := func(listenPorts ...string) error {
+		_, err := NewInbound(context.Background(), nil, log.NewNOPFactory().Logger(), "hy2-in", option.Hysteria2InboundOptions{
+			ListenOptions: option.ListenOptions{
+				Listen:     common.Ptr(badoption.Addr(netip.AddrFrom4([4]byte{127, 0, 0, 1}))),
+				ListenPort: 20000,
+			},
+			ListenPorts: listenPorts,
+			InboundTLSOptionsContainer: option.InboundTLSOptionsContainer{
+				TLS: newTestTLSOptions(t),
+			},
+		})
+		return err
+	}
+	require.NoError(t, newInbound("20001:20128"))
+	require.ErrorContains(t, newInbound("20001:20129"), "too many listen_ports")
+	require.ErrorContains(t, newInbound("20000:30000"), "too many listen_ports")
+}
+
+type contextPacketConn struct {
+	net.PacketConn
+	ctx context.Context
+}
+
+func (c *contextPacketConn) Context() context.Context {
+	return c.ctx
+}
+
+func TestRateLimitedConnWrite(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	conn := &rateLimitedConn{
+		Conn:        serverConn,
+		ctx:         context.Background(),
+		sendLimiter: rate.NewLimiter(rate.Limit(1000), 1000),
+	}
+	go io.Copy(io.Discard, clientConn)
+	startAt := time.Now()
+	for range 3 {
+		_, err := conn.Write(make([]byte, 500))
+		require.NoError(t, err)
+	}
+	require.GreaterOrEqual(t, time.Since(startAt), 400*time.Millisecond, "writes beyond the burst must wait for the send rate")
+}
+
+func TestUserBandwidthTracking(t *testing.T) {
+	t.Parallel()
+	tlsConfig, err := tls.NewServer(context.Background(), log.NewNOPFactory().Logger(), *newTestTLSOptions(t))
+	require.NoError(t, err)
+	require.NoError(t, tlsConfig.Start())
+	defer tlsConfig.Close()
+	inbound := &Inbound{
+		logger:      log.NewNOPFactory().Logger(),
+		connTracker: newConnTracker(),
+		userBandwidthList: []userBandwidth{
+			{},
+			{sendBPS: 1 << 20, receiveBPS: 1 << 20},
+			{receiveBPS: 2 << 20},
+		},
+	}
+	serviceTLSConfig := &bandwidthTLSConfig{ServerConfig: tlsConfig, tracker: inbound.connTracker}
+	serviceTLSConfig.ConfigureHTTP3()
+	listener, err := serviceTLSConfig.Listen(listenLoopbackUDP(t), &quic.Config{})
+	require.NoError(t, err)
+	defer listener.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	dial := func() (client *quic.Conn, server *quic.Conn, stream *quic.Stream) {
+		client, err := quic.DialAddr(ctx, listener.Addr().String(), &stdTLS.Config{
+			InsecureSkipVerify: true,
+			NextProtos:         []string{"h3"},
+		}, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { client.CloseWithError(0, "") })
+		server, err = listener.Accept(ctx)
+		require.NoError(t, err)
+		clientStream, err := client.OpenStreamSync(ctx)
+		require.NoError(t, err)
+		_, err = clientStream.Write([]byte("request"))
+		require.NoError(t, err)
+		stream, err = server.AcceptStream(ctx)
+		require.NoError(t, err)
+		return
+	}
+	firstClient, firstServer, firstStream := dial()
+	_, secondServer, secondStream := dial()
+
+	// streams are matched by the connection carried in their context, regardless of the reported source
+	unrelatedSource := M.ParseSocksaddr("192.0.2.1:443")
+	require.Same(t, firstServer, inbound.connTracker.lookup(firstStream, unrelatedSource).conn)
+	require.Same(t, secondServer, inbound.connTracker.lookup(secondStream, unrelatedSource).conn)
+
+	sendLimiter, receiveLimiter := inbound.applyUserBandwidth(ctx, firstStream, unrelatedSource, 1)
+	require.NotNil(t, sendLimiter)
+	require.NotNil(t, receiveLimiter)
+	require.Equal(t, float64(1<<20), float64(sendLimiter.Limit()))
+	require.Equal(t, float64(1<<20), float64(receiveLimiter.Limit()))
+	sameSendLimiter, sameReceiveLimiter := inbound.applyUserBandwidth(ctx, firstStream, unrelatedSource, 1)
+	require.Same(t, sendLimiter, sameSendLimiter, "bandwidth must be applied once per connection")
+	require.Same(t, receiveLimiter, sameReceiveLimiter)
+	noSendLimiter, noReceiveLimiter := inbound.applyUserBandwidth(ctx, secondStream, unrelatedSource, 0)
+	require.Nil(t, noSendLimiter)
+	require.Nil(t, noReceiveLimiter)
+	secondSendLimiter, secondReceiveLimiter := inbound.applyUserBandwidth(ctx, secondStream, unrelatedSource, 2)
+	require.Nil(t, secondSendLimiter, "the congestion control negotiated with the client is kept without a send rate")
+	require.NotNil(t, secondReceiveLimiter)
+	require.NotSame(t, receiveLimiter, secondReceiveLimiter)
+	require.Equal(t, float64(2<<20), float64(secondReceiveLimiter.Limit()))
+
+	// UDP sessions are only reported with the remote address of their connection
+	packetConn := &contextPacketConn{ctx: context.Background()}
+	packetSendLimiter, _ := inbound.applyUserBandwidth(ctx, packetConn, M.SocksaddrFromNet(firstServer.RemoteAddr()), 1)
+	require.Same(t, sendLimiter, packetSendLimiter)
+	require.Nil(t, inbound.connTracker.lookup(packetConn, unrelatedSource))
+
+	firstClient.CloseWithError(0, "")
+	require.Eventually(t, func() bool {
+		return inbound.connTracker.lookup(packetConn, M.SocksaddrFromNet(firstServer.RemoteAddr())) == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
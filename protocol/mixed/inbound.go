@@ -8,15 +8,18 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/httprewrite"
 	"github.com/sagernet/sing-box/common/listener"
 	"github.com/sagernet/sing-box/common/tls"
 	"github.com/sagernet/sing-box/common/uot"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/v2ray"
 	"github.com/sagernet/sing/common"
 	"github.com/sagernet/sing/common/auth"
 	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
 	"github.com/sagernet/sing/protocol/http"
 	"github.com/sagernet/sing/protocol/socks"
@@ -38,6 +41,8 @@ type Inbound struct {
 	authenticator *auth.Authenticator
 	tlsConfig     tls.ServerConfig
 	udpTimeout    time.Duration
+	transport     adapter.V2RayServerTransport
+	rewriter      *httprewrite.Rewriter
 }
 
 func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.HTTPMixedInboundOptions) (adapter.Inbound, error) {
@@ -54,6 +59,13 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		authenticator: auth.NewAuthenticator(options.Users),
 		udpTimeout:    udpTimeout,
 	}
+	if len(options.HTTPRewrite) > 0 {
+		rewriter, err := httprewrite.New(options.HTTPRewrite)
+		if err != nil {
+			return nil, err
+		}
+		inbound.rewriter = rewriter
+	}
 	if options.TLS != nil {
 		tlsConfig, err := tls.NewServerWithOptions(tls.ServerOptions{
 			Context:        ctx,
@@ -66,6 +78,13 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		}
 		inbound.tlsConfig = tlsConfig
 	}
+	if options.Transport != nil {
+		var err error
+		inbound.transport, err = v2ray.NewServerTransport(ctx, logger, common.PtrValueOrDefault(options.Transport), inbound.tlsConfig, (*inboundTransportHandler)(inbound))
+		if err != nil {
+			return nil, E.Cause(err, "create server transport: ", options.Transport.Type)
+		}
+	}
 	inbound.listener = listener.New(listener.Options{
 		Context:           ctx,
 		Logger:            logger,
@@ -88,13 +107,41 @@ func (h *Inbound) Start(stage adapter.StartStage) error {
 			return E.Cause(err, "create TLS config")
 		}
 	}
-	return h.listener.Start()
+	if h.transport == nil {
+		return h.listener.Start()
+	}
+	if common.Contains(h.transport.Network(), N.NetworkTCP) {
+		tcpListener, err := h.listener.ListenTCP()
+		if err != nil {
+			return err
+		}
+		go func() {
+			sErr := h.transport.Serve(tcpListener)
+			if sErr != nil && !E.IsClosed(sErr) {
+				h.logger.Error("transport serve error: ", sErr)
+			}
+		}()
+	}
+	if common.Contains(h.transport.Network(), N.NetworkUDP) {
+		udpConn, err := h.listener.ListenUDP()
+		if err != nil {
+			return err
+		}
+		go func() {
+			sErr := h.transport.ServePacket(udpConn)
+			if sErr != nil && !E.IsClosed(sErr) {
+				h.logger.Error("transport serve error: ", sErr)
+			}
+		}()
+	}
+	return nil
 }
 
 func (h *Inbound) Close() error {
 	return common.Close(
 		h.listener,
 		h.tlsConfig,
+		h.transport,
 	)
 }
 
@@ -111,7 +158,7 @@ func (h *Inbound) NewConnectionEx(ctx context.Context, conn net.Conn, metadata a
 }
 
 func (h *Inbound) newConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) error {
-	if h.tlsConfig != nil {
+	if h.tlsConfig != nil && h.transport == nil {
 		tlsConn, err := tls.ServerHandshake(ctx, conn, h.tlsConfig)
 		if err != nil {
 			return E.Cause(err, "TLS handshake")
@@ -125,12 +172,35 @@ func (h *Inbound) newConnection(ctx context.Context, conn net.Conn, metadata ada
 	}
 	switch headerBytes[0] {
 	case socks4.Version, socks5.Version:
-		return socks.HandleConnectionEx(ctx, conn, reader, h.authenticator, adapter.NewUpstreamHandlerEx(metadata, h.newUserConnection, h.streamUserPacketConnection), h.listener, h.udpTimeout, metadata.Source, onClose)
+		return socks.HandleConnectionEx(ctx, conn, reader, h.authenticator, adapter.NewUpstreamHandlerEx(metadata, h.newUserConnection, h.streamUserPacketConnection), h.packetListener(), h.udpTimeout, metadata.Source, onClose)
 	default:
-		return http.HandleConnectionEx(ctx, conn, reader, h.authenticator, adapter.NewUpstreamHandlerEx(metadata, h.newUserConnection, h.streamUserPacketConnection), metadata.Source, onClose)
+		return http.HandleConnectionEx(ctx, conn, reader, h.authenticator, adapter.NewUpstreamHandlerEx(metadata, h.newHTTPUserConnection(conn), h.streamUserPacketConnection), metadata.Source, onClose)
 	}
 }
 
+// newHTTPUserConnection rewrites plaintext requests forwarded by the HTTP proxy,
+// while CONNECT tunnels are routed untouched.
+func (h *Inbound) newHTTPUserConnection(clientConn net.Conn) adapter.ConnectionHandlerFuncEx {
+	if h.rewriter == nil {
+		return h.newUserConnection
+	}
+	return func(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+		if httprewrite.IsPlaintextRequestConn(conn, clientConn) {
+			conn = h.rewriter.NewConn(ctx, conn, "http", h.logger)
+		}
+		h.newUserConnection(ctx, conn, metadata, onClose)
+	}
+}
+
+// packetListener returns the listener for SOCKS5 UDP ASSOCIATE, which is rejected
+// when a transport is enabled, since clients can not reach the UDP relay through it.
+func (h *Inbound) packetListener() socks.PacketListener {
+	if h.transport != nil {
+		return listener.DisabledPacketListener{}
+	}
+	return h.listener
+}
+
 func (h *Inbound) newUserConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
 	metadata.Inbound = h.Tag()
 	metadata.InboundType = h.Type()
@@ -166,3 +236,18 @@ func (h *Inbound) streamUserPacketConnection(ctx context.Context, conn N.PacketC
 	}
 	h.router.RoutePacketConnectionEx(ctx, conn, metadata, onClose)
 }
+
+var _ adapter.V2RayServerTransportHandler = (*inboundTransportHandler)(nil)
+
+type inboundTransportHandler Inbound
+
+func (h *inboundTransportHandler) NewConnectionEx(ctx context.Context, conn net.Conn, source M.Socksaddr, destination M.Socksaddr, onClose N.CloseHandlerFunc) {
+	var metadata adapter.InboundContext
+	metadata.Source = source
+	metadata.Destination = destination
+	//nolint:staticcheck
+	metadata.InboundDetour = h.listener.ListenOptions().Detour
+	//nolint:staticcheck
+	h.logger.InfoContext(ctx, "inbound connection from ", metadata.Source)
+	(*Inbound)(h).NewConnectionEx(ctx, conn, metadata, onClose)
+}
@@ -34,8 +34,12 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		return nil, E.New("users and destinations options must not be combined")
 	} else if options.Managed && (len(options.Users) > 0 || len(options.Destinations) > 0) {
 		return nil, E.New("users and destinations options are not supported in managed servers")
+	} else if options.Relay && !options.Managed {
+		return nil, E.New("relay option is only supported in managed servers")
 	}
-	if len(options.Users) > 0 || options.Managed {
+	if options.Managed && options.Relay {
+		return newRelayInbound(ctx, router, logger, tag, options)
+	} else if len(options.Users) > 0 || options.Managed {
 		return newMultiInbound(ctx, router, logger, tag, options)
 	} else if len(options.Destinations) > 0 {
 		return newRelayInbound(ctx, router, logger, tag, options)
@@ -25,7 +25,10 @@ import (
 	N "github.com/sagernet/sing/common/network"
 )
 
-var _ adapter.TCPInjectableInbound = (*RelayInbound)(nil)
+var (
+	_ adapter.TCPInjectableInbound  = (*RelayInbound)(nil)
+	_ adapter.ManagedSSMRelayServer = (*RelayInbound)(nil)
+)
 
 type RelayInbound struct {
 	inbound.Adapter
@@ -35,6 +38,7 @@ type RelayInbound struct {
 	listener     *listener.Listener
 	service      *shadowaead_2022.RelayService[int]
 	destinations []option.ShadowsocksDestination
+	tracker      adapter.SSMTracker
 }
 
 func newRelayInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.ShadowsocksInboundOptions) (*RelayInbound, error) {
@@ -97,6 +101,29 @@ func (h *RelayInbound) Close() error {
 	return h.listener.Close()
 }
 
+func (h *RelayInbound) SetTracker(tracker adapter.SSMTracker) {
+	h.tracker = tracker
+}
+
+func (h *RelayInbound) UpdateUsers(users []string, uPSKs []string) error {
+	return E.New("missing destinations for relay server")
+}
+
+func (h *RelayInbound) UpdateDestinations(names []string, uPSKs []string, destinations []M.Socksaddr) error {
+	err := h.service.UpdateUsersWithPasswords(common.MapIndexed(names, func(index int, name string) int {
+		return index
+	}), uPSKs, destinations)
+	if err != nil {
+		return err
+	}
+	h.destinations = common.Map(names, func(name string) option.ShadowsocksDestination {
+		return option.ShadowsocksDestination{
+			Name: name,
+		}
+	})
+	return nil
+}
+
 //nolint:staticcheck
 func (h *RelayInbound) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
 	err := h.service.NewConnection(ctx, conn, adapter.UpstreamMetadata(metadata))
@@ -134,6 +161,9 @@ func (h *RelayInbound) newConnection(ctx context.Context, conn net.Conn, metadat
 	metadata.InboundType = h.Type()
 	//nolint:staticcheck
 	metadata.InboundDetour = h.listener.ListenOptions().Detour
+	if h.tracker != nil {
+		conn = h.tracker.TrackConnection(conn, metadata)
+	}
 	//nolint:staticcheck
 	return h.router.RouteConnection(ctx, conn, metadata)
 }
@@ -156,6 +186,9 @@ func (h *RelayInbound) newPacketConnection(ctx context.Context, conn N.PacketCon
 	metadata.InboundType = h.Type()
 	//nolint:staticcheck
 	metadata.InboundDetour = h.listener.ListenOptions().Detour
+	if h.tracker != nil {
+		conn = h.tracker.TrackPacketConnection(conn, metadata)
+	}
 	//nolint:staticcheck
 	return h.router.RoutePacketConnection(ctx, conn, metadata)
 }
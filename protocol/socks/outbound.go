@@ -10,6 +10,7 @@ import (
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/v2ray"
 	"github.com/sagernet/sing/common"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/logger"
@@ -33,6 +34,7 @@ type Outbound struct {
 	client    *socks.Client
 	resolve   bool
 	uotClient *uot.Client
+	transport adapter.V2RayClientTransport
 }
 
 func NewOutbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.SOCKSOutboundOptions) (adapter.Outbound, error) {
@@ -50,14 +52,30 @@ func NewOutbound(ctx context.Context, router adapter.Router, logger log.ContextL
 	if err != nil {
 		return nil, err
 	}
+	uotOptions := common.PtrValueOrDefault(options.UDPOverTCP)
+	networkList := options.Network.Build()
+	var transport adapter.V2RayClientTransport
+	if options.Transport != nil {
+		transport, err = v2ray.NewClientTransport(ctx, outboundDialer, options.ServerOptions.Build(), common.PtrValueOrDefault(options.Transport), nil)
+		if err != nil {
+			return nil, E.Cause(err, "create client transport: ", options.Transport.Type)
+		}
+		outboundDialer = v2ray.NewTransportDialer(transport)
+		if !uotOptions.Enabled {
+			// UDP ASSOCIATE requires a separate UDP socket to the server
+			networkList = common.Filter(networkList, func(it string) bool {
+				return it != N.NetworkUDP
+			})
+		}
+	}
 	outbound := &Outbound{
-		Adapter:   outbound.NewAdapterWithDialerOptions(C.TypeSOCKS, tag, options.Network.Build(), options.DialerOptions),
+		Adapter:   outbound.NewAdapterWithDialerOptions(C.TypeSOCKS, tag, networkList, options.DialerOptions),
 		dnsRouter: service.FromContext[adapter.DNSRouter](ctx),
 		logger:    logger,
 		client:    socks.NewClient(outboundDialer, options.ServerOptions.Build(), version, options.Username, options.Password),
 		resolve:   version == socks.Version4,
+		transport: transport,
 	}
-	uotOptions := common.PtrValueOrDefault(options.UDPOverTCP)
 	if uotOptions.Enabled {
 		outbound.uotClient = &uot.Client{
 			Dialer:  outbound.client,
@@ -115,3 +133,13 @@ func (h *Outbound) ListenPacket(ctx context.Context, destination M.Socksaddr) (n
 	h.logger.InfoContext(ctx, "outbound packet connection to ", destination)
 	return h.client.ListenPacket(ctx, destination)
 }
+
+func (h *Outbound) InterfaceUpdated() {
+	if h.transport != nil {
+		h.transport.Close()
+	}
+}
+
+func (h *Outbound) Close() error {
+	return common.Close(h.transport)
+}
@@ -0,0 +1,277 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/listener"
+	"github.com/sagernet/sing-box/common/uot"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func RegisterInbound(registry *inbound.Registry) {
+	inbound.Register[option.SSHInboundOptions](registry, C.TypeSSH, NewInbound)
+}
+
+var _ adapter.TCPInjectableInbound = (*Inbound)(nil)
+
+type Inbound struct {
+	inbound.Adapter
+	router   adapter.ConnectionRouterEx
+	logger   logger.ContextLogger
+	listener *listener.Listener
+	users    map[string]*inboundUser
+	config   *ssh.ServerConfig
+}
+
+type inboundUser struct {
+	passwords  []string
+	publicKeys []ssh.PublicKey
+}
+
+func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.SSHInboundOptions) (adapter.Inbound, error) {
+	inbound := &Inbound{
+		Adapter: inbound.NewAdapter(C.TypeSSH, tag),
+		router:  uot.NewRouter(router, logger),
+		logger:  logger,
+		users:   make(map[string]*inboundUser),
+	}
+	if len(options.Users) == 0 {
+		return nil, E.New("missing users")
+	}
+	for index, user := range options.Users {
+		if user.Name == "" {
+			return nil, E.New("missing name for user[", index, "]")
+		}
+		if user.Password == "" && len(user.PublicKey) == 0 {
+			return nil, E.New("missing password or public key for user[", index, "]")
+		}
+		userEntry := inbound.users[user.Name]
+		if userEntry == nil {
+			userEntry = &inboundUser{}
+			inbound.users[user.Name] = userEntry
+		}
+		if user.Password != "" {
+			userEntry.passwords = append(userEntry.passwords, user.Password)
+		}
+		for _, publicKey := range user.PublicKey {
+			key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+			if err != nil {
+				return nil, E.Cause(err, "parse public key for user[", index, "]")
+			}
+			userEntry.publicKeys = append(userEntry.publicKeys, key)
+		}
+	}
+	hostKey, err := newHostKey(options)
+	if err != nil {
+		return nil, err
+	}
+	serverVersion := options.ServerVersion
+	if serverVersion == "" {
+		serverVersion = randomVersion()
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback:  inbound.passwordCallback,
+		PublicKeyCallback: inbound.publicKeyCallback,
+		ServerVersion:     serverVersion,
+	}
+	config.AddHostKey(hostKey)
+	inbound.config = config
+	inbound.listener = listener.New(listener.Options{
+		Context:           ctx,
+		Logger:            logger,
+		Network:           []string{N.NetworkTCP},
+		Listen:            options.ListenOptions,
+		ConnectionHandler: inbound,
+	})
+	return inbound, nil
+}
+
+func newHostKey(options option.SSHInboundOptions) (ssh.Signer, error) {
+	var hostKey []byte
+	if len(options.HostKey) > 0 {
+		hostKey = []byte(strings.Join(options.HostKey, "\n"))
+	} else if options.HostKeyPath != "" {
+		var err error
+		hostKey, err = os.ReadFile(os.ExpandEnv(options.HostKeyPath))
+		if err != nil {
+			return nil, E.Cause(err, "read host key")
+		}
+	} else {
+		return nil, E.New("missing host key")
+	}
+	var (
+		signer ssh.Signer
+		err    error
+	)
+	if options.HostKeyPassphrase == "" {
+		signer, err = ssh.ParsePrivateKey(hostKey)
+	} else {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(hostKey, []byte(options.HostKeyPassphrase))
+	}
+	if err != nil {
+		return nil, E.Cause(err, "parse host key")
+	}
+	if len(options.HostKeyAlgorithms) > 0 {
+		algorithmSigner, isAlgorithmSigner := signer.(ssh.AlgorithmSigner)
+		if !isAlgorithmSigner {
+			return nil, E.New("host key does not support host_key_algorithms")
+		}
+		signer, err = ssh.NewSignerWithAlgorithms(algorithmSigner, options.HostKeyAlgorithms)
+		if err != nil {
+			return nil, E.Cause(err, "host_key_algorithms")
+		}
+	}
+	return signer, nil
+}
+
+func (h *Inbound) passwordCallback(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+	user := h.users[conn.User()]
+	if user != nil {
+		for _, userPassword := range user.passwords {
+			if subtle.ConstantTimeCompare([]byte(userPassword), password) == 1 {
+				return nil, nil
+			}
+		}
+	}
+	return nil, E.New("password rejected for ", conn.User())
+}
+
+func (h *Inbound) publicKeyCallback(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	user := h.users[conn.User()]
+	if user != nil {
+		keyBytes := key.Marshal()
+		for _, publicKey := range user.publicKeys {
+			if bytes.Equal(keyBytes, publicKey.Marshal()) {
+				return nil, nil
+			}
+		}
+	}
+	return nil, E.New("public key rejected for ", conn.User())
+}
+
+func (h *Inbound) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateStart {
+		return nil
+	}
+	return h.listener.Start()
+}
+
+func (h *Inbound) Close() error {
+	return h.listener.Close()
+}
+
+func (h *Inbound) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	err := conn.SetDeadline(time.Now().Add(C.TCPTimeout))
+	if err != nil {
+		N.CloseOnHandshakeFailure(conn, onClose, err)
+		h.logger.ErrorContext(ctx, E.Cause(err, "set handshake deadline"))
+		return
+	}
+	serverConn, channels, requests, err := ssh.NewServerConn(conn, h.config)
+	if err == nil {
+		err = conn.SetDeadline(time.Time{})
+	}
+	N.CloseOnHandshakeFailure(conn, onClose, err)
+	if err != nil {
+		if E.IsClosedOrCanceled(err) {
+			h.logger.DebugContext(ctx, "connection closed: ", err)
+		} else {
+			h.logger.ErrorContext(ctx, E.Cause(err, "process connection from ", metadata.Source))
+		}
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type: "+newChannel.ChannelType())
+			continue
+		}
+		// RFC 4254 7.2
+		var request struct {
+			DestinationAddress string
+			DestinationPort    uint32
+			OriginAddress      string
+			OriginPort         uint32
+		}
+		err = ssh.Unmarshal(newChannel.ExtraData(), &request)
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, "invalid direct-tcpip request")
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			h.logger.ErrorContext(ctx, E.Cause(err, "accept channel"))
+			continue
+		}
+		go ssh.DiscardRequests(channelRequests)
+		channelMetadata := metadata
+		channelMetadata.Destination = M.ParseSocksaddrHostPort(request.DestinationAddress, uint16(request.DestinationPort))
+		go h.newChannelConnection(log.ContextWithNewID(ctx), serverConn, &channelConn{
+			Channel:    channel,
+			localAddr:  conn.LocalAddr(),
+			remoteAddr: conn.RemoteAddr(),
+		}, channelMetadata)
+	}
+	serverConn.Close()
+	onClose(nil)
+}
+
+func (h *Inbound) newChannelConnection(ctx context.Context, serverConn *ssh.ServerConn, conn net.Conn, metadata adapter.InboundContext) {
+	metadata.Inbound = h.Tag()
+	metadata.InboundType = h.Type()
+	metadata.User = serverConn.User()
+	h.logger.InfoContext(ctx, "[", metadata.User, "] inbound connection to ", metadata.Destination)
+	h.router.RouteConnectionEx(ctx, conn, metadata, N.OnceClose(func(it error) {
+		conn.Close()
+	}))
+}
+
+type channelConn struct {
+	ssh.Channel
+	localAddr  net.Addr
+	remoteAddr net.Addr
+}
+
+func (c *channelConn) LocalAddr() net.Addr {
+	return c.localAddr
+}
+
+func (c *channelConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+func (c *channelConn) SetDeadline(t time.Time) error {
+	return os.ErrInvalid
+}
+
+func (c *channelConn) SetReadDeadline(t time.Time) error {
+	return os.ErrInvalid
+}
+
+func (c *channelConn) SetWriteDeadline(t time.Time) error {
+	return os.ErrInvalid
+}
+
+func (c *channelConn) NeedAdditionalReadDeadline() bool {
+	return true
+}
+
+func (c *channelConn) Upstream() any {
+	return c.Channel
+}
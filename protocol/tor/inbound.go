@@ -0,0 +1,215 @@
+package tor
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/dialer"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/rw"
+
+	"github.com/cretz/bine/control"
+)
+
+const onionKeyFileName = "onion_service_key"
+
+func RegisterInbound(registry *inbound.Registry) {
+	inbound.Register[option.TorInboundOptions](registry, C.TypeTor, NewInbound)
+}
+
+type Inbound struct {
+	inbound.Adapter
+	ctx                 context.Context
+	router              adapter.ConnectionRouterEx
+	logger              log.ContextLogger
+	instance            *instance
+	privateKey          control.Key
+	ports               []uint16
+	listeners           []net.Listener
+	overrideOption      int
+	overrideDestination M.Socksaddr
+}
+
+func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.TorInboundOptions) (adapter.Inbound, error) {
+	inbound := &Inbound{
+		Adapter: inbound.NewAdapter(C.TypeTor, tag),
+		ctx:     ctx,
+		router:  router,
+		logger:  logger,
+		ports:   options.Ports,
+	}
+	if len(inbound.ports) == 0 {
+		inbound.ports = []uint16{80}
+	}
+	if options.PrivateKey != "" {
+		privateKey, err := parseOnionKey(options.PrivateKey)
+		if err != nil {
+			return nil, E.Cause(err, "parse private_key")
+		}
+		inbound.privateKey = privateKey
+	}
+	if options.OverrideAddress != "" && options.OverridePort != 0 {
+		inbound.overrideOption = 1
+		inbound.overrideDestination = M.ParseSocksaddrHostPort(options.OverrideAddress, options.OverridePort)
+	} else if options.OverrideAddress != "" {
+		inbound.overrideOption = 2
+		inbound.overrideDestination = M.ParseSocksaddrHostPort(options.OverrideAddress, options.OverridePort)
+	} else if options.OverridePort != 0 {
+		inbound.overrideOption = 3
+		inbound.overrideDestination = M.Socksaddr{Port: options.OverridePort}
+	}
+	inboundDialer, err := dialer.New(ctx, options.DialerOptions, false)
+	if err != nil {
+		return nil, err
+	}
+	inbound.instance, err = newInstance(ctx, logger, inboundDialer, options.TorOptions)
+	if err != nil {
+		return nil, err
+	}
+	return inbound, nil
+}
+
+func parseOnionKey(content string) (control.Key, error) {
+	privateKey, err := control.KeyFromString(strings.TrimSpace(content))
+	if err != nil {
+		return nil, err
+	}
+	if privateKey.Type() != control.KeyTypeED25519V3 {
+		return nil, E.New("unsupported key type: ", string(privateKey.Type()))
+	}
+	return privateKey, nil
+}
+
+func (h *Inbound) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateStart {
+		return nil
+	}
+	err := h.start()
+	if err != nil {
+		h.Close()
+	}
+	return err
+}
+
+func (h *Inbound) start() error {
+	privateKey, keyFile, err := h.loadPrivateKey()
+	if err != nil {
+		return err
+	}
+	err = h.instance.Start()
+	if err != nil {
+		return err
+	}
+	var ports []*control.KeyVal
+	for _, port := range h.ports {
+		tcpListener, err := net.ListenTCP("tcp", &net.TCPAddr{
+			IP: net.IPv4(127, 0, 0, 1),
+		})
+		if err != nil {
+			return err
+		}
+		h.listeners = append(h.listeners, tcpListener)
+		ports = append(ports, control.NewKeyVal(F.ToString(port), tcpListener.Addr().String()))
+	}
+	response, err := h.instance.Control().AddOnion(&control.AddOnionRequest{
+		Key:   privateKey,
+		Ports: ports,
+	})
+	if err != nil {
+		return E.Cause(err, "create onion service")
+	}
+	if keyFile != "" && response.Key != nil {
+		err = os.WriteFile(keyFile, []byte(string(response.Key.Type())+":"+response.Key.Blob()), 0o600)
+		if err != nil {
+			return E.Cause(err, "save onion service key")
+		}
+	}
+	onionAddress := response.ServiceID + ".onion"
+	h.logger.Info("onion service published at ", onionAddress)
+	for index, tcpListener := range h.listeners {
+		go h.acceptLoop(tcpListener, M.ParseSocksaddrHostPort(onionAddress, h.ports[index]))
+	}
+	return nil
+}
+
+// loadPrivateKey returns the configured key, or the key saved in the data directory,
+// and the file to save the generated key to.
+func (h *Inbound) loadPrivateKey() (control.Key, string, error) {
+	if h.privateKey != nil {
+		return h.privateKey, "", nil
+	}
+	generateKey := control.GenKey(control.KeyAlgoED25519V3)
+	dataDirectory := h.instance.DataDirectory()
+	if dataDirectory == "" {
+		h.logger.Warn("private_key and data_directory not set, the onion address will change on every start")
+		return generateKey, "", nil
+	}
+	keyFile := filepath.Join(dataDirectory, onionKeyFileName)
+	if !rw.IsFile(keyFile) {
+		return generateKey, keyFile, nil
+	}
+	content, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, "", E.Cause(err, "read onion service key")
+	}
+	privateKey, err := parseOnionKey(string(content))
+	if err != nil {
+		return nil, "", E.Cause(err, "parse onion service key")
+	}
+	return privateKey, "", nil
+}
+
+func (h *Inbound) acceptLoop(tcpListener net.Listener, destination M.Socksaddr) {
+	for {
+		conn, err := tcpListener.Accept()
+		if err != nil {
+			if !E.IsClosed(err) {
+				h.logger.Error(E.Cause(err, "accept onion service connection"))
+			}
+			return
+		}
+		go h.newConnection(log.ContextWithNewID(h.ctx), conn, destination)
+	}
+}
+
+func (h *Inbound) newConnection(ctx context.Context, conn net.Conn, destination M.Socksaddr) {
+	var metadata adapter.InboundContext
+	metadata.Inbound = h.Tag()
+	metadata.InboundType = h.Type()
+	metadata.Source = M.SocksaddrFromNet(conn.RemoteAddr()).Unwrap()
+	switch h.overrideOption {
+	case 1:
+		destination = h.overrideDestination
+	case 2:
+		destination.Addr = h.overrideDestination.Addr
+		destination.Fqdn = h.overrideDestination.Fqdn
+	case 3:
+		destination.Port = h.overrideDestination.Port
+	}
+	metadata.Destination = destination
+	h.logger.InfoContext(ctx, "inbound connection to ", metadata.Destination)
+	h.router.RouteConnectionEx(ctx, conn, metadata, N.OnceClose(func(it error) {
+		conn.Close()
+	}))
+}
+
+func (h *Inbound) Close() error {
+	var errs []error
+	for _, tcpListener := range h.listeners {
+		errs = append(errs, tcpListener.Close())
+	}
+	h.listeners = nil
+	errs = append(errs, h.instance.Close())
+	return E.Errors(errs...)
+}
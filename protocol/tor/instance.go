@@ -0,0 +1,215 @@
+package tor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/logger"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/rw"
+
+	"github.com/cretz/bine/control"
+	"github.com/cretz/bine/tor"
+)
+
+var torLogEvents = []control.EventCode{
+	control.EventCodeLogDebug,
+	control.EventCodeLogErr,
+	control.EventCodeLogInfo,
+	control.EventCodeLogNotice,
+	control.EventCodeLogWarn,
+}
+
+// instance manages the Tor process started by a tor inbound or outbound.
+type instance struct {
+	ctx       context.Context
+	logger    logger.ContextLogger
+	proxy     *ProxyListener
+	startConf *tor.StartConf
+	options   []*control.KeyVal
+	events    chan control.Event
+	tor       *tor.Tor
+}
+
+func newInstance(ctx context.Context, logger logger.ContextLogger, dialer N.Dialer, options option.TorOptions) (*instance, error) {
+	var startConf tor.StartConf
+	startConf.DataDir = os.ExpandEnv(options.DataDirectory)
+	startConf.TempDataDirBase = os.TempDir()
+	startConf.ExtraArgs = options.ExtraArgs
+	if options.DataDirectory != "" {
+		dataDirAbs, _ := filepath.Abs(startConf.DataDir)
+		if geoIPPath := filepath.Join(dataDirAbs, "geoip"); rw.IsFile(geoIPPath) && !common.Contains(options.ExtraArgs, "--GeoIPFile") {
+			options.ExtraArgs = append(options.ExtraArgs, "--GeoIPFile", geoIPPath)
+		}
+		if geoIP6Path := filepath.Join(dataDirAbs, "geoip6"); rw.IsFile(geoIP6Path) && !common.Contains(options.ExtraArgs, "--GeoIPv6File") {
+			options.ExtraArgs = append(options.ExtraArgs, "--GeoIPv6File", geoIP6Path)
+		}
+	}
+	if options.ExecutablePath != "" {
+		startConf.ExePath = options.ExecutablePath
+		startConf.ProcessCreator = nil
+		startConf.UseEmbeddedControlConn = false
+	}
+	if startConf.DataDir != "" {
+		torrcFile := filepath.Join(startConf.DataDir, "torrc")
+		err := rw.MkdirParent(torrcFile)
+		if err != nil {
+			return nil, err
+		}
+		if !rw.IsFile(torrcFile) {
+			err := os.WriteFile(torrcFile, []byte(""), 0o600)
+			if err != nil {
+				return nil, err
+			}
+		}
+		startConf.TorrcFile = torrcFile
+	}
+	confOptions, err := newConfOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	return &instance{
+		ctx:       ctx,
+		logger:    logger,
+		proxy:     NewProxyListener(ctx, logger, dialer),
+		startConf: &startConf,
+		options:   confOptions,
+	}, nil
+}
+
+func newConfOptions(options option.TorOptions) ([]*control.KeyVal, error) {
+	var confOptions []*control.KeyVal
+	if len(options.Bridges) > 0 {
+		confOptions = append(confOptions, control.NewKeyVal("UseBridges", "1"))
+		for _, bridge := range options.Bridges {
+			confOptions = append(confOptions, control.NewKeyVal("Bridge", bridge))
+		}
+	}
+	for index, transport := range options.PluggableTransports {
+		if len(transport.Transports) == 0 {
+			return nil, E.New("missing transports for pluggable_transports[", index, "]")
+		}
+		if transport.ExecutablePath == "" {
+			return nil, E.New("missing executable_path for pluggable_transports[", index, "]")
+		}
+		pluginLine := strings.Join(transport.Transports, ",") + " exec " + os.ExpandEnv(transport.ExecutablePath)
+		if len(transport.ExtraArgs) > 0 {
+			pluginLine += " " + strings.Join(transport.ExtraArgs, " ")
+		}
+		confOptions = append(confOptions, control.NewKeyVal("ClientTransportPlugin", pluginLine))
+	}
+	for key, value := range options.Options {
+		switch key {
+		case "Socks5Proxy",
+			"Socks5ProxyUsername",
+			"Socks5ProxyPassword":
+			continue
+		case "UseBridges",
+			"Bridge",
+			"ClientTransportPlugin":
+			if len(options.Bridges) > 0 || len(options.PluggableTransports) > 0 {
+				return nil, E.New("torrc option ", key, " conflicts with bridges and pluggable_transports")
+			}
+		}
+		confOptions = append(confOptions, control.NewKeyVal(key, value))
+	}
+	return confOptions, nil
+}
+
+func (i *instance) Start() error {
+	err := i.start()
+	if err != nil {
+		i.Close()
+	}
+	return err
+}
+
+func (i *instance) start() error {
+	torInstance, err := tor.Start(i.ctx, i.startConf)
+	if err != nil {
+		return E.New(strings.ToLower(err.Error()))
+	}
+	i.tor = torInstance
+	i.events = make(chan control.Event, 8)
+	err = torInstance.Control.AddEventListener(i.events, torLogEvents...)
+	if err != nil {
+		return err
+	}
+	go i.recvLoop()
+	err = i.proxy.Start()
+	if err != nil {
+		return err
+	}
+	proxyPort := "127.0.0.1:" + F.ToString(i.proxy.Port())
+	proxyUsername := i.proxy.Username()
+	proxyPassword := i.proxy.Password()
+	i.logger.Trace("created upstream proxy at ", proxyPort)
+	i.logger.Trace("upstream proxy username ", proxyUsername)
+	i.logger.Trace("upstream proxy password ", proxyPassword)
+	confOptions := []*control.KeyVal{
+		control.NewKeyVal("Socks5Proxy", proxyPort),
+		control.NewKeyVal("Socks5ProxyUsername", proxyUsername),
+		control.NewKeyVal("Socks5ProxyPassword", proxyPassword),
+	}
+	err = torInstance.Control.ResetConf(confOptions...)
+	if err != nil {
+		return err
+	}
+	if len(i.options) > 0 {
+		// list options such as Bridge must be set in one request, or each one replaces the previous
+		err = torInstance.Control.SetConf(i.options...)
+		if err != nil {
+			return E.Cause(err, "set torrc options")
+		}
+	}
+	return torInstance.EnableNetwork(i.ctx, true)
+}
+
+func (i *instance) recvLoop() {
+	for rawEvent := range i.events {
+		switch event := rawEvent.(type) {
+		case *control.LogEvent:
+			event.Raw = strings.ToLower(event.Raw)
+			switch event.Severity {
+			case control.EventCodeLogDebug, control.EventCodeLogInfo:
+				i.logger.Trace(event.Raw)
+			case control.EventCodeLogNotice:
+				if strings.Contains(event.Raw, "disablenetwork") || strings.Contains(event.Raw, "socks listener") {
+					i.logger.Trace(event.Raw)
+					continue
+				}
+				i.logger.Info(event.Raw)
+			case control.EventCodeLogWarn:
+				i.logger.Warn(event.Raw)
+			case control.EventCodeLogErr:
+				i.logger.Error(event.Raw)
+			}
+		}
+	}
+}
+
+func (i *instance) Control() *control.Conn {
+	return i.tor.Control
+}
+
+func (i *instance) DataDirectory() string {
+	return i.startConf.DataDir
+}
+
+func (i *instance) Close() error {
+	err := common.Close(
+		common.PtrOrNil(i.proxy),
+		common.PtrOrNil(i.tor),
+	)
+	if i.events != nil {
+		close(i.events)
+		i.events = nil
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package route
+
+import (
+	"context"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	R "github.com/sagernet/sing-box/route/rule"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+// ExplainQuery describes a hypothetical connection to explain.
+type ExplainQuery struct {
+	Inbound     string
+	Network     string
+	Source      string
+	Destination string
+	Domain      string
+	Protocol    string
+	User        string
+	ProcessPath string
+	PackageName string
+}
+
+func (q ExplainQuery) Metadata() adapter.InboundContext {
+	metadata := adapter.InboundContext{
+		Inbound:     q.Inbound,
+		Network:     q.Network,
+		Source:      M.ParseSocksaddr(q.Source),
+		Destination: M.ParseSocksaddr(q.Destination),
+		Domain:      q.Domain,
+		Protocol:    q.Protocol,
+		User:        q.User,
+	}
+	if metadata.Domain == "" && metadata.Destination.IsFqdn() {
+		metadata.Domain = metadata.Destination.Fqdn
+	}
+	if q.ProcessPath != "" || q.PackageName != "" {
+		metadata.ProcessInfo = &adapter.ConnectionOwner{
+			UserId:      -1,
+			ProcessPath: q.ProcessPath,
+		}
+		if q.PackageName != "" {
+			metadata.ProcessInfo.AndroidPackageNames = []string{q.PackageName}
+		}
+	}
+	return metadata
+}
+
+// Explain runs a hypothetical connection through the route rules without connecting.
+// Actions that need the connection itself, like sniff, are skipped, so sniffed fields
+// such as protocol and domain must be provided in metadata, and the process is not searched.
+// The resolve action still queries DNS, since later IP rules depend on its result.
+func (r *Router) Explain(ctx context.Context, metadata adapter.InboundContext) (*adapter.RouteExplanation, error) {
+	if !metadata.Destination.IsValid() {
+		return nil, E.New("missing destination")
+	}
+	if metadata.Network == "" {
+		metadata.Network = N.NetworkTCP
+	}
+	if metadata.Inbound != "" && metadata.InboundType == "" {
+		inbound, loaded := r.inbound.Get(metadata.Inbound)
+		if !loaded {
+			return nil, E.New("inbound not found: ", metadata.Inbound)
+		}
+		metadata.InboundType = inbound.Type()
+	}
+	explainer := &R.Explainer{}
+	ctx = R.ContextWithExplainer(log.ContextWithNewID(ctx), explainer)
+	selectedRule, selectedRuleIndex, _, _, _, err := r.matchRule(ctx, &metadata, false, false, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	explanation := &adapter.RouteExplanation{
+		Metadata:  metadata,
+		Rules:     explainer.Rules,
+		RuleIndex: -1,
+	}
+	if selectedRule != nil {
+		explanation.RuleIndex = selectedRuleIndex
+		explanation.Rule = selectedRule.String()
+		explanation.Action = selectedRule.Action().String()
+		switch action := selectedRule.Action().(type) {
+		case *R.RuleActionRoute:
+			explanation.Outbound = action.Outbound
+		case *R.RuleActionBypass:
+			explanation.Outbound = action.Outbound
+		}
+	} else {
+		explanation.Outbound = r.outbound.Default().Tag()
+	}
+	if explanation.Outbound != "" && metadata.Destination.IsFqdn() {
+		explanation.DNS = r.dns.Explain(ctx, metadata)
+	}
+	return explanation, nil
+}
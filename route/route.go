@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/mitm"
 	"github.com/sagernet/sing-box/common/sniff"
+	boxUoT "github.com/sagernet/sing-box/common/uot"
 	C "github.com/sagernet/sing-box/constant"
 	R "github.com/sagernet/sing-box/route/rule"
 	"github.com/sagernet/sing-mux"
@@ -27,6 +29,7 @@ import (
 	"github.com/sagernet/sing/common/uot"
 
 	"golang.org/x/exp/slices"
+	"golang.org/x/net/http2"
 )
 
 // Deprecated: use RouteConnectionEx instead.
@@ -91,10 +94,13 @@ func (r *Router) routeConnection(ctx context.Context, conn net.Conn, metadata ad
 	if deadline.NeedAdditionalReadDeadline(conn) {
 		conn = deadline.NewConn(conn)
 	}
-	selectedRule, _, buffers, _, err := r.matchRule(ctx, &metadata, false, false, conn, nil)
+	selectedRule, _, buffers, _, interceptedConn, err := r.matchRule(ctx, &metadata, false, false, conn, nil)
 	if err != nil {
 		return err
 	}
+	if interceptedConn != nil {
+		conn = interceptedConn
+	}
 	var selectedOutbound adapter.Outbound
 	if selectedRule != nil {
 		switch action := selectedRule.Action().(type) {
@@ -220,7 +226,7 @@ func (r *Router) routePacketConnection(ctx context.Context, conn N.PacketConn, m
 		conn = deadline.NewPacketConn(bufio.NewNetPacketConn(conn))
 	}*/
 
-	selectedRule, _, _, packetBuffers, err := r.matchRule(ctx, &metadata, false, false, nil, conn)
+	selectedRule, _, _, packetBuffers, _, err := r.matchRule(ctx, &metadata, false, false, nil, conn)
 	if err != nil {
 		return err
 	}
@@ -235,9 +241,10 @@ func (r *Router) routePacketConnection(ctx context.Context, conn N.PacketConn, m
 				N.ReleaseMultiPacketBuffer(packetBuffers)
 				return E.New("outbound not found: ", action.Outbound)
 			}
-			if !common.Contains(selectedOutbound.Network(), N.NetworkUDP) {
+			selectedOutbound, err = r.packetOutbound(metadata, selectedOutbound)
+			if err != nil {
 				N.ReleaseMultiPacketBuffer(packetBuffers)
-				return E.New("UDP is not supported by outbound: ", selectedOutbound.Tag())
+				return err
 			}
 		case *R.RuleActionBypass:
 			if action.Outbound == "" {
@@ -249,9 +256,10 @@ func (r *Router) routePacketConnection(ctx context.Context, conn N.PacketConn, m
 				N.ReleaseMultiPacketBuffer(packetBuffers)
 				return E.New("outbound not found: ", action.Outbound)
 			}
-			if !common.Contains(selectedOutbound.Network(), N.NetworkUDP) {
+			selectedOutbound, err = r.packetOutbound(metadata, selectedOutbound)
+			if err != nil {
 				N.ReleaseMultiPacketBuffer(packetBuffers)
-				return E.New("UDP is not supported by outbound: ", selectedOutbound.Tag())
+				return err
 			}
 		case *R.RuleActionReject:
 			N.ReleaseMultiPacketBuffer(packetBuffers)
@@ -264,12 +272,11 @@ func (r *Router) routePacketConnection(ctx context.Context, conn N.PacketConn, m
 		}
 	}
 	if selectedRule == nil || selectReturn {
-		defaultOutbound := r.outbound.Default()
-		if !common.Contains(defaultOutbound.Network(), N.NetworkUDP) {
+		selectedOutbound, err = r.packetOutbound(metadata, r.outbound.Default())
+		if err != nil {
 			N.ReleaseMultiPacketBuffer(packetBuffers)
-			return E.New("UDP is not supported by outbound: ", defaultOutbound.Tag())
+			return err
 		}
-		selectedOutbound = defaultOutbound
 	}
 	for _, buffer := range packetBuffers {
 		conn = bufio.NewCachedPacketConn(conn, buffer.Buffer, buffer.Destination)
@@ -278,7 +285,7 @@ func (r *Router) routePacketConnection(ctx context.Context, conn N.PacketConn, m
 	for _, tracker := range r.trackers {
 		conn = tracker.RoutedPacketConnection(ctx, conn, metadata, selectedRule, selectedOutbound)
 	}
-	if metadata.FakeIP {
+	if metadata.FakeIP || metadata.DNS64 {
 		conn = bufio.NewNATPacketConn(bufio.NewNetPacketConn(conn), metadata.OriginDestination, metadata.Destination)
 	}
 	if outboundHandler, isHandler := selectedOutbound.(adapter.PacketConnectionHandlerEx); isHandler {
@@ -289,8 +296,26 @@ func (r *Router) routePacketConnection(ctx context.Context, conn N.PacketConn, m
 	return nil
 }
 
+// packetOutbound applies the UDP fallback route option to outbounds without UDP support.
+func (r *Router) packetOutbound(metadata adapter.InboundContext, outbound adapter.Outbound) (adapter.Outbound, error) {
+	if common.Contains(outbound.Network(), N.NetworkUDP) {
+		return outbound, nil
+	}
+	switch metadata.UDPFallback {
+	case C.RuleActionUDPFallbackUDPOverTCP:
+		if common.Contains(outbound.Network(), N.NetworkTCP) {
+			return boxUoT.NewOutbound(outbound), nil
+		}
+	case C.RuleActionUDPFallbackRejectQUIC:
+		if metadata.Protocol == C.ProtocolQUIC {
+			return nil, &R.RejectedError{Cause: E.New("QUIC rejected by outbound without UDP support: ", outbound.Tag())}
+		}
+	}
+	return nil, E.New("UDP is not supported by outbound: ", outbound.Tag())
+}
+
 func (r *Router) PreMatch(metadata adapter.InboundContext, routeContext tun.DirectRouteContext, timeout time.Duration, supportBypass bool) (tun.DirectRouteDestination, error) {
-	selectedRule, _, _, _, err := r.matchRule(r.ctx, &metadata, true, supportBypass, nil, nil)
+	selectedRule, _, _, _, _, err := r.matchRule(r.ctx, &metadata, true, supportBypass, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -405,9 +430,12 @@ func (r *Router) matchRule(
 	inputConn net.Conn, inputPacketConn N.PacketConn,
 ) (
 	selectedRule adapter.Rule, selectedRuleIndex int,
-	buffers []*buf.Buffer, packetBuffers []*N.PacketBuffer, fatalErr error,
+	buffers []*buf.Buffer, packetBuffers []*N.PacketBuffer, interceptedConn net.Conn, fatalErr error,
 ) {
-	r.searchProcessInfo(ctx, metadata)
+	// an explained connection does not exist, so there is no process to search for
+	if R.ExplainerFromContext(ctx) == nil {
+		r.searchProcessInfo(ctx, metadata)
+	}
 	if metadata.Destination.Addr.IsValid() && r.dnsTransport.FakeIP() != nil && r.dnsTransport.FakeIP().Store().Contains(metadata.Destination.Addr) {
 		domain, loaded := r.dnsTransport.FakeIP().Store().Lookup(metadata.Destination.Addr)
 		if !loaded {
@@ -430,16 +458,28 @@ func (r *Router) matchRule(
 			r.logger.DebugContext(ctx, "found reserve mapped domain: ", metadata.Domain)
 		}
 	}
+	if metadata.Destination.IsIPv6() && metadata.Network != N.NetworkICMP && r.dns64Available() {
+		address, loaded := r.dns.LookupDNS64(metadata.Destination.Addr)
+		if loaded {
+			metadata.OriginDestination = metadata.Destination
+			metadata.Destination = M.SocksaddrFrom(address, metadata.Destination.Port)
+			metadata.DNS64 = true
+			r.logger.DebugContext(ctx, "found DNS64 address: ", address)
+		}
+	}
 	if metadata.Destination.IsIPv4() {
 		metadata.IPVersion = 4
 	} else if metadata.Destination.IsIPv6() {
 		metadata.IPVersion = 6
 	}
 
+	explainer := R.ExplainerFromContext(ctx)
 match:
 	for currentRuleIndex, currentRule := range r.rules {
 		metadata.ResetRuleCache()
-		if !currentRule.Match(metadata) {
+		matched := currentRule.Match(metadata)
+		explainer.Record(currentRuleIndex, currentRule, metadata, matched)
+		if !matched {
 			continue
 		}
 		if !preMatch {
@@ -508,6 +548,9 @@ match:
 			if routeOptions.UDPTimeout > 0 {
 				metadata.UDPTimeout = routeOptions.UDPTimeout
 			}
+			if routeOptions.UDPFallback != "" {
+				metadata.UDPFallback = routeOptions.UDPFallback
+			}
 			if routeOptions.TLSFragment {
 				metadata.TLSFragment = true
 				metadata.TLSFragmentFallbackDelay = routeOptions.TLSFragmentFallbackDelay
@@ -515,6 +558,22 @@ match:
 			if routeOptions.TLSRecordFragment {
 				metadata.TLSRecordFragment = true
 			}
+			if routeOptions.MITM && !preMatch && inputConn != nil && !metadata.MITM {
+				var outboundTag string
+				if routeAction, isRoute := currentRule.Action().(*R.RuleActionRoute); isRoute {
+					outboundTag = routeAction.Outbound
+				}
+				newConn, newErr := r.actionMITM(ctx, metadata, outboundTag, inputConn, buffers)
+				if newErr != nil {
+					fatalErr = newErr
+					return
+				}
+				if newConn != nil {
+					inputConn = newConn
+					interceptedConn = newConn
+					buffers = nil
+				}
+			}
 		}
 		switch action := currentRule.Action().(type) {
 		case *R.RuleActionSniff:
@@ -539,6 +598,15 @@ match:
 			if fatalErr != nil {
 				return
 			}
+		case *R.RuleActionHTTPRewrite:
+			if !preMatch && inputConn != nil {
+				newConn := r.actionHTTPRewrite(ctx, metadata, action, inputConn, buffers)
+				if newConn != nil {
+					inputConn = newConn
+					interceptedConn = newConn
+					buffers = nil
+				}
+			}
 		}
 		actionType := currentRule.Action().Type()
 		if actionType == C.RuleActionTypeRoute ||
@@ -566,14 +634,19 @@ func (r *Router) actionSniff(
 	inputConn net.Conn, inputPacketConn N.PacketConn, inputBuffers []*buf.Buffer, inputPacketBuffers []*N.PacketBuffer,
 ) (buffer *buf.Buffer, packetBuffers []*N.PacketBuffer, fatalErr error) {
 	if sniff.Skip(metadata) {
-		r.logger.DebugContext(ctx, "sniff skipped due to port considered as server-first")
+		if protocol := sniff.PortProtocol(metadata); common.Contains(action.SnifferNames, protocol) {
+			metadata.Protocol = protocol
+			r.logger.DebugContext(ctx, "classified protocol by server-first port: ", protocol)
+		} else {
+			r.logger.DebugContext(ctx, "sniff skipped due to port considered as server-first")
+		}
 		return
 	} else if metadata.Protocol != "" {
 		r.logger.DebugContext(ctx, "duplicate sniff skipped")
 		return
 	}
 	if inputConn != nil {
-		if len(action.StreamSniffers) == 0 && len(action.PacketSniffers) > 0 {
+		if len(action.StreamSniffers) == 0 && len(action.SnifferNames) > 0 {
 			return
 		} else if slices.Equal(metadata.SnifferNames, action.SnifferNames) && metadata.SniffError != nil && !errors.Is(metadata.SniffError, sniff.ErrNeedMoreData) {
 			r.logger.DebugContext(ctx, "packet sniff skipped due to previous error: ", metadata.SniffError)
@@ -626,14 +699,15 @@ func (r *Router) actionSniff(
 			sniffBuffer.Release()
 		}
 	} else if inputPacketConn != nil {
-		if len(action.PacketSniffers) == 0 && len(action.StreamSniffers) > 0 {
+		if len(action.PacketSniffers) == 0 && len(action.SnifferNames) > 0 {
 			return
 		} else if slices.Equal(metadata.SnifferNames, action.SnifferNames) && metadata.SniffError != nil && !errors.Is(metadata.SniffError, sniff.ErrNeedMoreData) {
 			r.logger.DebugContext(ctx, "packet sniff skipped due to previous error: ", metadata.SniffError)
 			return
 		}
 		quicMoreData := func() bool {
-			return slices.Equal(metadata.SnifferNames, action.SnifferNames) && errors.Is(metadata.SniffError, sniff.ErrNeedMoreData)
+			// only the QUIC sniffer keeps fragments in the sniff context
+			return slices.Equal(metadata.SnifferNames, action.SnifferNames) && errors.Is(metadata.SniffError, sniff.ErrNeedMoreData) && metadata.SniffContext != nil
 		}
 		var packetSniffers []sniff.PacketSniffer
 		if len(action.PacketSniffers) > 0 {
@@ -668,8 +742,7 @@ func (r *Router) actionSniff(
 			metadata.SnifferNames = action.SnifferNames
 			metadata.SniffError = err
 			if errors.Is(err, sniff.ErrNeedMoreData) {
-				// TODO: replace with generic message when there are more multi-packet protocols
-				r.logger.DebugContext(ctx, "attempt to sniff fragmented QUIC client hello")
+				r.logger.DebugContext(ctx, "attempt to sniff more packets")
 				continue
 			}
 			goto finally
@@ -728,8 +801,7 @@ func (r *Router) actionSniff(
 				metadata.SnifferNames = action.SnifferNames
 				metadata.SniffError = err
 				if errors.Is(err, sniff.ErrNeedMoreData) {
-					// TODO: replace with generic message when there are more multi-packet protocols
-					r.logger.DebugContext(ctx, "attempt to sniff fragmented QUIC client hello")
+					r.logger.DebugContext(ctx, "attempt to sniff more packets")
 					continue
 				}
 			}
@@ -783,3 +855,112 @@ func (r *Router) actionResolve(ctx context.Context, metadata *adapter.InboundCon
 	}
 	return nil
 }
+
+// actionMITM terminates TLS of a sniffed connection to read its first HTTP request.
+// The real server is probed through the outbound first, so that the client is only offered
+// the protocol that the server accepts.
+func (r *Router) actionMITM(ctx context.Context, metadata *adapter.InboundContext, outboundTag string, inputConn net.Conn, inputBuffers []*buf.Buffer) (net.Conn, error) {
+	if r.mitmAuthority == nil {
+		return nil, E.New("MITM is not enabled in route options")
+	}
+	if metadata.Protocol != C.ProtocolTLS || metadata.Domain == "" {
+		r.logger.DebugContext(ctx, "MITM skipped: not a sniffed TLS connection with server name")
+		return nil, nil
+	}
+	fingerprint := sniff.SniffedTLSFingerprint(metadata)
+	var clientALPN []string
+	if fingerprint != nil {
+		clientALPN = fingerprint.ALPN
+	}
+	if !mitm.IsHTTP(clientALPN) {
+		r.logger.DebugContext(ctx, "MITM skipped: no HTTP protocol offered in ALPN: ", strings.Join(clientALPN, ","))
+		return nil, nil
+	}
+	var outbound adapter.Outbound
+	if outboundTag != "" {
+		var loaded bool
+		outbound, loaded = r.outbound.Outbound(outboundTag)
+		if !loaded {
+			return nil, E.New("outbound not found: ", outboundTag)
+		}
+	} else {
+		outbound = r.outbound.Default()
+	}
+	upstreamProtocol, err := r.mitmAuthority.UpstreamProtocol(ctx, outbound, metadata.Destination, metadata.DestinationAddresses, metadata.Domain, clientALPN)
+	if err != nil {
+		r.logger.DebugContext(ctx, "MITM skipped: probe server: ", err)
+		return nil, nil
+	}
+	if upstreamProtocol == "" {
+		upstreamProtocol = "http/1.1"
+	}
+	if len(clientALPN) > 0 && !common.Contains(clientALPN, upstreamProtocol) {
+		r.logger.DebugContext(ctx, "MITM skipped: server protocol ", upstreamProtocol, " not offered in ALPN: ", strings.Join(clientALPN, ","))
+		return nil, nil
+	}
+	conn := inputConn
+	for _, buffer := range inputBuffers {
+		conn = bufio.NewCachedConn(conn, buffer)
+	}
+	tlsConn, err := r.mitmAuthority.Server(ctx, conn, metadata.Domain, upstreamProtocol)
+	if err != nil {
+		return nil, E.Cause(err, "MITM")
+	}
+	nextProtocol := tlsConn.ConnectionState().NegotiatedProtocol
+	request, payload, err := mitm.ReadRequest(tlsConn, nextProtocol)
+	if err != nil {
+		if len(payload) == 0 {
+			return nil, E.Cause(err, "MITM: read request")
+		}
+		r.logger.DebugContext(ctx, "MITM: read request: ", err)
+	}
+	metadata.MITM = true
+	metadata.MITMServerName = metadata.Domain
+	metadata.MITMNextProtocol = nextProtocol
+	metadata.SniffContext = &sniff.InterceptedRequest{
+		Fingerprint: fingerprint,
+		Request:     request,
+	}
+	if nextProtocol != "" {
+		r.logger.DebugContext(ctx, "intercepted TLS connection to ", metadata.Domain, " using ", nextProtocol)
+	} else {
+		r.logger.DebugContext(ctx, "intercepted TLS connection to ", metadata.Domain)
+	}
+	var outputConn net.Conn = tlsConn
+	if len(payload) > 0 {
+		outputConn = bufio.NewCachedConn(tlsConn, buf.As(payload))
+	}
+	return mitm.NewLoggingConn(ctx, outputConn, nextProtocol, r.logger), nil
+}
+
+func (r *Router) actionHTTPRewrite(ctx context.Context, metadata *adapter.InboundContext, action *R.RuleActionHTTPRewrite, inputConn net.Conn, inputBuffers []*buf.Buffer) net.Conn {
+	var scheme string
+	if metadata.Protocol == C.ProtocolHTTP {
+		scheme = "http"
+	} else if metadata.MITM && metadata.MITMNextProtocol != http2.NextProtoTLS {
+		scheme = "https"
+	} else {
+		r.logger.DebugContext(ctx, "HTTP rewrite skipped: not a plaintext HTTP/1.x connection")
+		return nil
+	}
+	conn := inputConn
+	for _, buffer := range inputBuffers {
+		conn = bufio.NewCachedConn(conn, buffer)
+	}
+	return action.Rewriter.NewConn(ctx, conn, scheme, r.logger)
+}
+
+// dns64Available reports whether addresses synthesized by DNS64 should be dialed via IPv4,
+// which is the case unless the default interface is known to have no IPv4 address.
+func (r *Router) dns64Available() bool {
+	if r.network.InterfaceMonitor() == nil {
+		return true
+	}
+	defaultInterface := r.network.DefaultNetworkInterface()
+	if defaultInterface == nil {
+		return true
+	}
+	return common.Any(defaultInterface.Addresses, func(it netip.Prefix) bool {
+		return it.Addr().Is4()
+	})
+}
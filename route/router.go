@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/mitm"
 	"github.com/sagernet/sing-box/common/process"
 	"github.com/sagernet/sing-box/common/taskmonitor"
 	C "github.com/sagernet/sing-box/constant"
@@ -22,7 +23,10 @@ import (
 	"github.com/sagernet/sing/service/pause"
 )
 
-var _ adapter.Router = (*Router)(nil)
+var (
+	_ adapter.Router         = (*Router)(nil)
+	_ adapter.RuleSetTracker = (*Router)(nil)
+)
 
 type Router struct {
 	ctx               context.Context
@@ -41,7 +45,10 @@ type Router struct {
 	processCache      freelru.Cache[processCacheKey, processCacheEntry]
 	pauseManager      pause.Manager
 	trackers          []adapter.ConnectionTracker
+	ruleSetTrackers   []adapter.RuleSetTracker
 	platformInterface adapter.PlatformInterface
+	mitmOptions       *option.MITMOptions
+	mitmAuthority     *mitm.Authority
 	started           bool
 }
 
@@ -60,6 +67,7 @@ func NewRouter(ctx context.Context, logFactory log.Factory, options option.Route
 		needFindProcess:   hasRule(options.Rules, isProcessRule) || hasDNSRule(dnsOptions.Rules, isProcessDNSRule) || options.FindProcess,
 		pauseManager:      service.FromContext[pause.Manager](ctx),
 		platformInterface: service.FromContext[adapter.PlatformInterface](ctx),
+		mitmOptions:       options.MITM,
 	}
 }
 
@@ -89,6 +97,15 @@ func (r *Router) Start(stage adapter.StartStage) error {
 	monitor := taskmonitor.New(r.logger, C.StartTimeout)
 	switch stage {
 	case adapter.StartStateStart:
+		if r.mitmOptions != nil && r.mitmOptions.Enabled {
+			monitor.Start("initialize MITM CA")
+			authority, err := mitm.NewAuthority(r.ctx, *r.mitmOptions)
+			monitor.Finish()
+			if err != nil {
+				return E.Cause(err, "initialize MITM CA")
+			}
+			r.mitmAuthority = authority
+		}
 		var cacheContext *adapter.HTTPStartContext
 		if len(r.ruleSets) > 0 {
 			monitor.Start("initialize rule-set")
@@ -211,6 +228,10 @@ func (r *Router) RuleSet(tag string) (adapter.RuleSet, bool) {
 	return ruleSet, loaded
 }
 
+func (r *Router) RuleSets() []adapter.RuleSet {
+	return r.ruleSets
+}
+
 func (r *Router) Rules() []adapter.Rule {
 	return r.rules
 }
@@ -219,6 +240,16 @@ func (r *Router) AppendTracker(tracker adapter.ConnectionTracker) {
 	r.trackers = append(r.trackers, tracker)
 }
 
+func (r *Router) AppendRuleSetTracker(tracker adapter.RuleSetTracker) {
+	r.ruleSetTrackers = append(r.ruleSetTrackers, tracker)
+}
+
+func (r *Router) RuleSetReloaded(event adapter.RuleSetReloadEvent) {
+	for _, tracker := range r.ruleSetTrackers {
+		tracker.RuleSetReloaded(event)
+	}
+}
+
 func (r *Router) NeedFindProcess() bool {
 	return r.needFindProcess
 }
@@ -38,7 +38,7 @@ func (r *abstractDefaultRule) Start() error {
 			}
 		}
 	}
-	return nil
+	return startAction(r.action)
 }
 
 func (r *abstractDefaultRule) Close() error {
@@ -210,6 +210,15 @@ func (r *abstractLogicalRule) Start() error {
 			return err
 		}
 	}
+	return startAction(r.action)
+}
+
+func startAction(action adapter.RuleAction) error {
+	if starter, isStarter := action.(interface {
+		Start() error
+	}); isStarter {
+		return starter.Start()
+	}
 	return nil
 }
 
@@ -10,6 +10,7 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/common/dialer"
+	"github.com/sagernet/sing-box/common/httprewrite"
 	"github.com/sagernet/sing-box/common/sniff"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
@@ -17,9 +18,11 @@ import (
 	"github.com/sagernet/sing/common"
 	E "github.com/sagernet/sing/common/exceptions"
 	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json/badoption"
 	"github.com/sagernet/sing/common/logger"
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
 
 	"github.com/miekg/dns"
 )
@@ -38,11 +41,13 @@ func NewRuleAction(ctx context.Context, logger logger.ContextLogger, action opti
 				FallbackDelay:             time.Duration(action.RouteOptions.FallbackDelay),
 				UDPDisableDomainUnmapping: action.RouteOptions.UDPDisableDomainUnmapping,
 				UDPConnect:                action.RouteOptions.UDPConnect,
+				UDPFallback:               action.RouteOptions.UDPFallback,
 				TLSFragment:               action.RouteOptions.TLSFragment,
 				TLSFragmentFallbackDelay:  time.Duration(action.RouteOptions.TLSFragmentFallbackDelay),
 				TLSRecordFragment:         action.RouteOptions.TLSRecordFragment,
+				MITM:                      action.RouteOptions.MITM,
 			},
-		}, nil
+		}, checkUDPFallback(action.RouteOptions.UDPFallback)
 	case C.RuleActionTypeRouteOptions:
 		return &RuleActionRouteOptions{
 			OverrideAddress:           M.ParseSocksaddrHostPort(action.RouteOptionsOptions.OverrideAddress, 0),
@@ -52,10 +57,12 @@ func NewRuleAction(ctx context.Context, logger logger.ContextLogger, action opti
 			UDPDisableDomainUnmapping: action.RouteOptionsOptions.UDPDisableDomainUnmapping,
 			UDPConnect:                action.RouteOptionsOptions.UDPConnect,
 			UDPTimeout:                time.Duration(action.RouteOptionsOptions.UDPTimeout),
+			UDPFallback:               action.RouteOptionsOptions.UDPFallback,
 			TLSFragment:               action.RouteOptionsOptions.TLSFragment,
 			TLSFragmentFallbackDelay:  time.Duration(action.RouteOptionsOptions.TLSFragmentFallbackDelay),
 			TLSRecordFragment:         action.RouteOptionsOptions.TLSRecordFragment,
-		}, nil
+			MITM:                      action.RouteOptionsOptions.MITM,
+		}, checkUDPFallback(action.RouteOptionsOptions.UDPFallback)
 	case C.RuleActionTypeBypass:
 		return &RuleActionBypass{
 			Outbound: action.BypassOptions.Outbound,
@@ -66,11 +73,13 @@ func NewRuleAction(ctx context.Context, logger logger.ContextLogger, action opti
 				FallbackDelay:             time.Duration(action.BypassOptions.FallbackDelay),
 				UDPDisableDomainUnmapping: action.BypassOptions.UDPDisableDomainUnmapping,
 				UDPConnect:                action.BypassOptions.UDPConnect,
+				UDPFallback:               action.BypassOptions.UDPFallback,
 				TLSFragment:               action.BypassOptions.TLSFragment,
 				TLSFragmentFallbackDelay:  time.Duration(action.BypassOptions.TLSFragmentFallbackDelay),
 				TLSRecordFragment:         action.BypassOptions.TLSRecordFragment,
+				MITM:                      action.BypassOptions.MITM,
 			},
-		}, nil
+		}, checkUDPFallback(action.BypassOptions.UDPFallback)
 	case C.RuleActionTypeDirect:
 		directDialer, err := dialer.New(ctx, option.DialerOptions(action.DirectOptions), false)
 		if err != nil {
@@ -103,8 +112,15 @@ func NewRuleAction(ctx context.Context, logger logger.ContextLogger, action opti
 		sniffAction := &RuleActionSniff{
 			SnifferNames: action.SniffOptions.Sniffer,
 			Timeout:      time.Duration(action.SniffOptions.Timeout),
+			AmneziaWG:    action.SniffOptions.AmneziaWG,
 		}
 		return sniffAction, sniffAction.build()
+	case C.RuleActionTypeHTTPRewrite:
+		rewriter, err := httprewrite.New(action.HTTPRewriteOptions.Rules)
+		if err != nil {
+			return nil, err
+		}
+		return &RuleActionHTTPRewrite{Rewriter: rewriter}, nil
 	case C.RuleActionTypeResolve:
 		return &RuleActionResolve{
 			Server:       action.ResolveOptions.Server,
@@ -118,10 +134,10 @@ func NewRuleAction(ctx context.Context, logger logger.ContextLogger, action opti
 	}
 }
 
-func NewDNSRuleAction(logger logger.ContextLogger, action option.DNSRuleAction) adapter.RuleAction {
+func NewDNSRuleAction(ctx context.Context, logger logger.ContextLogger, action option.DNSRuleAction) (adapter.RuleAction, error) {
 	switch action.Action {
 	case "":
-		return nil
+		return nil, nil
 	case C.RuleActionTypeRoute:
 		return &RuleActionDNSRoute{
 			Server: action.RouteOptions.Server,
@@ -130,28 +146,34 @@ func NewDNSRuleAction(logger logger.ContextLogger, action option.DNSRuleAction)
 				DisableCache: action.RouteOptions.DisableCache,
 				RewriteTTL:   action.RouteOptions.RewriteTTL,
 				ClientSubnet: netip.Prefix(common.PtrValueOrDefault(action.RouteOptions.ClientSubnet)),
+				DNS64:        action.RouteOptions.DNS64,
+				DNS64Prefix:  netip.Prefix(common.PtrValueOrDefault(action.RouteOptions.DNS64Prefix)),
 			},
-		}
+		}, checkDNS64Prefix(action.RouteOptions.DNS64Prefix)
 	case C.RuleActionTypeRouteOptions:
 		return &RuleActionDNSRouteOptions{
 			Strategy:     C.DomainStrategy(action.RouteOptionsOptions.Strategy),
 			DisableCache: action.RouteOptionsOptions.DisableCache,
 			RewriteTTL:   action.RouteOptionsOptions.RewriteTTL,
 			ClientSubnet: netip.Prefix(common.PtrValueOrDefault(action.RouteOptionsOptions.ClientSubnet)),
-		}
+			DNS64:        action.RouteOptionsOptions.DNS64,
+			DNS64Prefix:  netip.Prefix(common.PtrValueOrDefault(action.RouteOptionsOptions.DNS64Prefix)),
+		}, checkDNS64Prefix(action.RouteOptionsOptions.DNS64Prefix)
 	case C.RuleActionTypeReject:
 		return &RuleActionReject{
 			Method: action.RejectOptions.Method,
 			NoDrop: action.RejectOptions.NoDrop,
 			logger: logger,
-		}
+		}, nil
 	case C.RuleActionTypePredefined:
 		return &RuleActionPredefined{
 			Rcode:  action.PredefinedOptions.Rcode.Build(),
 			Answer: common.Map(action.PredefinedOptions.Answer, option.DNSRecordOptions.Build),
 			Ns:     common.Map(action.PredefinedOptions.Ns, option.DNSRecordOptions.Build),
 			Extra:  common.Map(action.PredefinedOptions.Extra, option.DNSRecordOptions.Build),
-		}
+		}, nil
+	case C.RuleActionTypeRewrite:
+		return NewRuleActionDNSRewrite(ctx, action.RewriteOptions)
 	default:
 		panic(F.ToString("unknown rule action: ", action.Action))
 	}
@@ -202,9 +224,11 @@ type RuleActionRouteOptions struct {
 	UDPDisableDomainUnmapping bool
 	UDPConnect                bool
 	UDPTimeout                time.Duration
+	UDPFallback               string
 	TLSFragment               bool
 	TLSFragmentFallbackDelay  time.Duration
 	TLSRecordFragment         bool
+	MITM                      bool
 }
 
 func (r *RuleActionRouteOptions) Type() string {
@@ -244,6 +268,9 @@ func (r *RuleActionRouteOptions) Descriptions() []string {
 	if r.UDPTimeout > 0 {
 		descriptions = append(descriptions, "udp-timeout")
 	}
+	if r.UDPFallback != "" {
+		descriptions = append(descriptions, F.ToString("udp-fallback=", r.UDPFallback))
+	}
 	if r.TLSFragment {
 		descriptions = append(descriptions, "tls-fragment")
 	}
@@ -253,6 +280,9 @@ func (r *RuleActionRouteOptions) Descriptions() []string {
 	if r.TLSRecordFragment {
 		descriptions = append(descriptions, "tls-record-fragment")
 	}
+	if r.MITM {
+		descriptions = append(descriptions, "mitm")
+	}
 	return descriptions
 }
 
@@ -277,6 +307,9 @@ func (r *RuleActionDNSRoute) String() string {
 	if r.ClientSubnet.IsValid() {
 		descriptions = append(descriptions, F.ToString("client-subnet=", r.ClientSubnet))
 	}
+	if r.DNS64 {
+		descriptions = append(descriptions, "dns64")
+	}
 	return F.ToString("route(", strings.Join(descriptions, ","), ")")
 }
 
@@ -285,6 +318,32 @@ type RuleActionDNSRouteOptions struct {
 	DisableCache bool
 	RewriteTTL   *uint32
 	ClientSubnet netip.Prefix
+	DNS64        bool
+	DNS64Prefix  netip.Prefix
+}
+
+func checkUDPFallback(udpFallback string) error {
+	switch udpFallback {
+	case "", C.RuleActionUDPFallbackUDPOverTCP, C.RuleActionUDPFallbackRejectQUIC:
+		return nil
+	default:
+		return E.New("unknown UDP fallback: ", udpFallback)
+	}
+}
+
+func checkDNS64Prefix(prefix *badoption.Prefix) error {
+	if prefix == nil {
+		return nil
+	}
+	if !(*netip.Prefix)(prefix).Addr().Is6() {
+		return E.New("DNS64 prefix must be an IPv6 prefix")
+	}
+	switch (*netip.Prefix)(prefix).Bits() {
+	case 32, 40, 48, 56, 64, 96:
+		return nil
+	default:
+		return E.New("DNS64 prefix length must be one of 32, 40, 48, 56, 64 or 96")
+	}
 }
 
 func (r *RuleActionDNSRouteOptions) Type() string {
@@ -302,9 +361,99 @@ func (r *RuleActionDNSRouteOptions) String() string {
 	if r.ClientSubnet.IsValid() {
 		descriptions = append(descriptions, F.ToString("client-subnet=", r.ClientSubnet))
 	}
+	if r.DNS64 {
+		descriptions = append(descriptions, "dns64")
+	}
 	return F.ToString("route-options(", strings.Join(descriptions, ","), ")")
 }
 
+type RuleActionDNSRewrite struct {
+	CNAME        string
+	StripAAAA    bool
+	ClientSubnet netip.Prefix
+	filterItems  []RuleItem
+}
+
+func NewRuleActionDNSRewrite(ctx context.Context, options option.DNSRouteActionRewrite) (*RuleActionDNSRewrite, error) {
+	action := &RuleActionDNSRewrite{
+		CNAME:        options.CNAME,
+		StripAAAA:    options.StripAAAA,
+		ClientSubnet: netip.Prefix(common.PtrValueOrDefault(options.ClientSubnet)),
+	}
+	if action.CNAME != "" {
+		if _, isDomain := dns.IsDomainName(action.CNAME); !isDomain {
+			return nil, E.New("invalid cname target: ", action.CNAME)
+		}
+	}
+	if len(options.FilterIPCIDR) > 0 {
+		item, err := NewIPCIDRItem(false, options.FilterIPCIDR)
+		if err != nil {
+			return nil, E.Cause(err, "filter_ip_cidr")
+		}
+		action.filterItems = append(action.filterItems, item)
+	}
+	if len(options.FilterRuleSet) > 0 {
+		router := service.FromContext[adapter.Router](ctx)
+		if router == nil {
+			return nil, E.New("missing router in context")
+		}
+		action.filterItems = append(action.filterItems, NewRuleSetItem(router, options.FilterRuleSet, false, false))
+	}
+	return action, nil
+}
+
+func (r *RuleActionDNSRewrite) Type() string {
+	return C.RuleActionTypeRewrite
+}
+
+func (r *RuleActionDNSRewrite) Start() error {
+	for _, item := range r.filterItems {
+		if starter, isStarter := item.(interface {
+			Start() error
+		}); isStarter {
+			err := starter.Start()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FilterAddress reports whether the address should be removed from responses.
+func (r *RuleActionDNSRewrite) FilterAddress(addr netip.Addr) bool {
+	if len(r.filterItems) == 0 {
+		return false
+	}
+	metadata := adapter.InboundContext{
+		Destination: M.SocksaddrFrom(addr, 0),
+	}
+	for _, item := range r.filterItems {
+		metadata.ResetRuleMatchCache()
+		if item.Match(&metadata) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RuleActionDNSRewrite) String() string {
+	var descriptions []string
+	if r.CNAME != "" {
+		descriptions = append(descriptions, F.ToString("cname=", r.CNAME))
+	}
+	if r.StripAAAA {
+		descriptions = append(descriptions, "strip-aaaa")
+	}
+	for _, item := range r.filterItems {
+		descriptions = append(descriptions, F.ToString("filter-", item))
+	}
+	if r.ClientSubnet.IsValid() {
+		descriptions = append(descriptions, F.ToString("client-subnet=", r.ClientSubnet))
+	}
+	return F.ToString("rewrite(", strings.Join(descriptions, ","), ")")
+}
+
 type RuleActionDirect struct {
 	Dialer      N.Dialer
 	description string
@@ -417,6 +566,7 @@ type RuleActionSniff struct {
 	StreamSniffers []sniff.StreamSniffer
 	PacketSniffers []sniff.PacketSniffer
 	Timeout        time.Duration
+	AmneziaWG      *option.SniffAmneziaWGOptions
 	// Deprecated
 	OverrideDestination bool
 }
@@ -451,10 +601,33 @@ func (r *RuleActionSniff) build() error {
 			r.StreamSniffers = append(r.StreamSniffers, sniff.RDP)
 		case C.ProtocolNTP:
 			r.PacketSniffers = append(r.PacketSniffers, sniff.NTP)
+		case C.ProtocolWireGuard:
+			r.PacketSniffers = append(r.PacketSniffers, sniff.WireGuard)
+			if r.AmneziaWG != nil {
+				amneziaWG, err := sniff.NewAmneziaWG(*r.AmneziaWG)
+				if err != nil {
+					return E.Cause(err, "create AmneziaWG sniffer")
+				}
+				r.PacketSniffers = append(r.PacketSniffers, amneziaWG)
+			}
+		case C.ProtocolOpenVPN:
+			r.StreamSniffers = append(r.StreamSniffers, sniff.StreamOpenVPN)
+			r.PacketSniffers = append(r.PacketSniffers, sniff.OpenVPN)
+		case C.ProtocolSMTP, C.ProtocolIMAP, C.ProtocolPOP3:
+			// classified by the server-first port in the router, not sniffed
+		case C.ProtocolMQTT:
+			r.StreamSniffers = append(r.StreamSniffers, sniff.MQTT)
+		case C.ProtocolRTMP:
+			r.StreamSniffers = append(r.StreamSniffers, sniff.RTMP)
+		case C.ProtocolSOCKS:
+			r.StreamSniffers = append(r.StreamSniffers, sniff.SOCKS)
 		default:
 			return E.New("unknown sniffer: ", name)
 		}
 	}
+	if r.AmneziaWG != nil && !common.Contains(r.SnifferNames, C.ProtocolWireGuard) {
+		return E.New("AmneziaWG options require the wireguard sniffer")
+	}
 	return nil
 }
 
@@ -506,6 +679,18 @@ func (r *RuleActionResolve) String() string {
 	}
 }
 
+type RuleActionHTTPRewrite struct {
+	Rewriter *httprewrite.Rewriter
+}
+
+func (r *RuleActionHTTPRewrite) Type() string {
+	return C.RuleActionTypeHTTPRewrite
+}
+
+func (r *RuleActionHTTPRewrite) String() string {
+	return F.ToString("http-rewrite(", r.Rewriter, ")")
+}
+
 type RuleActionPredefined struct {
 	Rcode  int
 	Answer []dns.RR
@@ -104,6 +104,55 @@ func NewDefaultRule(ctx context.Context, logger log.ContextLogger, options optio
 		rule.items = append(rule.items, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.HTTPMethod) > 0 {
+		item := NewHTTPMethodItem(options.HTTPMethod)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.HTTPPathPrefix) > 0 {
+		item := NewHTTPPathPrefixItem(options.HTTPPathPrefix)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.HTTPPathRegex) > 0 {
+		item, err := NewHTTPPathRegexItem(options.HTTPPathRegex)
+		if err != nil {
+			return nil, E.Cause(err, "http_path_regex")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if options.HTTPHeader != nil && options.HTTPHeader.Size() > 0 {
+		item, err := NewHTTPHeaderItem(options.HTTPHeader)
+		if err != nil {
+			return nil, E.Cause(err, "http_header")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.TLSFingerprint) > 0 {
+		item := NewTLSFingerprintItem(options.TLSFingerprint)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.TLSJA4) > 0 {
+		item := NewTLSJA4Item(options.TLSJA4)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.TLSALPN) > 0 {
+		item := NewTLSALPNItem(options.TLSALPN)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.TLSVersion) > 0 {
+		item, err := NewTLSVersionItem(options.TLSVersion)
+		if err != nil {
+			return nil, E.Cause(err, "tls_version")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.Domain) > 0 || len(options.DomainSuffix) > 0 {
 		item, err := NewDomainItem(options.Domain, options.DomainSuffix)
 		if err != nil {
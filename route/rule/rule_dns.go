@@ -52,10 +52,14 @@ func (r *DefaultDNSRule) matchStates(metadata *adapter.InboundContext) ruleMatch
 }
 
 func NewDefaultDNSRule(ctx context.Context, logger log.ContextLogger, options option.DefaultDNSRule) (*DefaultDNSRule, error) {
+	action, err := NewDNSRuleAction(ctx, logger, options.DNSRuleAction)
+	if err != nil {
+		return nil, E.Cause(err, "action")
+	}
 	rule := &DefaultDNSRule{
 		abstractDefaultRule: abstractDefaultRule{
 			invert: options.Invert,
-			action: NewDNSRuleAction(logger, options.DNSRuleAction),
+			action: action,
 		},
 	}
 	if len(options.Inbound) > 0 {
@@ -321,11 +325,15 @@ func (r *LogicalDNSRule) matchStates(metadata *adapter.InboundContext) ruleMatch
 }
 
 func NewLogicalDNSRule(ctx context.Context, logger log.ContextLogger, options option.LogicalDNSRule) (*LogicalDNSRule, error) {
+	action, err := NewDNSRuleAction(ctx, logger, options.DNSRuleAction)
+	if err != nil {
+		return nil, E.Cause(err, "action")
+	}
 	r := &LogicalDNSRule{
 		abstractLogicalRule: abstractLogicalRule{
 			rules:  make([]adapter.HeadlessRule, len(options.Rules)),
 			invert: options.Invert,
-			action: NewDNSRuleAction(logger, options.DNSRuleAction),
+			action: action,
 		},
 	}
 	switch options.Mode {
@@ -0,0 +1,80 @@
+package rule
+
+import (
+	"context"
+
+	"github.com/sagernet/sing-box/adapter"
+)
+
+// Explainer records every rule evaluated while matching a connection or DNS query.
+type Explainer struct {
+	Rules []adapter.RuleExplanation
+}
+
+type (
+	explainerKey    struct{}
+	dnsExplainerKey struct{}
+)
+
+func ContextWithExplainer(ctx context.Context, explainer *Explainer) context.Context {
+	return context.WithValue(ctx, explainerKey{}, explainer)
+}
+
+func ExplainerFromContext(ctx context.Context) *Explainer {
+	explainer, _ := ctx.Value(explainerKey{}).(*Explainer)
+	return explainer
+}
+
+// ContextWithDNSExplainer is separate from ContextWithExplainer, so that DNS queries
+// made by route rules, like resolve, do not record into the route explanation.
+func ContextWithDNSExplainer(ctx context.Context, explainer *Explainer) context.Context {
+	return context.WithValue(ctx, dnsExplainerKey{}, explainer)
+}
+
+func DNSExplainerFromContext(ctx context.Context) *Explainer {
+	explainer, _ := ctx.Value(dnsExplainerKey{}).(*Explainer)
+	return explainer
+}
+
+// Record is a no-op on a nil Explainer, so callers do not need to check whether explanation is requested.
+func (e *Explainer) Record(index int, rule adapter.Rule, metadata *adapter.InboundContext, matched bool) {
+	if e == nil {
+		return
+	}
+	explanation := adapter.RuleExplanation{
+		Index:   index,
+		Rule:    rule.String(),
+		Action:  rule.Action().String(),
+		Matched: matched,
+	}
+	if explainableRule, isExplainable := rule.(adapter.RuleWithExplanation); isExplainable {
+		explanation.Items = explainableRule.ExplainItems(metadata)
+	}
+	e.Rules = append(e.Rules, explanation)
+}
+
+func (r *abstractDefaultRule) ExplainItems(metadata *adapter.InboundContext) []adapter.RuleItemExplanation {
+	items := make([]adapter.RuleItemExplanation, 0, len(r.allItems))
+	for _, item := range r.allItems {
+		itemMetadata := *metadata
+		itemMetadata.ResetRuleCache()
+		items = append(items, adapter.RuleItemExplanation{
+			Item:    item.String(),
+			Matched: item.Match(&itemMetadata),
+		})
+	}
+	return items
+}
+
+func (r *abstractLogicalRule) ExplainItems(metadata *adapter.InboundContext) []adapter.RuleItemExplanation {
+	items := make([]adapter.RuleItemExplanation, 0, len(r.rules))
+	for _, rule := range r.rules {
+		nestedMetadata := *metadata
+		nestedMetadata.ResetRuleCache()
+		items = append(items, adapter.RuleItemExplanation{
+			Item:    rule.String(),
+			Matched: rule.Match(&nestedMetadata),
+		})
+	}
+	return items
+}
@@ -0,0 +1,40 @@
+package rule
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainerRecord(t *testing.T) {
+	t.Parallel()
+	rule := routeRuleForTest(func(rule *abstractDefaultRule) {
+		addDestinationAddressItem(t, rule, []string{"www.example.com"}, nil)
+		addDestinationPortItem(rule, []uint16{80})
+		rule.action = &RuleActionRoute{Outbound: "direct"}
+	})
+	require.Nil(t, ExplainerFromContext(context.Background()))
+	explainer := &Explainer{}
+	ctx := ContextWithExplainer(context.Background(), explainer)
+	require.Same(t, explainer, ExplainerFromContext(ctx))
+	require.Nil(t, DNSExplainerFromContext(ctx))
+
+	metadata := testMetadata("www.example.com")
+	ExplainerFromContext(ctx).Record(3, rule, &metadata, rule.Match(&metadata))
+	require.Len(t, explainer.Rules, 1)
+	explanation := explainer.Rules[0]
+	require.Equal(t, 3, explanation.Index)
+	require.False(t, explanation.Matched)
+	require.Equal(t, rule.String(), explanation.Rule)
+	require.Equal(t, "route(direct)", explanation.Action)
+	require.Equal(t, []adapter.RuleItemExplanation{
+		{Item: rule.allItems[0].String(), Matched: true},
+		{Item: rule.allItems[1].String(), Matched: false},
+	}, explanation.Items)
+
+	var nilExplainer *Explainer
+	nilExplainer.Record(0, rule, &metadata, false)
+}
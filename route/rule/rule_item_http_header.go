@@ -0,0 +1,76 @@
+package rule
+
+import (
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json/badjson"
+	"github.com/sagernet/sing/common/json/badoption"
+)
+
+var _ RuleItem = (*HTTPHeaderItem)(nil)
+
+type HTTPHeaderItem struct {
+	headers     map[string][]*regexp.Regexp
+	description string
+}
+
+func NewHTTPHeaderItem(headers *badjson.TypedMap[string, badoption.Listable[string]]) (*HTTPHeaderItem, error) {
+	item := &HTTPHeaderItem{
+		headers: make(map[string][]*regexp.Regexp, headers.Size()),
+	}
+	var entryDescriptions []string
+	for _, entry := range headers.Entries() {
+		matchers := make([]*regexp.Regexp, 0, len(entry.Value))
+		for i, regex := range entry.Value {
+			matcher, err := regexp.Compile(regex)
+			if err != nil {
+				return nil, E.Cause(err, "parse expression ", i, " of ", entry.Key)
+			}
+			matchers = append(matchers, matcher)
+		}
+		item.headers[textproto.CanonicalMIMEHeaderKey(entry.Key)] = matchers
+		entryDescriptions = append(entryDescriptions, entry.Key+"="+strings.Join(entry.Value, ","))
+	}
+	item.description = "http_header=[" + strings.Join(entryDescriptions, " ") + "]"
+	return item, nil
+}
+
+func (r *HTTPHeaderItem) Match(metadata *adapter.InboundContext) bool {
+	request := sniff.SniffedHTTPRequest(metadata)
+	if request == nil {
+		return false
+	}
+	for key, matchers := range r.headers {
+		var values []string
+		if key == "Host" {
+			// moved out of the header by the request parser
+			values = []string{request.Host}
+		} else {
+			values = request.Header.Values(key)
+		}
+		if !r.matchHeader(values, matchers) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *HTTPHeaderItem) matchHeader(values []string, matchers []*regexp.Regexp) bool {
+	for _, value := range values {
+		for _, matcher := range matchers {
+			if matcher.MatchString(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *HTTPHeaderItem) String() string {
+	return r.description
+}
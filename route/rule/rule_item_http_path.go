@@ -0,0 +1,87 @@
+package rule
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var (
+	_ RuleItem = (*HTTPPathPrefixItem)(nil)
+	_ RuleItem = (*HTTPPathRegexItem)(nil)
+)
+
+type HTTPPathPrefixItem struct {
+	prefixes []string
+}
+
+func NewHTTPPathPrefixItem(prefixes []string) *HTTPPathPrefixItem {
+	return &HTTPPathPrefixItem{prefixes}
+}
+
+func (r *HTTPPathPrefixItem) Match(metadata *adapter.InboundContext) bool {
+	request := sniff.SniffedHTTPRequest(metadata)
+	if request == nil {
+		return false
+	}
+	for _, prefix := range r.prefixes {
+		if strings.HasPrefix(request.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *HTTPPathPrefixItem) String() string {
+	if len(r.prefixes) == 1 {
+		return F.ToString("http_path_prefix=", r.prefixes[0])
+	}
+	return F.ToString("http_path_prefix=[", strings.Join(r.prefixes, " "), "]")
+}
+
+type HTTPPathRegexItem struct {
+	matchers    []*regexp.Regexp
+	description string
+}
+
+func NewHTTPPathRegexItem(expressions []string) (*HTTPPathRegexItem, error) {
+	matchers := make([]*regexp.Regexp, 0, len(expressions))
+	for i, regex := range expressions {
+		matcher, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, E.Cause(err, "parse expression ", i)
+		}
+		matchers = append(matchers, matcher)
+	}
+	description := "http_path_regex="
+	eLen := len(expressions)
+	if eLen == 1 {
+		description += expressions[0]
+	} else if eLen > 3 {
+		description += F.ToString("[", strings.Join(expressions[:3], " "), "]")
+	} else {
+		description += F.ToString("[", strings.Join(expressions, " "), "]")
+	}
+	return &HTTPPathRegexItem{matchers, description}, nil
+}
+
+func (r *HTTPPathRegexItem) Match(metadata *adapter.InboundContext) bool {
+	request := sniff.SniffedHTTPRequest(metadata)
+	if request == nil {
+		return false
+	}
+	for _, matcher := range r.matchers {
+		if matcher.MatchString(request.URL.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *HTTPPathRegexItem) String() string {
+	return r.description
+}
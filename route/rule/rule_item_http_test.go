@@ -0,0 +1,102 @@
+package rule
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	"github.com/sagernet/sing/common/json/badjson"
+	"github.com/sagernet/sing/common/json/badoption"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPRuleItems(t *testing.T) {
+	t.Parallel()
+	request := &http.Request{
+		Method: http.MethodPost,
+		URL:    &url.URL{Path: "/api/v1/upload", RawQuery: "token=secret"},
+		Host:   "www.example.com",
+		Header: http.Header{
+			"User-Agent": []string{"curl/8.5.0"},
+			"Accept":     []string{"text/html", "application/json"},
+		},
+	}
+	pathRegexItem, err := NewHTTPPathRegexItem([]string{`^/api/v\d+/`})
+	require.NoError(t, err)
+	queryRegexItem, err := NewHTTPPathRegexItem([]string{`token=`})
+	require.NoError(t, err)
+	testCases := []struct {
+		name    string
+		item    RuleItem
+		matched bool
+	}{
+		{"method", NewHTTPMethodItem([]string{"GET", "POST"}), true},
+		{"method case insensitive", NewHTTPMethodItem([]string{"post"}), true},
+		{"method mismatch", NewHTTPMethodItem([]string{"GET"}), false},
+		{"path prefix", NewHTTPPathPrefixItem([]string{"/static/", "/api/"}), true},
+		{"path prefix mismatch", NewHTTPPathPrefixItem([]string{"/static/"}), false},
+		{"path regex", pathRegexItem, true},
+		{"path regex excludes query", queryRegexItem, false},
+		{"header", mustHTTPHeaderItem(t, map[string][]string{"user-agent": {`^curl/`}}), true},
+		{"header any value", mustHTTPHeaderItem(t, map[string][]string{"Accept": {`json`}}), true},
+		{"header host", mustHTTPHeaderItem(t, map[string][]string{"Host": {`example\.com$`}}), true},
+		{"header all keys", mustHTTPHeaderItem(t, map[string][]string{"User-Agent": {`^curl/`}, "Accept": {`^image/`}}), false},
+		{"header missing", mustHTTPHeaderItem(t, map[string][]string{"Cookie": {`.*`}}), false},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			metadata := adapter.InboundContext{SniffContext: request}
+			require.Equal(t, testCase.matched, testCase.item.Match(&metadata))
+			metadata = adapter.InboundContext{SniffContext: &sniff.InterceptedRequest{Request: request}}
+			require.Equal(t, testCase.matched, testCase.item.Match(&metadata), "request decrypted by mitm")
+		})
+	}
+}
+
+func TestHTTPRuleItemsWithoutRequest(t *testing.T) {
+	t.Parallel()
+	pathRegexItem, err := NewHTTPPathRegexItem([]string{`.*`})
+	require.NoError(t, err)
+	items := []RuleItem{
+		NewHTTPMethodItem([]string{"GET"}),
+		NewHTTPPathPrefixItem([]string{"/"}),
+		pathRegexItem,
+		mustHTTPHeaderItem(t, map[string][]string{"Host": {`.*`}}),
+	}
+	for _, metadata := range []adapter.InboundContext{
+		{},
+		{SniffContext: &sniff.TLSFingerprint{}},
+		{SniffContext: &sniff.InterceptedRequest{Fingerprint: &sniff.TLSFingerprint{}}},
+	} {
+		for _, item := range items {
+			require.False(t, item.Match(&metadata), item.String())
+		}
+	}
+}
+
+func TestHTTPRuleItemInvalidRegex(t *testing.T) {
+	t.Parallel()
+	_, err := NewHTTPPathRegexItem([]string{`(`})
+	require.Error(t, err)
+	_, err = NewHTTPHeaderItem(httpHeaderMap(map[string][]string{"Host": {`(`}}))
+	require.Error(t, err)
+}
+
+func mustHTTPHeaderItem(t *testing.T, headers map[string][]string) *HTTPHeaderItem {
+	t.Helper()
+	item, err := NewHTTPHeaderItem(httpHeaderMap(headers))
+	require.NoError(t, err)
+	return item
+}
+
+func httpHeaderMap(headers map[string][]string) *badjson.TypedMap[string, badoption.Listable[string]] {
+	headerMap := new(badjson.TypedMap[string, badoption.Listable[string]])
+	for key, values := range headers {
+		headerMap.Put(key, values)
+	}
+	return headerMap
+}
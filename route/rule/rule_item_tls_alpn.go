@@ -0,0 +1,47 @@
+package rule
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ RuleItem = (*TLSALPNItem)(nil)
+
+type TLSALPNItem struct {
+	protocols   []string
+	protocolMap map[string]bool
+}
+
+func NewTLSALPNItem(protocols []string) *TLSALPNItem {
+	protocolMap := make(map[string]bool)
+	for _, protocol := range protocols {
+		protocolMap[protocol] = true
+	}
+	return &TLSALPNItem{
+		protocols:   protocols,
+		protocolMap: protocolMap,
+	}
+}
+
+func (r *TLSALPNItem) Match(metadata *adapter.InboundContext) bool {
+	fingerprint := sniff.SniffedTLSFingerprint(metadata)
+	if fingerprint == nil {
+		return false
+	}
+	for _, protocol := range fingerprint.ALPN {
+		if r.protocolMap[protocol] {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *TLSALPNItem) String() string {
+	if len(r.protocols) == 1 {
+		return F.ToString("tls_alpn=", r.protocols[0])
+	}
+	return F.ToString("tls_alpn=[", strings.Join(r.protocols, " "), "]")
+}
@@ -0,0 +1,77 @@
+package rule
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var (
+	_ RuleItem = (*TLSFingerprintItem)(nil)
+	_ RuleItem = (*TLSJA4Item)(nil)
+)
+
+type TLSFingerprintItem struct {
+	fingerprints   []string
+	fingerprintMap map[string]bool
+}
+
+func NewTLSFingerprintItem(fingerprints []string) *TLSFingerprintItem {
+	fingerprintMap := make(map[string]bool)
+	for _, fingerprint := range fingerprints {
+		fingerprintMap[strings.ToLower(fingerprint)] = true
+	}
+	return &TLSFingerprintItem{
+		fingerprints:   fingerprints,
+		fingerprintMap: fingerprintMap,
+	}
+}
+
+func (r *TLSFingerprintItem) Match(metadata *adapter.InboundContext) bool {
+	fingerprint := sniff.SniffedTLSFingerprint(metadata)
+	if fingerprint == nil {
+		return false
+	}
+	return r.fingerprintMap[strings.ToLower(fingerprint.JA3)]
+}
+
+func (r *TLSFingerprintItem) String() string {
+	if len(r.fingerprints) == 1 {
+		return F.ToString("tls_fingerprint=", r.fingerprints[0])
+	}
+	return F.ToString("tls_fingerprint=[", strings.Join(r.fingerprints, " "), "]")
+}
+
+// TLSJA4Item matches case-insensitively, as the ALPN characters in the first part of a JA4 fingerprint keep their case.
+type TLSJA4Item struct {
+	fingerprints   []string
+	fingerprintMap map[string]bool
+}
+
+func NewTLSJA4Item(fingerprints []string) *TLSJA4Item {
+	fingerprintMap := make(map[string]bool)
+	for _, fingerprint := range fingerprints {
+		fingerprintMap[strings.ToLower(fingerprint)] = true
+	}
+	return &TLSJA4Item{
+		fingerprints:   fingerprints,
+		fingerprintMap: fingerprintMap,
+	}
+}
+
+func (r *TLSJA4Item) Match(metadata *adapter.InboundContext) bool {
+	fingerprint := sniff.SniffedTLSFingerprint(metadata)
+	if fingerprint == nil {
+		return false
+	}
+	return r.fingerprintMap[strings.ToLower(fingerprint.JA4)]
+}
+
+func (r *TLSJA4Item) String() string {
+	if len(r.fingerprints) == 1 {
+		return F.ToString("tls_ja4=", r.fingerprints[0])
+	}
+	return F.ToString("tls_ja4=[", strings.Join(r.fingerprints, " "), "]")
+}
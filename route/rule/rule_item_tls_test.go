@@ -0,0 +1,58 @@
+package rule
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSRuleItems(t *testing.T) {
+	t.Parallel()
+	fingerprint := &sniff.TLSFingerprint{
+		JA3:     "579ccef312d18482fc42e2b822ca2430",
+		JA4:     "t13d1516H2_8daaf6152771_e5627efa2ab1",
+		ALPN:    []string{"h2", "http/1.1"},
+		Version: tls.VersionTLS13,
+	}
+	versionItem, err := NewTLSVersionItem([]string{"1.2", "1.3"})
+	require.NoError(t, err)
+	legacyVersionItem, err := NewTLSVersionItem([]string{"1.0", "1.1"})
+	require.NoError(t, err)
+	testCases := []struct {
+		name    string
+		item    RuleItem
+		matched bool
+	}{
+		{"ja3", NewTLSFingerprintItem([]string{"579ccef312d18482fc42e2b822ca2430"}), true},
+		{"ja3 uppercase", NewTLSFingerprintItem([]string{"579CCEF312D18482FC42E2B822CA2430"}), true},
+		{"ja3 mismatch", NewTLSFingerprintItem([]string{"00000000000000000000000000000000"}), false},
+		{"ja4 exact", NewTLSJA4Item([]string{"t13d1516H2_8daaf6152771_e5627efa2ab1"}), true},
+		{"ja4 lowercase", NewTLSJA4Item([]string{"t13d1516h2_8daaf6152771_e5627efa2ab1"}), true},
+		{"ja4 uppercase", NewTLSJA4Item([]string{"T13D1516H2_8DAAF6152771_E5627EFA2AB1"}), true},
+		{"ja4 mismatch", NewTLSJA4Item([]string{"t12d1516h2_8daaf6152771_e5627efa2ab1"}), false},
+		{"alpn", NewTLSALPNItem([]string{"http/1.1"}), true},
+		{"alpn mismatch", NewTLSALPNItem([]string{"h3"}), false},
+		{"version", versionItem, true},
+		{"version mismatch", legacyVersionItem, false},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			metadata := adapter.InboundContext{SniffContext: fingerprint}
+			require.Equal(t, testCase.matched, testCase.item.Match(&metadata))
+			metadata = adapter.InboundContext{SniffContext: &sniff.InterceptedRequest{Fingerprint: fingerprint}}
+			require.Equal(t, testCase.matched, testCase.item.Match(&metadata), "fingerprint kept by mitm")
+			require.False(t, testCase.item.Match(&adapter.InboundContext{}), "no sniffed client hello")
+		})
+	}
+}
+
+func TestTLSVersionItemInvalid(t *testing.T) {
+	t.Parallel()
+	_, err := NewTLSVersionItem([]string{"1.4"})
+	require.Error(t, err)
+}
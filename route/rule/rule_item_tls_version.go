@@ -0,0 +1,55 @@
+package rule
+
+import (
+	"crypto/tls"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ RuleItem = (*TLSVersionItem)(nil)
+
+type TLSVersionItem struct {
+	versions   []string
+	versionMap map[uint16]bool
+}
+
+func NewTLSVersionItem(versions []string) (*TLSVersionItem, error) {
+	versionMap := make(map[uint16]bool)
+	for _, version := range versions {
+		switch version {
+		case "1.0":
+			versionMap[tls.VersionTLS10] = true
+		case "1.1":
+			versionMap[tls.VersionTLS11] = true
+		case "1.2":
+			versionMap[tls.VersionTLS12] = true
+		case "1.3":
+			versionMap[tls.VersionTLS13] = true
+		default:
+			return nil, E.New("unknown tls version: ", version)
+		}
+	}
+	return &TLSVersionItem{
+		versions:   versions,
+		versionMap: versionMap,
+	}, nil
+}
+
+func (r *TLSVersionItem) Match(metadata *adapter.InboundContext) bool {
+	fingerprint := sniff.SniffedTLSFingerprint(metadata)
+	if fingerprint == nil {
+		return false
+	}
+	return r.versionMap[fingerprint.Version]
+}
+
+func (r *TLSVersionItem) String() string {
+	if len(r.versions) == 1 {
+		return F.ToString("tls_version=", r.versions[0])
+	}
+	return F.ToString("tls_version=[", strings.Join(r.versions, " "), "]")
+}
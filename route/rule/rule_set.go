@@ -2,13 +2,16 @@ package rule
 
 import (
 	"context"
+	"time"
 
 	"github.com/sagernet/sing-box/adapter"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common"
 	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
 	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/service"
 
 	"go4.org/netipx"
 )
@@ -24,6 +27,31 @@ func NewRuleSet(ctx context.Context, logger logger.ContextLogger, options option
 	}
 }
 
+func reportRuleSetReload(ctx context.Context, tag string, setType string, previousRuleCount int, ruleCount int, err error) {
+	tracker, loaded := service.FromContext[adapter.Router](ctx).(adapter.RuleSetTracker)
+	if !loaded {
+		return
+	}
+	event := adapter.RuleSetReloadEvent{
+		Time:              time.Now(),
+		Tag:               tag,
+		Type:              setType,
+		RuleCount:         ruleCount,
+		PreviousRuleCount: previousRuleCount,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	tracker.RuleSetReloaded(event)
+}
+
+func formatRuleCountDelta(delta int) string {
+	if delta >= 0 {
+		return F.ToString("+", delta)
+	}
+	return F.ToString(delta)
+}
+
 func extractIPSetFromRule(rawRule adapter.HeadlessRule) []*netipx.IPSet {
 	switch rule := rawRule.(type) {
 	case *DefaultHeadlessRule:
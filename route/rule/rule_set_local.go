@@ -7,6 +7,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/sagernet/fswatch"
 	"github.com/sagernet/sing-box/adapter"
@@ -24,19 +25,26 @@ import (
 	"go4.org/netipx"
 )
 
-var _ adapter.RuleSet = (*LocalRuleSet)(nil)
+var (
+	_ adapter.RuleSetWithStatistics = (*LocalRuleSet)(nil)
+	_ adapter.RuleSetWithUpdate     = (*LocalRuleSet)(nil)
+)
 
 type LocalRuleSet struct {
-	ctx        context.Context
-	logger     logger.Logger
-	tag        string
-	access     sync.RWMutex
-	rules      []adapter.HeadlessRule
-	metadata   adapter.RuleSetMetadata
-	fileFormat string
-	watcher    *fswatch.Watcher
-	callbacks  list.List[adapter.RuleSetUpdateCallback]
-	refs       atomic.Int32
+	ctx          context.Context
+	logger       logger.Logger
+	tag          string
+	setType      string
+	access       sync.RWMutex
+	updateAccess sync.Mutex
+	rules        []adapter.HeadlessRule
+	metadata     adapter.RuleSetMetadata
+	fileFormat   string
+	filePath     string
+	lastLoaded   time.Time
+	watcher      *fswatch.Watcher
+	callbacks    list.List[adapter.RuleSetUpdateCallback]
+	refs         atomic.Int32
 }
 
 func NewLocalRuleSet(ctx context.Context, logger logger.Logger, options option.RuleSet) (*LocalRuleSet, error) {
@@ -44,6 +52,7 @@ func NewLocalRuleSet(ctx context.Context, logger logger.Logger, options option.R
 		ctx:        ctx,
 		logger:     logger,
 		tag:        options.Tag,
+		setType:    options.Type,
 		fileFormat: options.Format,
 	}
 	if options.Type == C.RuleSetTypeInline {
@@ -57,6 +66,7 @@ func NewLocalRuleSet(ctx context.Context, logger logger.Logger, options option.R
 	} else {
 		filePath := filemanager.BasePath(ctx, options.LocalOptions.Path)
 		filePath, _ = filepath.Abs(filePath)
+		ruleSet.filePath = filePath
 		err := ruleSet.reloadFile(filePath)
 		if err != nil {
 			return nil, err
@@ -64,7 +74,7 @@ func NewLocalRuleSet(ctx context.Context, logger logger.Logger, options option.R
 		watcher, err := fswatch.NewWatcher(fswatch.Options{
 			Path: []string{filePath},
 			Callback: func(path string) {
-				uErr := ruleSet.reloadFile(path)
+				uErr := ruleSet.Update(ctx)
 				if uErr != nil {
 					logger.Error(E.Cause(uErr, "reload rule-set ", options.Tag))
 				}
@@ -96,6 +106,29 @@ func (s *LocalRuleSet) StartContext(ctx context.Context, startContext *adapter.H
 	return nil
 }
 
+// Update reloads the rule-set file. Inline rule-sets have nothing to reload.
+// Reloads from the file watcher and from the Clash API are serialized,
+// so that each reported delta is taken against the rules it replaced.
+func (s *LocalRuleSet) Update(ctx context.Context) error {
+	if s.filePath == "" {
+		return nil
+	}
+	s.updateAccess.Lock()
+	defer s.updateAccess.Unlock()
+	s.access.RLock()
+	previousRuleCount := len(s.rules)
+	s.access.RUnlock()
+	err := s.reloadFile(s.filePath)
+	s.access.RLock()
+	ruleCount := len(s.rules)
+	s.access.RUnlock()
+	if err == nil {
+		s.logger.Info("reloaded rule-set ", s.tag, ": ", ruleCount, " rules (", formatRuleCountDelta(ruleCount-previousRuleCount), ")")
+	}
+	reportRuleSetReload(s.ctx, s.tag, s.setType, previousRuleCount, ruleCount, err)
+	return err
+}
+
 func (s *LocalRuleSet) reloadFile(path string) error {
 	var ruleSet option.PlainRuleSetCompat
 	switch s.fileFormat {
@@ -110,11 +143,8 @@ func (s *LocalRuleSet) reloadFile(path string) error {
 		}
 
 	case C.RuleSetFormatBinary:
-		setFile, err := os.Open(path)
-		if err != nil {
-			return err
-		}
-		ruleSet, err = srs.Read(setFile, false)
+		var err error
+		ruleSet, err = srs.ReadFile(path, false)
 		if err != nil {
 			return err
 		}
@@ -144,6 +174,7 @@ func (s *LocalRuleSet) reloadRules(headlessRules []option.HeadlessRule) error {
 	s.access.Lock()
 	s.rules = rules
 	s.metadata = metadata
+	s.lastLoaded = time.Now()
 	callbacks := s.callbacks.Array()
 	s.access.Unlock()
 	for _, callback := range callbacks {
@@ -162,6 +193,17 @@ func (s *LocalRuleSet) Metadata() adapter.RuleSetMetadata {
 	return s.metadata
 }
 
+func (s *LocalRuleSet) Statistics() adapter.RuleSetStatistics {
+	s.access.RLock()
+	defer s.access.RUnlock()
+	return adapter.RuleSetStatistics{
+		Type:        s.setType,
+		Format:      s.fileFormat,
+		LastUpdated: s.lastLoaded,
+		RuleCount:   len(s.rules),
+	}
+}
+
 func (s *LocalRuleSet) ExtractIPSet() []*netipx.IPSet {
 	s.access.RLock()
 	defer s.access.RUnlock()
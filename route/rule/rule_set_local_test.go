@@ -0,0 +1,98 @@
+package rule
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRuleSetTracker struct {
+	adapter.Router
+	events []adapter.RuleSetReloadEvent
+}
+
+func (t *testRuleSetTracker) RuleSetReloaded(event adapter.RuleSetReloadEvent) {
+	t.events = append(t.events, event)
+}
+
+func TestLocalRuleSetUpdate(t *testing.T) {
+	t.Parallel()
+	tracker := &testRuleSetTracker{}
+	ctx := service.ContextWith[adapter.Router](context.Background(), tracker)
+	path := filepath.Join(t.TempDir(), "rule-set.json")
+	writeRuleSet := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	writeRuleSet(`{"version":3,"rules":[{"domain":"a.example"}]}`)
+	ruleSet, err := NewLocalRuleSet(ctx, logger.NOP(), option.RuleSet{
+		Type:         C.RuleSetTypeLocal,
+		Tag:          "test",
+		Format:       C.RuleSetFormatSource,
+		LocalOptions: option.LocalRuleSet{Path: path},
+	})
+	require.NoError(t, err)
+	defer ruleSet.Close()
+
+	writeRuleSet(`{"version":3,"rules":[{"domain":"a.example"},{"domain":"b.example"},{"domain":"c.example"}]}`)
+	require.NoError(t, ruleSet.Update(ctx))
+	writeRuleSet(`{"version":3,"rules":[{"unknown_field":true}]}`)
+	require.Error(t, ruleSet.Update(ctx))
+
+	require.Len(t, tracker.events, 2)
+	require.Equal(t, "test", tracker.events[0].Tag)
+	require.Equal(t, 1, tracker.events[0].PreviousRuleCount)
+	require.Equal(t, 3, tracker.events[0].RuleCount)
+	require.Empty(t, tracker.events[0].Error)
+	require.Equal(t, 3, tracker.events[1].PreviousRuleCount)
+	require.Equal(t, 3, tracker.events[1].RuleCount)
+	require.NotEmpty(t, tracker.events[1].Error)
+	require.Equal(t, 3, ruleSet.Statistics().RuleCount)
+}
+
+func TestLocalRuleSetConcurrentUpdate(t *testing.T) {
+	t.Parallel()
+	tracker := &testRuleSetTracker{}
+	ctx := service.ContextWith[adapter.Router](context.Background(), tracker)
+	path := filepath.Join(t.TempDir(), "rule-set.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":3,"rules":[{"domain":"a.example"}]}`), 0o644))
+	ruleSet, err := NewLocalRuleSet(ctx, logger.NOP(), option.RuleSet{
+		Type:         C.RuleSetTypeLocal,
+		Tag:          "test",
+		Format:       C.RuleSetFormatSource,
+		LocalOptions: option.LocalRuleSet{Path: path},
+	})
+	require.NoError(t, err)
+	defer ruleSet.Close()
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":3,"rules":[{"domain":"a.example"},{"domain":"b.example"},{"domain":"c.example"}]}`), 0o644))
+	var group sync.WaitGroup
+	for range 8 {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			assert.NoError(t, ruleSet.Update(ctx))
+		}()
+	}
+	group.Wait()
+
+	require.Len(t, tracker.events, 8)
+	var changed int
+	for _, event := range tracker.events {
+		require.Equal(t, 3, event.RuleCount)
+		if event.PreviousRuleCount != event.RuleCount {
+			changed++
+		}
+	}
+	require.Equal(t, 1, changed, "only the first reload replaces the single rule")
+}
@@ -3,7 +3,9 @@ package rule
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"io"
 	"net"
 	"net/http"
@@ -14,6 +16,8 @@ import (
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/convertor/adguard"
+	"github.com/sagernet/sing-box/common/convertor/clash"
 	"github.com/sagernet/sing-box/common/srs"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
@@ -32,7 +36,10 @@ import (
 	"go4.org/netipx"
 )
 
-var _ adapter.RuleSet = (*RemoteRuleSet)(nil)
+var (
+	_ adapter.RuleSetWithStatistics = (*RemoteRuleSet)(nil)
+	_ adapter.RuleSetWithUpdate     = (*RemoteRuleSet)(nil)
+)
 
 type RemoteRuleSet struct {
 	ctx            context.Context
@@ -43,10 +50,15 @@ type RemoteRuleSet struct {
 	updateInterval time.Duration
 	dialer         N.Dialer
 	access         sync.RWMutex
+	updateAccess   sync.Mutex
 	rules          []adapter.HeadlessRule
 	metadata       adapter.RuleSetMetadata
 	lastUpdated    time.Time
 	lastEtag       string
+	version        uint8
+	checksum       string
+	ruleCount      int
+	ignoredCount   int
 	updateTicker   *time.Ticker
 	cacheFile      adapter.CacheFile
 	pauseManager   pause.Manager
@@ -96,16 +108,24 @@ func (s *RemoteRuleSet) StartContext(ctx context.Context, startContext *adapter.
 	s.dialer = dialer
 	if s.cacheFile != nil {
 		if savedSet := s.cacheFile.LoadRuleSet(s.options.Tag); savedSet != nil {
-			err := s.loadBytes(savedSet.Content)
+			format := s.options.Format
+			if isConvertedRuleSetFormat(format) {
+				format = C.RuleSetFormatBinary
+			}
+			err := s.loadBytes(savedSet.Content, format)
 			if err != nil {
 				return E.Cause(err, "restore cached rule-set")
 			}
 			s.lastUpdated = savedSet.LastUpdated
 			s.lastEtag = savedSet.LastEtag
+			s.ruleCount = savedSet.RuleCount
+			s.ignoredCount = savedSet.IgnoredCount
 		}
 	}
 	if s.lastUpdated.IsZero() {
+		s.updateAccess.Lock()
 		err := s.fetch(ctx, startContext)
+		s.updateAccess.Unlock()
 		if err != nil {
 			return E.Cause(err, "initial rule-set: ", s.options.Tag)
 		}
@@ -125,6 +145,21 @@ func (s *RemoteRuleSet) Metadata() adapter.RuleSetMetadata {
 	return s.metadata
 }
 
+func (s *RemoteRuleSet) Statistics() adapter.RuleSetStatistics {
+	s.access.RLock()
+	defer s.access.RUnlock()
+	return adapter.RuleSetStatistics{
+		Type:         C.RuleSetTypeRemote,
+		Format:       s.options.Format,
+		LastUpdated:  s.lastUpdated,
+		RuleCount:    s.ruleCount,
+		IgnoredCount: s.ignoredCount,
+		Version:      s.version,
+		Checksum:     s.checksum,
+		ETag:         s.lastEtag,
+	}
+}
+
 func (s *RemoteRuleSet) ExtractIPSet() []*netipx.IPSet {
 	s.access.RLock()
 	defer s.access.RUnlock()
@@ -159,12 +194,12 @@ func (s *RemoteRuleSet) UnregisterCallback(element *list.Element[adapter.RuleSet
 	s.callbacks.Remove(element)
 }
 
-func (s *RemoteRuleSet) loadBytes(content []byte) error {
+func (s *RemoteRuleSet) loadBytes(content []byte, format string) error {
 	var (
 		ruleSet option.PlainRuleSetCompat
 		err     error
 	)
-	switch s.options.Format {
+	switch format {
 	case C.RuleSetFormatSource:
 		ruleSet, err = json.UnmarshalExtended[option.PlainRuleSetCompat](content)
 		if err != nil {
@@ -176,7 +211,7 @@ func (s *RemoteRuleSet) loadBytes(content []byte) error {
 			return err
 		}
 	default:
-		return E.New("unknown rule-set format: ", s.options.Format)
+		return E.New("unknown rule-set format: ", format)
 	}
 	plainRuleSet, err := ruleSet.Upgrade()
 	if err != nil {
@@ -189,11 +224,19 @@ func (s *RemoteRuleSet) loadBytes(content []byte) error {
 			return E.Cause(err, "parse rule_set.rules.[", i, "]")
 		}
 	}
+	contentHash := sha256.Sum256(content)
+	checksum := hex.EncodeToString(contentHash[:])
 	s.access.Lock()
 	s.metadata.ContainsProcessRule = HasHeadlessRule(plainRuleSet.Rules, isProcessHeadlessRule)
 	s.metadata.ContainsWIFIRule = HasHeadlessRule(plainRuleSet.Rules, isWIFIHeadlessRule)
 	s.metadata.ContainsIPCIDRRule = HasHeadlessRule(plainRuleSet.Rules, isIPCIDRHeadlessRule)
 	s.rules = rules
+	s.version = ruleSet.Version
+	s.checksum = checksum
+	if !isConvertedRuleSetFormat(s.options.Format) {
+		s.ruleCount = len(rules)
+		s.ignoredCount = 0
+	}
 	callbacks := s.callbacks.Array()
 	s.access.Unlock()
 	for _, callback := range callbacks {
@@ -204,12 +247,7 @@ func (s *RemoteRuleSet) loadBytes(content []byte) error {
 
 func (s *RemoteRuleSet) loopUpdate() {
 	if time.Since(s.lastUpdated) > s.updateInterval {
-		err := s.fetch(s.ctx, nil)
-		if err != nil {
-			s.logger.Error("fetch rule-set ", s.options.Tag, ": ", err)
-		} else if s.refs.Load() == 0 {
-			s.rules = nil
-		}
+		s.updateOnce()
 	}
 	for {
 		runtime.GC()
@@ -223,12 +261,26 @@ func (s *RemoteRuleSet) loopUpdate() {
 }
 
 func (s *RemoteRuleSet) updateOnce() {
-	err := s.fetch(s.ctx, nil)
+	err := s.Update(s.ctx)
 	if err != nil {
 		s.logger.Error("fetch rule-set ", s.options.Tag, ": ", err)
-	} else if s.refs.Load() == 0 {
+	}
+}
+
+// Update fetches the rule-set immediately, regardless of update_interval.
+func (s *RemoteRuleSet) Update(ctx context.Context) error {
+	if s.dialer == nil {
+		return E.New("rule-set not started")
+	}
+	s.updateAccess.Lock()
+	defer s.updateAccess.Unlock()
+	previousRuleCount := s.Statistics().RuleCount
+	err := s.fetch(ctx, nil)
+	if err == nil && s.refs.Load() == 0 {
 		s.rules = nil
 	}
+	reportRuleSetReload(s.ctx, s.options.Tag, C.RuleSetTypeRemote, previousRuleCount, s.Statistics().RuleCount, err)
+	return err
 }
 
 func (s *RemoteRuleSet) fetch(ctx context.Context, startContext *adapter.HTTPStartContext) error {
@@ -287,12 +339,26 @@ func (s *RemoteRuleSet) fetch(ctx context.Context, startContext *adapter.HTTPSta
 		response.Body.Close()
 		return err
 	}
-	err = s.loadBytes(content)
+	response.Body.Close()
+	format := s.options.Format
+	var statistics adguard.Statistics
+	if isConvertedRuleSetFormat(format) {
+		content, statistics, err = s.compile(content)
+		if err != nil {
+			return err
+		}
+		format = C.RuleSetFormatBinary
+	}
+	err = s.loadBytes(content, format)
 	if err != nil {
-		response.Body.Close()
 		return err
 	}
-	response.Body.Close()
+	if isConvertedRuleSetFormat(s.options.Format) {
+		s.access.Lock()
+		s.ruleCount = statistics.Rules
+		s.ignoredCount = statistics.Ignored
+		s.access.Unlock()
+	}
 	eTagHeader := response.Header.Get("Etag")
 	if eTagHeader != "" {
 		s.lastEtag = eTagHeader
@@ -300,9 +366,11 @@ func (s *RemoteRuleSet) fetch(ctx context.Context, startContext *adapter.HTTPSta
 	s.lastUpdated = time.Now()
 	if s.cacheFile != nil {
 		err = s.cacheFile.SaveRuleSet(s.options.Tag, &adapter.SavedBinary{
-			LastUpdated: s.lastUpdated,
-			Content:     content,
-			LastEtag:    s.lastEtag,
+			LastUpdated:  s.lastUpdated,
+			Content:      content,
+			LastEtag:     s.lastEtag,
+			RuleCount:    s.ruleCount,
+			IgnoredCount: s.ignoredCount,
 		})
 		if err != nil {
 			s.logger.Error("save rule-set cache: ", err)
@@ -312,6 +380,55 @@ func (s *RemoteRuleSet) fetch(ctx context.Context, startContext *adapter.HTTPSta
 	return nil
 }
 
+// compile converts a downloaded filter list into a binary rule-set,
+// so that the cache file stores the converted result.
+func (s *RemoteRuleSet) compile(content []byte) ([]byte, adguard.Statistics, error) {
+	var (
+		rules      []option.HeadlessRule
+		statistics adguard.Statistics
+		err        error
+	)
+	switch s.options.Format {
+	case C.RuleSetFormatAdGuard:
+		rules, statistics, err = adguard.ToOptionsWithStatistics(bytes.NewReader(content), s.logger)
+	case C.RuleSetFormatHosts:
+		rules, statistics, err = adguard.HostsToOptions(bytes.NewReader(content), s.logger)
+	case C.RuleSetFormatClashClassical:
+		rules, statistics, err = fromClashStatistics(clash.ClassicalToOptions(bytes.NewReader(content), s.logger))
+	case C.RuleSetFormatClashDomain:
+		rules, statistics, err = fromClashStatistics(clash.DomainToOptions(bytes.NewReader(content), s.logger))
+	case C.RuleSetFormatClashIPCIDR:
+		rules, statistics, err = fromClashStatistics(clash.IPCIDRToOptions(bytes.NewReader(content), s.logger))
+	case C.RuleSetFormatMRS:
+		rules, statistics, err = fromClashStatistics(clash.MRSToOptions(bytes.NewReader(content), s.logger))
+	default:
+		return nil, statistics, E.New("unknown rule-set format: ", s.options.Format)
+	}
+	if err != nil {
+		return nil, statistics, err
+	}
+	var buffer bytes.Buffer
+	err = srs.Write(&buffer, option.PlainRuleSet{Rules: rules}, C.RuleSetVersionCurrent)
+	if err != nil {
+		return nil, statistics, E.Cause(err, "compile rule-set")
+	}
+	s.logger.Debug("converted rule-set ", s.options.Tag, ": ", statistics.Rules, " rules loaded, ", statistics.Ignored, " unsupported lines")
+	return buffer.Bytes(), statistics, nil
+}
+
+func fromClashStatistics(rules []option.HeadlessRule, statistics clash.Statistics, err error) ([]option.HeadlessRule, adguard.Statistics, error) {
+	return rules, adguard.Statistics{Rules: statistics.Rules, Ignored: statistics.Ignored}, err
+}
+
+func isConvertedRuleSetFormat(format string) bool {
+	switch format {
+	case C.RuleSetFormatAdGuard, C.RuleSetFormatHosts, C.RuleSetFormatClashClassical, C.RuleSetFormatClashDomain, C.RuleSetFormatClashIPCIDR, C.RuleSetFormatMRS:
+		return true
+	default:
+		return false
+	}
+}
+
 func (s *RemoteRuleSet) Close() error {
 	s.rules = nil
 	s.cancel()
@@ -0,0 +1,34 @@
+package rule
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteRuleSetStatistics(t *testing.T) {
+	t.Parallel()
+	ruleSet := NewRemoteRuleSet(context.Background(), log.NewNOPFactory().Logger(), option.RuleSet{
+		Type:   C.RuleSetTypeRemote,
+		Tag:    "test",
+		Format: C.RuleSetFormatSource,
+	})
+	defer ruleSet.Close()
+	content := []byte(`{"version":2,"rules":[{"domain":"a.example"},{"domain":"b.example"}]}`)
+	require.NoError(t, ruleSet.loadBytes(content, C.RuleSetFormatSource))
+	contentHash := sha256.Sum256(content)
+	statistics := ruleSet.Statistics()
+	require.Equal(t, 2, statistics.RuleCount)
+	require.Equal(t, uint8(C.RuleSetVersion2), statistics.Version)
+	require.Equal(t, hex.EncodeToString(contentHash[:]), statistics.Checksum)
+
+	require.Error(t, ruleSet.loadBytes([]byte(`{"version":2,"rules":[{"unknown_field":true}]}`), C.RuleSetFormatSource))
+	require.Equal(t, hex.EncodeToString(contentHash[:]), ruleSet.Statistics().Checksum, "a failed load must keep the previous status")
+}
@@ -53,6 +53,7 @@ func (s *APIServer) getServerInfo(writer http.ResponseWriter, request *http.Requ
 type UserObject struct {
 	UserName        string `json:"username"`
 	Password        string `json:"uPSK,omitempty"`
+	Destination     string `json:"destination,omitempty"`
 	DownlinkBytes   int64  `json:"downlinkBytes"`
 	UplinkBytes     int64  `json:"uplinkBytes"`
 	DownlinkPackets int64  `json:"downlinkPackets"`
@@ -69,8 +70,9 @@ func (s *APIServer) listUser(writer http.ResponseWriter, request *http.Request)
 
 func (s *APIServer) addUser(writer http.ResponseWriter, request *http.Request) {
 	var addRequest struct {
-		UserName string `json:"username"`
-		Password string `json:"uPSK"`
+		UserName    string `json:"username"`
+		Password    string `json:"uPSK"`
+		Destination string `json:"destination"`
 	}
 	err := render.DecodeJSON(request.Body, &addRequest)
 	if err != nil {
@@ -78,7 +80,7 @@ func (s *APIServer) addUser(writer http.ResponseWriter, request *http.Request) {
 		render.PlainText(writer, request, err.Error())
 		return
 	}
-	err = s.user.Add(addRequest.UserName, addRequest.Password)
+	err = s.user.Add(addRequest.UserName, addRequest.Password, addRequest.Destination)
 	if err != nil {
 		render.Status(request, http.StatusBadRequest)
 		render.PlainText(writer, request, err.Error())
@@ -93,15 +95,11 @@ func (s *APIServer) getUser(writer http.ResponseWriter, request *http.Request) {
 		writer.WriteHeader(http.StatusBadRequest)
 		return
 	}
-	uPSK, loaded := s.user.Get(userName)
+	user, loaded := s.user.Get(userName)
 	if !loaded {
 		writer.WriteHeader(http.StatusNotFound)
 		return
 	}
-	user := UserObject{
-		UserName: userName,
-		Password: uPSK,
-	}
 	s.traffic.ReadUser(&user)
 	render.JSON(writer, request, user)
 }
@@ -113,7 +111,8 @@ func (s *APIServer) updateUser(writer http.ResponseWriter, request *http.Request
 		return
 	}
 	var updateRequest struct {
-		Password string `json:"uPSK"`
+		Password    string `json:"uPSK"`
+		Destination string `json:"destination"`
 	}
 	err := render.DecodeJSON(request.Body, &updateRequest)
 	if err != nil {
@@ -126,7 +125,7 @@ func (s *APIServer) updateUser(writer http.ResponseWriter, request *http.Request
 		writer.WriteHeader(http.StatusNotFound)
 		return
 	}
-	err = s.user.Update(userName, updateRequest.Password)
+	err = s.user.Update(userName, updateRequest.Password, updateRequest.Destination)
 	if err != nil {
 		render.Status(request, http.StatusBadRequest)
 		render.PlainText(writer, request, err.Error())
@@ -30,6 +30,7 @@ type EndpointCache struct {
 	UserTCPSessions       *badjson.TypedMap[string, int64]  `json:"user_tcp_sessions"`
 	UserUDPSessions       *badjson.TypedMap[string, int64]  `json:"user_udp_sessions"`
 	Users                 *badjson.TypedMap[string, string] `json:"users"`
+	Destinations          *badjson.TypedMap[string, string] `json:"destinations,omitempty"`
 }
 
 func (s *Service) loadCache() error {
@@ -118,6 +119,7 @@ func (s *Service) decodeCache(cacheBinary []byte) error {
 			continue
 		}
 		userManager.usersMap = typedMap(entry.Value.Users)
+		userManager.destinationsMap = typedMap(entry.Value.Destinations)
 		_ = userManager.postUpdate(false)
 	}
 	return nil
@@ -134,6 +136,7 @@ func (s *Service) encodeCache() ([]byte, error) {
 			userTCPSessions     = new(badjson.TypedMap[string, int64])
 			userUDPSessions     = new(badjson.TypedMap[string, int64])
 			userMap             = new(badjson.TypedMap[string, string])
+			destinationMap      *badjson.TypedMap[string, string]
 		)
 		for user, uplink := range traffic.userUplink {
 			if uplink.Load() > 0 {
@@ -173,6 +176,12 @@ func (s *Service) encodeCache() ([]byte, error) {
 					userMap.Put(username, password)
 				}
 			}
+			for username, destination := range userManager.destinationsMap {
+				if destinationMap == nil {
+					destinationMap = new(badjson.TypedMap[string, string])
+				}
+				destinationMap.Put(username, destination)
+			}
 		}
 		endpoints.Put(tag, &EndpointCache{
 			GlobalUplink:          traffic.globalUplink.Load(),
@@ -188,6 +197,7 @@ func (s *Service) encodeCache() ([]byte, error) {
 			UserTCPSessions:       sortTypedMap(userTCPSessions),
 			UserUDPSessions:       sortTypedMap(userUDPSessions),
 			Users:                 sortTypedMap(userMap),
+			Destinations:          sortTypedMap(destinationMap),
 		})
 	}
 	var buffer bytes.Buffer
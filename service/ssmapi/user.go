@@ -5,20 +5,26 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
 )
 
 type UserManager struct {
-	access         sync.Mutex
-	usersMap       map[string]string
-	server         adapter.ManagedSSMServer
-	trafficManager *TrafficManager
+	access          sync.Mutex
+	usersMap        map[string]string
+	destinationsMap map[string]string
+	server          adapter.ManagedSSMServer
+	relayServer     adapter.ManagedSSMRelayServer
+	trafficManager  *TrafficManager
 }
 
 func NewUserManager(inbound adapter.ManagedSSMServer, trafficManager *TrafficManager) *UserManager {
+	relayServer, _ := inbound.(adapter.ManagedSSMRelayServer)
 	return &UserManager{
-		usersMap:       make(map[string]string),
-		server:         inbound,
-		trafficManager: trafficManager,
+		usersMap:        make(map[string]string),
+		destinationsMap: make(map[string]string),
+		server:          inbound,
+		relayServer:     relayServer,
+		trafficManager:  trafficManager,
 	}
 }
 
@@ -29,7 +35,16 @@ func (m *UserManager) postUpdate(updated bool) error {
 		users = append(users, username)
 		uPSKs = append(uPSKs, password)
 	}
-	err := m.server.UpdateUsers(users, uPSKs)
+	var err error
+	if m.relayServer != nil {
+		destinations := make([]M.Socksaddr, 0, len(users))
+		for _, username := range users {
+			destinations = append(destinations, M.ParseSocksaddr(m.destinationsMap[username]))
+		}
+		err = m.relayServer.UpdateDestinations(users, uPSKs, destinations)
+	} else {
+		err = m.server.UpdateUsers(users, uPSKs)
+	}
 	if err != nil {
 		return err
 	}
@@ -39,6 +54,23 @@ func (m *UserManager) postUpdate(updated bool) error {
 	return nil
 }
 
+func (m *UserManager) checkDestination(destination string) error {
+	if m.relayServer == nil {
+		if destination != "" {
+			return E.New("destination is only supported by relay servers")
+		}
+		return nil
+	}
+	if destination == "" {
+		return E.New("missing destination")
+	}
+	destinationAddr := M.ParseSocksaddr(destination)
+	if !destinationAddr.IsValid() || destinationAddr.Port == 0 {
+		return E.New("invalid destination: ", destination)
+	}
+	return nil
+}
+
 func (m *UserManager) List() []*UserObject {
 	m.access.Lock()
 	defer m.access.Unlock()
@@ -46,36 +78,59 @@ func (m *UserManager) List() []*UserObject {
 	users := make([]*UserObject, 0, len(m.usersMap))
 	for username, password := range m.usersMap {
 		users = append(users, &UserObject{
-			UserName: username,
-			Password: password,
+			UserName:    username,
+			Password:    password,
+			Destination: m.destinationsMap[username],
 		})
 	}
 	return users
 }
 
-func (m *UserManager) Add(username string, password string) error {
+func (m *UserManager) Add(username string, password string, destination string) error {
 	m.access.Lock()
 	defer m.access.Unlock()
 	if _, found := m.usersMap[username]; found {
 		return E.New("user ", username, " already exists")
 	}
+	err := m.checkDestination(destination)
+	if err != nil {
+		return err
+	}
 	m.usersMap[username] = password
+	if destination != "" {
+		m.destinationsMap[username] = destination
+	}
 	return m.postUpdate(true)
 }
 
-func (m *UserManager) Get(username string) (string, bool) {
+func (m *UserManager) Get(username string) (UserObject, bool) {
 	m.access.Lock()
 	defer m.access.Unlock()
 	if password, found := m.usersMap[username]; found {
-		return password, true
+		return UserObject{
+			UserName:    username,
+			Password:    password,
+			Destination: m.destinationsMap[username],
+		}, true
 	}
-	return "", false
+	return UserObject{}, false
 }
 
-func (m *UserManager) Update(username string, password string) error {
+// Update replaces the password of the user, and the destination if not empty.
+func (m *UserManager) Update(username string, password string, destination string) error {
 	m.access.Lock()
 	defer m.access.Unlock()
+	if destination == "" {
+		destination = m.destinationsMap[username]
+	}
+	err := m.checkDestination(destination)
+	if err != nil {
+		return err
+	}
 	m.usersMap[username] = password
+	if destination != "" {
+		m.destinationsMap[username] = destination
+	}
 	return m.postUpdate(true)
 }
 
@@ -83,5 +138,6 @@ func (m *UserManager) Delete(username string) error {
 	m.access.Lock()
 	defer m.access.Unlock()
 	delete(m.usersMap, username)
+	delete(m.destinationsMap, username)
 	return m.postUpdate(true)
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+func TestV2RayXHTTPSelf(t *testing.T) {
+	for _, mode := range []string{C.XHTTPModePacketUp, C.XHTTPModeStreamUp, C.XHTTPModeStreamOne} {
+		t.Run(mode, func(t *testing.T) {
+			testV2RayTransportSelf(t, &option.V2RayTransportOptions{
+				Type: C.V2RayTransportTypeXHTTP,
+				XHTTPOptions: option.V2RayXHTTPOptions{
+					Path: "/xhttp",
+					Mode: mode,
+				},
+			})
+		})
+	}
+}
+
+func TestV2RayXHTTPPlainSelf(t *testing.T) {
+	for _, mode := range []string{C.XHTTPModePacketUp, C.XHTTPModeStreamUp, C.XHTTPModeStreamOne} {
+		t.Run(mode, func(t *testing.T) {
+			testV2RayTransportNOTLSSelf(t, &option.V2RayTransportOptions{
+				Type: C.V2RayTransportTypeXHTTP,
+				XHTTPOptions: option.V2RayXHTTPOptions{
+					Mode: mode,
+				},
+			})
+		})
+	}
+}
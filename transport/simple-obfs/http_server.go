@@ -0,0 +1,129 @@
+package obfs
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// HTTPObfsServer is the server side of shadowsocks http simple-obfs
+type HTTPObfsServer struct {
+	net.Conn
+	host          string
+	reader        *bufio.Reader
+	body          []byte
+	requestKey    string
+	firstRequest  bool
+	firstResponse bool
+}
+
+func (ho *HTTPObfsServer) readRequest() error {
+	request, err := http.ReadRequest(ho.reader)
+	if err != nil {
+		return E.Cause(err, "read obfs request")
+	}
+	// the simple-obfs client always sends a websocket upgrade request
+	if request.Method != http.MethodGet ||
+		!strings.EqualFold(request.Header.Get("Upgrade"), "websocket") ||
+		!headerContainsToken(request.Header, "Connection", "Upgrade") ||
+		request.Header.Get("Sec-WebSocket-Key") == "" {
+		return E.New("invalid obfs request")
+	}
+	if ho.host != "" {
+		host, _, err := net.SplitHostPort(request.Host)
+		if err != nil {
+			host = request.Host
+		}
+		if !strings.EqualFold(host, ho.host) {
+			return E.New("bad obfs host: ", request.Host)
+		}
+	}
+	ho.requestKey = request.Header.Get("Sec-WebSocket-Key")
+	if request.ContentLength > 0 {
+		ho.body = make([]byte, request.ContentLength)
+		_, err = io.ReadFull(ho.reader, ho.body)
+		if err != nil {
+			return E.Cause(err, "read obfs request body")
+		}
+	}
+	return nil
+}
+
+func headerContainsToken(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, element := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(element), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (ho *HTTPObfsServer) Read(b []byte) (int, error) {
+	if ho.firstRequest {
+		ho.firstRequest = false
+		err := ho.readRequest()
+		if err != nil {
+			return 0, err
+		}
+	}
+	if len(ho.body) > 0 {
+		n := copy(b, ho.body)
+		ho.body = ho.body[n:]
+		return n, nil
+	}
+	if ho.reader.Buffered() > 0 {
+		return ho.reader.Read(b)
+	}
+	return ho.Conn.Read(b)
+}
+
+func (ho *HTTPObfsServer) Write(b []byte) (int, error) {
+	if ho.firstResponse {
+		ho.firstResponse = false
+		acceptKey := sha1.Sum([]byte(ho.requestKey + websocketGUID))
+		var response bytes.Buffer
+		response.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+		fmt.Fprintf(&response, "Server: nginx/1.%d.%d\r\n", rand.Intn(11), rand.Intn(12))
+		fmt.Fprintf(&response, "Date: %s\r\n", time.Now().UTC().Format(http.TimeFormat))
+		response.WriteString("Upgrade: websocket\r\n")
+		response.WriteString("Connection: Upgrade\r\n")
+		fmt.Fprintf(&response, "Sec-WebSocket-Accept: %s\r\n\r\n", base64.StdEncoding.EncodeToString(acceptKey[:]))
+		response.Write(b)
+		_, err := ho.Conn.Write(response.Bytes())
+		if err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	return ho.Conn.Write(b)
+}
+
+func (ho *HTTPObfsServer) Upstream() any {
+	return ho.Conn
+}
+
+// NewHTTPObfsServer return a HTTPObfsServer,
+// the request is read on the first Read and the response is sent with the first Write
+func NewHTTPObfsServer(conn net.Conn, host string) net.Conn {
+	return &HTTPObfsServer{
+		Conn:          conn,
+		host:          host,
+		reader:        bufio.NewReader(conn),
+		firstRequest:  true,
+		firstResponse: true,
+	}
+}
@@ -0,0 +1,255 @@
+package obfs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	B "github.com/sagernet/sing/common/buf"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const (
+	serverNameExtensionType    = 0x0000
+	sessionTicketExtensionType = 0x0023
+)
+
+// TLSObfsServer is the server side of shadowsocks tls simple-obfs
+type TLSObfsServer struct {
+	net.Conn
+	host          string
+	sessionID     []byte
+	buffer        []byte
+	remain        int
+	firstRequest  bool
+	firstResponse bool
+}
+
+// readRecordHeader reads a record header, which must match what the simple-obfs client sends.
+func (to *TLSObfsServer) readRecordHeader(recordType byte, version uint16) (int, error) {
+	var header [5]byte
+	_, err := io.ReadFull(to.Conn, header[:])
+	if err != nil {
+		return 0, err
+	}
+	if header[0] != recordType || binary.BigEndian.Uint16(header[1:]) != version {
+		return 0, E.New("invalid obfs record header: ", header[:3])
+	}
+	return int(binary.BigEndian.Uint16(header[3:])), nil
+}
+
+func (to *TLSObfsServer) readClientHello() error {
+	length, err := to.readRecordHeader(0x16, 0x0301)
+	if err != nil {
+		return err
+	}
+	hello := make([]byte, length)
+	_, err = io.ReadFull(to.Conn, hello)
+	if err != nil {
+		return err
+	}
+	sessionID, ticket, serverName, err := parseClientHelloMsg(hello)
+	if err != nil {
+		return err
+	}
+	if to.host != "" && !strings.EqualFold(serverName, to.host) {
+		return E.New("bad obfs server name: ", serverName)
+	}
+	to.sessionID = sessionID
+	to.buffer = ticket
+	return nil
+}
+
+func parseClientHelloMsg(hello []byte) (sessionID []byte, ticket []byte, serverName string, err error) {
+	reader := bytes.NewReader(hello)
+	// handshake type, length, version
+	var header [6]byte
+	if _, err = io.ReadFull(reader, header[:]); err != nil {
+		return
+	}
+	if header[0] != 0x01 || binary.BigEndian.Uint16(header[4:]) != 0x0303 {
+		err = E.New("invalid obfs client hello")
+		return
+	}
+	// random
+	if _, err = reader.Seek(32, io.SeekCurrent); err != nil {
+		return
+	}
+	sessionIDLen, err := reader.ReadByte()
+	if err != nil {
+		return
+	}
+	if sessionIDLen != 32 {
+		err = E.New("invalid obfs client hello: bad session id length: ", sessionIDLen)
+		return
+	}
+	sessionID = make([]byte, sessionIDLen)
+	if _, err = io.ReadFull(reader, sessionID); err != nil {
+		return
+	}
+	var length uint16
+	// cipher suites
+	if err = binary.Read(reader, binary.BigEndian, &length); err != nil {
+		return
+	}
+	if _, err = reader.Seek(int64(length), io.SeekCurrent); err != nil {
+		return
+	}
+	// compression methods
+	compressionLen, err := reader.ReadByte()
+	if err != nil {
+		return
+	}
+	if _, err = reader.Seek(int64(compressionLen), io.SeekCurrent); err != nil {
+		return
+	}
+	// extensions
+	if err = binary.Read(reader, binary.BigEndian, &length); err != nil {
+		return
+	}
+	var ticketLoaded bool
+	for reader.Len() > 0 {
+		var extensionType, extensionLen uint16
+		if err = binary.Read(reader, binary.BigEndian, &extensionType); err != nil {
+			return
+		}
+		if err = binary.Read(reader, binary.BigEndian, &extensionLen); err != nil {
+			return
+		}
+		extension := make([]byte, extensionLen)
+		if _, err = io.ReadFull(reader, extension); err != nil {
+			return
+		}
+		switch extensionType {
+		case serverNameExtensionType:
+			// list length (2) | name type (1) | name length (2) | name
+			if len(extension) > 5 && extension[2] == 0 {
+				nameLen := int(binary.BigEndian.Uint16(extension[3:]))
+				if len(extension) >= 5+nameLen {
+					serverName = string(extension[5 : 5+nameLen])
+				}
+			}
+		case sessionTicketExtensionType:
+			ticket = extension
+			ticketLoaded = true
+		}
+	}
+	if !ticketLoaded {
+		err = E.New("invalid obfs client hello: missing session ticket")
+	}
+	return
+}
+
+func (to *TLSObfsServer) Read(b []byte) (int, error) {
+	if to.firstRequest {
+		to.firstRequest = false
+		err := to.readClientHello()
+		if err != nil {
+			return 0, E.Cause(err, "read obfs client hello")
+		}
+	}
+	if len(to.buffer) > 0 {
+		n := copy(b, to.buffer)
+		to.buffer = to.buffer[n:]
+		return n, nil
+	}
+	if to.remain == 0 {
+		length, err := to.readRecordHeader(0x17, 0x0303)
+		if err != nil {
+			return 0, err
+		}
+		to.remain = length
+	}
+	n, err := to.Conn.Read(b[:min(len(b), to.remain)])
+	to.remain -= n
+	return n, err
+}
+
+func (to *TLSObfsServer) Write(b []byte) (int, error) {
+	length := len(b)
+	for i := 0; i < length; i += chunkSize {
+		end := i + chunkSize
+		if end > length {
+			end = length
+		}
+		n, err := to.write(b[i:end])
+		if err != nil {
+			return n, err
+		}
+	}
+	return length, nil
+}
+
+func (to *TLSObfsServer) write(b []byte) (int, error) {
+	if to.firstResponse {
+		to.firstResponse = false
+		_, err := to.Conn.Write(makeServerHelloMsg(b, to.sessionID))
+		return len(b), err
+	}
+	buf := B.NewSize(5 + len(b))
+	defer buf.Release()
+	buf.Write([]byte{0x17, 0x03, 0x03})
+	binary.Write(buf, binary.BigEndian, uint16(len(b)))
+	buf.Write(b)
+	_, err := to.Conn.Write(buf.Bytes())
+	return len(b), err
+}
+
+func (to *TLSObfsServer) Upstream() any {
+	return to.Conn
+}
+
+// NewTLSObfsServer return a TLSObfsServer,
+// the server name of the client hello is checked against host if set
+func NewTLSObfsServer(conn net.Conn, host string) net.Conn {
+	return &TLSObfsServer{
+		Conn:          conn,
+		host:          host,
+		firstRequest:  true,
+		firstResponse: true,
+	}
+}
+
+func makeServerHelloMsg(data []byte, sessionID []byte) []byte {
+	random := make([]byte, 28)
+	rand.Read(random)
+
+	buf := &bytes.Buffer{}
+
+	// handshake, TLS 1.0 version, length
+	buf.Write([]byte{0x16, 0x03, 0x01})
+	binary.Write(buf, binary.BigEndian, uint16(91))
+
+	// serverHello, length, TLS 1.2 version
+	buf.Write([]byte{0x02, 0x00, 0x00, 0x57, 0x03, 0x03})
+
+	// random with timestamp, sid len, sid
+	binary.Write(buf, binary.BigEndian, uint32(time.Now().Unix()))
+	buf.Write(random)
+	buf.WriteByte(32)
+	if len(sessionID) == 32 {
+		buf.Write(sessionID)
+	} else {
+		buf.Write(make([]byte, 32))
+	}
+
+	// cipher suite, compression
+	buf.Write([]byte{0xcc, 0xa8, 0x00})
+
+	// extensions: renegotiation info, extended master secret, ec_point
+	buf.Write([]byte{0x00, 0x0f, 0xff, 0x01, 0x00, 0x01, 0x00, 0x00, 0x17, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x02, 0x01, 0x00})
+
+	// change cipher spec
+	buf.Write([]byte{0x14, 0x03, 0x03, 0x00, 0x01, 0x01})
+
+	// encrypted handshake carrying the first payload
+	buf.Write([]byte{0x16, 0x03, 0x03})
+	binary.Write(buf, binary.BigEndian, uint16(len(data)))
+	buf.Write(data)
+
+	return buf.Bytes()
+}
@@ -0,0 +1,35 @@
+package v2ray
+
+import (
+	"context"
+	"net"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+var _ N.Dialer = (*TransportDialer)(nil)
+
+// TransportDialer dials every TCP connection through the client transport,
+// for protocols that only need a stream to the server.
+type TransportDialer struct {
+	transport adapter.V2RayClientTransport
+}
+
+func NewTransportDialer(transport adapter.V2RayClientTransport) *TransportDialer {
+	return &TransportDialer{transport}
+}
+
+func (d *TransportDialer) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	if N.NetworkName(network) != N.NetworkTCP {
+		return nil, E.Extend(N.ErrUnknownNetwork, network)
+	}
+	return d.transport.DialContext(ctx)
+}
+
+func (d *TransportDialer) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	return nil, os.ErrInvalid
+}
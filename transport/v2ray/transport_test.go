@@ -0,0 +1,138 @@
+package v2ray_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/v2ray"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+type echoHandler struct{}
+
+func (h *echoHandler) NewConnectionEx(ctx context.Context, conn net.Conn, source M.Socksaddr, destination M.Socksaddr, onClose N.CloseHandlerFunc) {
+	defer conn.Close()
+	io.Copy(conn, conn)
+}
+
+func startTransportServer(t *testing.T, options option.V2RayTransportOptions) M.Socksaddr {
+	server, err := v2ray.NewServerTransport(context.Background(), log.NewNOPFactory().Logger(), options, nil, &echoHandler{})
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+	t.Cleanup(func() {
+		server.Close()
+		listener.Close()
+	})
+	return M.SocksaddrFromNet(listener.Addr())
+}
+
+func dialTransport(t *testing.T, serverAddr M.Socksaddr, options option.V2RayTransportOptions) (net.Conn, error) {
+	client, err := v2ray.NewClientTransport(context.Background(), N.SystemDialer, serverAddr, options, nil)
+	require.NoError(t, err)
+	return v2ray.NewTransportDialer(client).DialContext(context.Background(), N.NetworkTCP, M.ParseSocksaddr("example.com:443"))
+}
+
+func testEcho(t *testing.T, conn net.Conn) {
+	payload := make([]byte, 64*1024)
+	_, err := rand.Read(payload)
+	require.NoError(t, err)
+	go conn.Write(payload)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	received := make([]byte, len(payload))
+	_, err = io.ReadFull(conn, received)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(payload, received))
+}
+
+func TestTransportLoopback(t *testing.T) {
+	t.Parallel()
+	for name, options := range map[string]option.V2RayTransportOptions{
+		"obfs http": {
+			Type:        C.V2RayTransportTypeObfs,
+			ObfsOptions: option.V2RayObfsOptions{Mode: C.ObfsModeHTTP, Host: "example.com"},
+		},
+		"obfs tls": {
+			Type:        C.V2RayTransportTypeObfs,
+			ObfsOptions: option.V2RayObfsOptions{Mode: C.ObfsModeTLS, Host: "example.com"},
+		},
+		"v2ray-plugin": {
+			Type:          C.V2RayTransportTypePlugin,
+			PluginOptions: option.V2RayPluginOptions{Host: "example.com", Path: "/ws"},
+		},
+		"v2ray-plugin without mux": {
+			Type:          C.V2RayTransportTypePlugin,
+			PluginOptions: option.V2RayPluginOptions{Path: "/ws", DisableMux: true},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			serverAddr := startTransportServer(t, options)
+			conn, err := dialTransport(t, serverAddr, options)
+			require.NoError(t, err)
+			defer conn.Close()
+			testEcho(t, conn)
+		})
+	}
+}
+
+func TestTransportBadHost(t *testing.T) {
+	t.Parallel()
+	for name, options := range map[string]option.V2RayTransportOptions{
+		"obfs http": {
+			Type:        C.V2RayTransportTypeObfs,
+			ObfsOptions: option.V2RayObfsOptions{Mode: C.ObfsModeHTTP, Host: "example.com"},
+		},
+		"obfs tls": {
+			Type:        C.V2RayTransportTypeObfs,
+			ObfsOptions: option.V2RayObfsOptions{Mode: C.ObfsModeTLS, Host: "example.com"},
+		},
+		"v2ray-plugin": {
+			Type:          C.V2RayTransportTypePlugin,
+			PluginOptions: option.V2RayPluginOptions{Host: "example.com", Path: "/ws"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			serverAddr := startTransportServer(t, options)
+			clientOptions := options
+			clientOptions.ObfsOptions.Host = "example.org"
+			clientOptions.PluginOptions.Host = "example.org"
+			conn, err := dialTransport(t, serverAddr, clientOptions)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			_, err = conn.Write([]byte("ping"))
+			if err == nil {
+				conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+				_, err = conn.Read(make([]byte, 4))
+			}
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestTransportDialerNetwork(t *testing.T) {
+	t.Parallel()
+	options := option.V2RayTransportOptions{Type: C.V2RayTransportTypeObfs}
+	client, err := v2ray.NewClientTransport(context.Background(), N.SystemDialer, M.ParseSocksaddr("127.0.0.1:1"), options, nil)
+	require.NoError(t, err)
+	dialer := v2ray.NewTransportDialer(client)
+	_, err = dialer.DialContext(context.Background(), N.NetworkUDP, M.ParseSocksaddr("example.com:53"))
+	require.ErrorIs(t, err, N.ErrUnknownNetwork)
+	_, err = dialer.ListenPacket(context.Background(), M.ParseSocksaddr("example.com:53"))
+	require.Error(t, err)
+}
@@ -0,0 +1,80 @@
+package v2raykcp
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sync/atomic"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/option"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+var _ adapter.V2RayClientTransport = (*Client)(nil)
+
+var globalConversation atomic.Uint32
+
+func init() {
+	globalConversation.Store(rand.Uint32() & 0xFFFF)
+}
+
+type Client struct {
+	dialer     N.Dialer
+	serverAddr M.Socksaddr
+	config     *config
+	tlsConfig  tls.Config
+}
+
+func NewClient(ctx context.Context, dialer N.Dialer, serverAddr M.Socksaddr, options option.V2RayKCPOptions, tlsConfig tls.Config) (adapter.V2RayClientTransport, error) {
+	config, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		dialer:     dialer,
+		serverAddr: serverAddr,
+		config:     config,
+		tlsConfig:  tlsConfig,
+	}, nil
+}
+
+func (c *Client) DialContext(ctx context.Context) (net.Conn, error) {
+	codec, err := newPacketCodec(c.config)
+	if err != nil {
+		return nil, err
+	}
+	packetConn, err := c.dialer.DialContext(ctx, N.NetworkUDP, c.serverAddr)
+	if err != nil {
+		return nil, err
+	}
+	conn := newConn(uint16(globalConversation.Add(1)), packetConn.LocalAddr(), packetConn.RemoteAddr(), codec, func(packet []byte) error {
+		_, err := packetConn.Write(packet)
+		return err
+	}, packetConn, c.config)
+	go fetchInput(packetConn, codec, conn)
+	if c.tlsConfig != nil {
+		return tls.ClientHandshake(ctx, conn, c.tlsConfig)
+	}
+	return conn, nil
+}
+
+func fetchInput(packetConn net.Conn, codec *packetCodec, conn *Conn) {
+	buffer := make([]byte, 65535)
+	for {
+		n, err := packetConn.Read(buffer)
+		if err != nil {
+			return
+		}
+		segments := codec.Decode(buffer[:n])
+		if len(segments) > 0 {
+			conn.input(segments)
+		}
+	}
+}
+
+func (c *Client) Close() error {
+	return nil
+}
@@ -0,0 +1,68 @@
+package v2raykcp
+
+import (
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+type config struct {
+	mtu              uint32
+	tti              uint32
+	uplinkCapacity   uint32
+	downlinkCapacity uint32
+	congestion       bool
+	writeBufferSize  uint32
+	headerType       string
+	seed             string
+}
+
+func newConfig(options option.V2RayKCPOptions) (*config, error) {
+	c := &config{
+		mtu:              1350,
+		tti:              50,
+		uplinkCapacity:   5,
+		downlinkCapacity: 20,
+		congestion:       options.Congestion,
+		writeBufferSize:  2 * 1024 * 1024,
+		headerType:       options.HeaderType,
+		seed:             options.Seed,
+	}
+	if options.MTU != 0 {
+		if options.MTU < 576 || options.MTU > 1460 {
+			return nil, E.New("invalid mtu: ", options.MTU, ", must be between 576 and 1460")
+		}
+		c.mtu = options.MTU
+	}
+	if options.TTI != 0 {
+		if options.TTI < 10 || options.TTI > 100 {
+			return nil, E.New("invalid tti: ", options.TTI, ", must be between 10 and 100")
+		}
+		c.tti = options.TTI
+	}
+	if options.UplinkCapacity != 0 {
+		c.uplinkCapacity = options.UplinkCapacity
+	}
+	if options.DownlinkCapacity != 0 {
+		c.downlinkCapacity = options.DownlinkCapacity
+	}
+	if options.WriteBufferSize != 0 {
+		c.writeBufferSize = options.WriteBufferSize * 1024 * 1024
+	}
+	_, err := newPacketHeader(c.headerType)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *config) sendingInFlightSize() uint32 {
+	return max(c.uplinkCapacity*1024*1024/c.mtu/(1000/c.tti), 8)
+}
+
+func (c *config) sendingBufferSize() uint32 {
+	return c.writeBufferSize / c.mtu
+}
+
+func (c *config) receivingInFlightSize() uint32 {
+	return max(c.downlinkCapacity*1024*1024/c.mtu/(1000/c.tti), 8)
+}
@@ -0,0 +1,464 @@
+package v2raykcp
+
+import (
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type connState int32
+
+const (
+	stateActive connState = iota
+	stateReadyToClose
+	statePeerClosed
+	stateTerminating
+	statePeerTerminating
+	stateTerminated
+)
+
+type roundTripInfo struct {
+	access           sync.Mutex
+	variation        uint32
+	srtt             uint32
+	rto              uint32
+	minRtt           uint32
+	updatedTimestamp uint32
+}
+
+func (i *roundTripInfo) UpdatePeerRTO(rto uint32, current uint32) {
+	i.access.Lock()
+	defer i.access.Unlock()
+	if current-i.updatedTimestamp < 3000 {
+		return
+	}
+	i.updatedTimestamp = current
+	i.rto = rto
+}
+
+// Update follows RFC 6298.
+func (i *roundTripInfo) Update(rtt uint32, current uint32) {
+	if rtt > 0x7FFFFFFF {
+		return
+	}
+	i.access.Lock()
+	defer i.access.Unlock()
+	if i.srtt == 0 {
+		i.srtt = rtt
+		i.variation = rtt / 2
+	} else {
+		delta := rtt - i.srtt
+		if i.srtt > rtt {
+			delta = i.srtt - rtt
+		}
+		i.variation = (3*i.variation + delta) / 4
+		i.srtt = max((7*i.srtt+rtt)/8, i.minRtt)
+	}
+	var rto uint32
+	if i.minRtt < 4*i.variation {
+		rto = i.srtt + 4*i.variation
+	} else {
+		rto = i.srtt + i.variation
+	}
+	i.rto = min(rto, 10000) * 5 / 4
+	i.updatedTimestamp = current
+}
+
+func (i *roundTripInfo) Timeout() uint32 {
+	i.access.Lock()
+	defer i.access.Unlock()
+	return i.rto
+}
+
+// updater runs updateFunc every interval in a single goroutine while shouldContinue holds,
+// and is restarted by WakeUp.
+type updater struct {
+	interval        atomic.Int64
+	shouldContinue  func() bool
+	shouldTerminate func() bool
+	updateFunc      func()
+	running         chan struct{}
+}
+
+func newUpdater(interval time.Duration, shouldContinue func() bool, shouldTerminate func() bool, updateFunc func()) *updater {
+	u := &updater{
+		shouldContinue:  shouldContinue,
+		shouldTerminate: shouldTerminate,
+		updateFunc:      updateFunc,
+		running:         make(chan struct{}, 1),
+	}
+	u.interval.Store(int64(interval))
+	return u
+}
+
+func (u *updater) WakeUp() {
+	select {
+	case u.running <- struct{}{}:
+		go u.run()
+	default:
+	}
+}
+
+func (u *updater) run() {
+	defer func() {
+		<-u.running
+	}()
+	if u.shouldTerminate() {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(u.interval.Load()))
+	defer ticker.Stop()
+	for u.shouldContinue() {
+		u.updateFunc()
+		<-ticker.C
+	}
+}
+
+func (u *updater) SetInterval(interval time.Duration) {
+	u.interval.Store(int64(interval))
+}
+
+type segmentWriter struct {
+	access sync.Mutex
+	codec  *packetCodec
+	buffer []byte
+	write  func(packet []byte) error
+}
+
+func (w *segmentWriter) Write(seg segment) {
+	w.access.Lock()
+	defer w.access.Unlock()
+	size := seg.ByteSize()
+	if cap(w.buffer) < size {
+		w.buffer = make([]byte, size)
+	}
+	payload := w.buffer[:size]
+	seg.Serialize(payload)
+	_ = w.write(w.codec.Encode(payload))
+}
+
+var _ net.Conn = (*Conn)(nil)
+
+// Conn is a reliable stream over UDP packets, compatible with mKCP of v2ray.
+type Conn struct {
+	conversation  uint16
+	localAddr     net.Addr
+	remoteAddr    net.Addr
+	closer        io.Closer
+	config        *config
+	since         time.Time
+	readDeadline  atomic.Int64
+	writeDeadline atomic.Int64
+	dataInput     chan struct{}
+	dataOutput    chan struct{}
+
+	state            atomic.Int32
+	stateBeginTime   atomic.Uint32
+	lastIncomingTime atomic.Uint32
+	lastPingTime     atomic.Uint32
+
+	mss       uint32
+	roundTrip *roundTripInfo
+	output    *segmentWriter
+
+	receivingWorker *receivingWorker
+	sendingWorker   *sendingWorker
+	dataUpdater     *updater
+	pingUpdater     *updater
+}
+
+func newConn(conversation uint16, localAddr net.Addr, remoteAddr net.Addr, codec *packetCodec, write func(packet []byte) error, closer io.Closer, config *config) *Conn {
+	conn := &Conn{
+		conversation: conversation,
+		localAddr:    localAddr,
+		remoteAddr:   remoteAddr,
+		closer:       closer,
+		config:       config,
+		since:        time.Now(),
+		dataInput:    make(chan struct{}, 1),
+		dataOutput:   make(chan struct{}, 1),
+		mss:          config.mtu - uint32(codec.Overhead()) - dataSegmentOverhead,
+		roundTrip: &roundTripInfo{
+			rto:    100,
+			minRtt: config.tti,
+		},
+		output: &segmentWriter{
+			codec: codec,
+			write: write,
+		},
+	}
+	conn.receivingWorker = newReceivingWorker(conn)
+	conn.sendingWorker = newSendingWorker(conn)
+	isTerminating := func() bool {
+		state := conn.State()
+		return state == stateTerminating || state == stateTerminated
+	}
+	isTerminated := func() bool {
+		return conn.State() == stateTerminated
+	}
+	conn.dataUpdater = newUpdater(time.Duration(config.tti)*time.Millisecond, func() bool {
+		return !isTerminating() && (!conn.sendingWorker.IsEmpty() || conn.receivingWorker.UpdateNecessary())
+	}, isTerminating, conn.flush)
+	conn.pingUpdater = newUpdater(5*time.Second, func() bool {
+		return !isTerminated()
+	}, isTerminated, conn.flush)
+	conn.pingUpdater.WakeUp()
+	return conn
+}
+
+func (c *Conn) elapsed() uint32 {
+	return uint32(time.Since(c.since).Milliseconds())
+}
+
+func (c *Conn) State() connState {
+	return connState(c.state.Load())
+}
+
+func (c *Conn) setState(state connState) {
+	c.state.Store(int32(state))
+	c.stateBeginTime.Store(c.elapsed())
+	switch state {
+	case statePeerClosed:
+		c.sendingWorker.CloseWrite()
+	case stateTerminating, statePeerTerminating:
+		c.sendingWorker.CloseWrite()
+		c.pingUpdater.SetInterval(time.Second)
+	case stateTerminated:
+		c.sendingWorker.CloseWrite()
+		c.pingUpdater.SetInterval(time.Second)
+		c.dataUpdater.WakeUp()
+		c.pingUpdater.WakeUp()
+		go c.terminate()
+	}
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Conn) wait(ch chan struct{}, deadline *atomic.Int64) error {
+	timeout := 16 * time.Second
+	if deadlineNano := deadline.Load(); deadlineNano != 0 {
+		timeout = time.Until(time.Unix(0, deadlineNano))
+		if timeout <= 0 {
+			return os.ErrDeadlineExceeded
+		}
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-ch:
+	case <-timer.C:
+		if deadlineNano := deadline.Load(); deadlineNano != 0 && time.Now().UnixNano() >= deadlineNano {
+			return os.ErrDeadlineExceeded
+		}
+	}
+	return nil
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	for {
+		switch c.State() {
+		case stateReadyToClose, stateTerminating, stateTerminated:
+			return 0, io.EOF
+		}
+		n := c.receivingWorker.Read(b)
+		if n > 0 {
+			c.dataUpdater.WakeUp()
+			return n, nil
+		}
+		if c.State() == statePeerTerminating {
+			return 0, io.EOF
+		}
+		err := c.wait(c.dataInput, &c.readDeadline)
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (c *Conn) Write(b []byte) (int, error) {
+	var n int
+	for {
+		for n < len(b) {
+			if c.State() != stateActive {
+				return n, io.ErrClosedPipe
+			}
+			chunk := b[n:min(len(b), n+int(c.mss))]
+			if !c.sendingWorker.Push(append([]byte(nil), chunk...)) {
+				break
+			}
+			n += len(chunk)
+		}
+		c.dataUpdater.WakeUp()
+		if n == len(b) {
+			return n, nil
+		}
+		err := c.wait(c.dataOutput, &c.writeDeadline)
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+func (c *Conn) Close() error {
+	signal(c.dataInput)
+	signal(c.dataOutput)
+	switch c.State() {
+	case stateReadyToClose, stateTerminating, stateTerminated:
+		return net.ErrClosed
+	case stateActive:
+		c.setState(stateReadyToClose)
+	case statePeerClosed:
+		c.setState(stateTerminating)
+	case statePeerTerminating:
+		c.setState(stateTerminated)
+	}
+	return nil
+}
+
+func (c *Conn) terminate() {
+	signal(c.dataInput)
+	signal(c.dataOutput)
+	c.closer.Close()
+	c.sendingWorker.Release()
+	c.receivingWorker.Release()
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.localAddr
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.readDeadline.Store(deadlineNano(t))
+	signal(c.dataInput)
+	return nil
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline.Store(deadlineNano(t))
+	signal(c.dataOutput)
+	return nil
+}
+
+func deadlineNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func (c *Conn) handleOption(option byte) {
+	if option&optionClose == optionClose {
+		switch c.State() {
+		case stateReadyToClose:
+			c.setState(stateTerminating)
+		case stateActive:
+			c.setState(statePeerClosed)
+		}
+	}
+}
+
+func (c *Conn) input(segments []segment) {
+	current := c.elapsed()
+	c.lastIncomingTime.Store(current)
+	for _, seg := range segments {
+		if seg.Conversation() != c.conversation {
+			break
+		}
+		switch seg := seg.(type) {
+		case *dataSegment:
+			c.handleOption(seg.option)
+			c.receivingWorker.ProcessSegment(seg)
+			if c.receivingWorker.IsDataAvailable() {
+				signal(c.dataInput)
+			}
+			c.dataUpdater.WakeUp()
+		case *ackSegment:
+			c.handleOption(seg.option)
+			c.sendingWorker.ProcessSegment(current, seg, c.roundTrip.Timeout())
+			signal(c.dataOutput)
+			c.dataUpdater.WakeUp()
+		case *commandSegment:
+			c.handleOption(seg.option)
+			if seg.command == commandTerminate {
+				switch c.State() {
+				case stateActive, statePeerClosed:
+					c.setState(statePeerTerminating)
+				case stateReadyToClose:
+					c.setState(stateTerminating)
+				case stateTerminating:
+					c.setState(stateTerminated)
+				}
+			}
+			if seg.option == optionClose || seg.command == commandTerminate {
+				signal(c.dataInput)
+				signal(c.dataOutput)
+			}
+			c.sendingWorker.ProcessReceivingNext(seg.receivingNext)
+			c.receivingWorker.ProcessSendingNext(seg.sendingNext)
+			c.roundTrip.UpdatePeerRTO(seg.peerRTO, current)
+		}
+	}
+}
+
+func (c *Conn) flush() {
+	current := c.elapsed()
+	if c.State() == stateTerminated {
+		return
+	}
+	if c.State() == stateActive && current-c.lastIncomingTime.Load() >= 30000 {
+		c.Close()
+	}
+	if c.State() == stateReadyToClose && c.sendingWorker.IsEmpty() {
+		c.setState(stateTerminating)
+	}
+	if c.State() == stateTerminating {
+		c.ping(current, commandTerminate)
+		if current-c.stateBeginTime.Load() > 8000 {
+			c.setState(stateTerminated)
+		}
+		return
+	}
+	if c.State() == statePeerTerminating && current-c.stateBeginTime.Load() > 4000 {
+		c.setState(stateTerminating)
+	}
+	if c.State() == stateReadyToClose && current-c.stateBeginTime.Load() > 15000 {
+		c.setState(stateTerminating)
+	}
+	c.receivingWorker.Flush(current)
+	c.sendingWorker.Flush(current)
+	if current-c.lastPingTime.Load() >= 3000 {
+		c.ping(current, commandPing)
+	}
+}
+
+func (c *Conn) ping(current uint32, command byte) {
+	seg := &commandSegment{
+		conv:          c.conversation,
+		command:       command,
+		receivingNext: c.receivingWorker.NextNumber(),
+		sendingNext:   c.sendingWorker.FirstUnacknowledged(),
+		peerRTO:       c.roundTrip.Timeout(),
+	}
+	if c.State() == stateReadyToClose {
+		seg.option = optionClose
+	}
+	c.output.Write(seg)
+	c.lastPingTime.Store(current)
+}
@@ -0,0 +1,121 @@
+package v2raykcp
+
+import (
+	"encoding/binary"
+	"math/rand"
+
+	C "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// packetHeader disguises packets as other UDP protocols,
+// it is written before the encrypted payload and ignored by the receiver.
+type packetHeader interface {
+	Size() int
+	Serialize(b []byte)
+}
+
+func newPacketHeader(headerType string) (packetHeader, error) {
+	switch headerType {
+	case "", C.KCPHeaderNone:
+		return nil, nil
+	case C.KCPHeaderSRTP:
+		return &srtpHeader{header: 0xB5E8, number: uint16(rand.Uint32())}, nil
+	case C.KCPHeaderUTP:
+		return &utpHeader{header: 1, connectionID: uint16(rand.Uint32())}, nil
+	case C.KCPHeaderWechatVideo:
+		return &wechatVideoHeader{sn: rand.Uint32() & 0xFFFF}, nil
+	case C.KCPHeaderDTLS:
+		return &dtlsHeader{epoch: uint16(rand.Uint32()), length: 17}, nil
+	case C.KCPHeaderWireGuard:
+		return wireGuardHeader{}, nil
+	default:
+		return nil, E.New("unknown header type: ", headerType)
+	}
+}
+
+type srtpHeader struct {
+	header uint16
+	number uint16
+}
+
+func (h *srtpHeader) Size() int {
+	return 4
+}
+
+func (h *srtpHeader) Serialize(b []byte) {
+	h.number++
+	binary.BigEndian.PutUint16(b, h.header)
+	binary.BigEndian.PutUint16(b[2:], h.number)
+}
+
+type utpHeader struct {
+	header       byte
+	extension    byte
+	connectionID uint16
+}
+
+func (h *utpHeader) Size() int {
+	return 4
+}
+
+func (h *utpHeader) Serialize(b []byte) {
+	binary.BigEndian.PutUint16(b, h.connectionID)
+	b[2] = h.header
+	b[3] = h.extension
+}
+
+type wechatVideoHeader struct {
+	sn uint32
+}
+
+func (h *wechatVideoHeader) Size() int {
+	return 13
+}
+
+func (h *wechatVideoHeader) Serialize(b []byte) {
+	h.sn++
+	b[0] = 0xa1
+	b[1] = 0x08
+	binary.BigEndian.PutUint32(b[2:], h.sn)
+	copy(b[6:], []byte{0x00, 0x10, 0x11, 0x18, 0x30, 0x22, 0x30})
+}
+
+type dtlsHeader struct {
+	epoch    uint16
+	length   uint16
+	sequence uint32
+}
+
+func (h *dtlsHeader) Size() int {
+	return 13
+}
+
+func (h *dtlsHeader) Serialize(b []byte) {
+	b[0] = 23 // application data
+	b[1] = 254
+	b[2] = 253
+	binary.BigEndian.PutUint16(b[3:], h.epoch)
+	b[5] = 0
+	b[6] = 0
+	binary.BigEndian.PutUint32(b[7:], h.sequence)
+	h.sequence++
+	binary.BigEndian.PutUint16(b[11:], h.length)
+	h.length += 17
+	if h.length > 100 {
+		h.length -= 50
+	}
+}
+
+type wireGuardHeader struct{}
+
+func (wireGuardHeader) Size() int {
+	return 4
+}
+
+func (wireGuardHeader) Serialize(b []byte) {
+	b[0] = 0x04
+	b[1] = 0x00
+	b[2] = 0x00
+	b[3] = 0x00
+}
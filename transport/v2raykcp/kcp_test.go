@@ -0,0 +1,83 @@
+package v2raykcp_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/v2raykcp"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+type echoHandler struct{}
+
+func (h *echoHandler) NewConnectionEx(ctx context.Context, conn net.Conn, source M.Socksaddr, destination M.Socksaddr, onClose N.CloseHandlerFunc) {
+	defer conn.Close()
+	io.Copy(conn, conn)
+}
+
+func TestKCPLoopback(t *testing.T) {
+	t.Parallel()
+	for name, options := range map[string]option.V2RayKCPOptions{
+		"plain": {},
+		"seed with header": {
+			Seed:       "sing-box",
+			HeaderType: C.KCPHeaderWechatVideo,
+		},
+		"congestion": {
+			Congestion: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			testKCPLoopback(t, options)
+		})
+	}
+}
+
+func testKCPLoopback(t *testing.T, options option.V2RayKCPOptions) {
+	ctx := context.Background()
+	server, err := v2raykcp.NewServer(ctx, log.NewNOPFactory().Logger(), options, nil, &echoHandler{})
+	require.NoError(t, err)
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.ServePacket(packetConn)
+	defer server.Close()
+
+	client, err := v2raykcp.NewClient(ctx, N.SystemDialer, M.SocksaddrFromNet(packetConn.LocalAddr()), options, nil)
+	require.NoError(t, err)
+	conn, err := client.DialContext(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	payload := make([]byte, 256*1024)
+	_, err = rand.Read(payload)
+	require.NoError(t, err)
+	go conn.Write(payload)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(10*time.Second)))
+	received := make([]byte, len(payload))
+	_, err = io.ReadFull(conn, received)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(payload, received))
+}
+
+func TestKCPServerClose(t *testing.T) {
+	t.Parallel()
+	server, err := v2raykcp.NewServer(context.Background(), log.NewNOPFactory().Logger(), option.V2RayKCPOptions{}, nil, &echoHandler{})
+	require.NoError(t, err)
+	require.NoError(t, server.Close())
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer packetConn.Close()
+	require.ErrorIs(t, server.ServePacket(packetConn), net.ErrClosed)
+}
@@ -0,0 +1,147 @@
+package v2raykcp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/fnv"
+	"sync"
+
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// packetCodec seals segments into UDP packets and opens them back.
+type packetCodec struct {
+	access   sync.Mutex
+	header   packetHeader
+	security cipher.AEAD
+}
+
+func newPacketCodec(config *config) (*packetCodec, error) {
+	header, err := newPacketHeader(config.headerType)
+	if err != nil {
+		return nil, err
+	}
+	var security cipher.AEAD
+	if config.seed != "" {
+		security = newSeedAEAD(config.seed)
+	} else {
+		security = simpleAuthenticator{}
+	}
+	return &packetCodec{
+		header:   header,
+		security: security,
+	}, nil
+}
+
+func (c *packetCodec) headerSize() int {
+	if c.header == nil {
+		return 0
+	}
+	return c.header.Size()
+}
+
+// Overhead does not count the nonce, to keep the MSS consistent with v2ray.
+func (c *packetCodec) Overhead() int {
+	return c.headerSize() + c.security.Overhead()
+}
+
+func (c *packetCodec) Encode(payload []byte) []byte {
+	headerSize := c.headerSize()
+	nonceSize := c.security.NonceSize()
+	packet := make([]byte, headerSize+nonceSize, headerSize+nonceSize+c.security.Overhead()+len(payload))
+	if c.header != nil {
+		c.access.Lock()
+		c.header.Serialize(packet[:headerSize])
+		c.access.Unlock()
+	}
+	nonce := packet[headerSize:]
+	common.Must1(rand.Read(nonce))
+	return c.security.Seal(packet, nonce, payload, nil)
+}
+
+func (c *packetCodec) Decode(packet []byte) []segment {
+	headerSize := c.headerSize()
+	if len(packet) <= headerSize {
+		return nil
+	}
+	packet = packet[headerSize:]
+	nonceSize := c.security.NonceSize()
+	if len(packet) <= nonceSize+c.security.Overhead() {
+		return nil
+	}
+	payload, err := c.security.Open(nil, packet[:nonceSize], packet[nonceSize:], nil)
+	if err != nil {
+		return nil
+	}
+	var segments []segment
+	for len(payload) > 0 {
+		var seg segment
+		seg, payload = readSegment(payload)
+		if seg == nil {
+			break
+		}
+		segments = append(segments, seg)
+	}
+	return segments
+}
+
+func newSeedAEAD(seed string) cipher.AEAD {
+	hashedSeed := sha256.Sum256([]byte(seed))
+	block := common.Must1(aes.NewCipher(hashedSeed[:16]))
+	return common.Must1(cipher.NewGCM(block))
+}
+
+var _ cipher.AEAD = simpleAuthenticator{}
+
+// simpleAuthenticator is the unkeyed packet checksum used without a seed:
+// an FNV-1a hash and the length, obfuscated with a 4-byte forward XOR.
+type simpleAuthenticator struct{}
+
+func (simpleAuthenticator) NonceSize() int {
+	return 0
+}
+
+func (simpleAuthenticator) Overhead() int {
+	return 6
+}
+
+func (simpleAuthenticator) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	start := len(dst)
+	dst = append(dst, 0, 0, 0, 0, 0, 0)
+	sealed := dst[start:]
+	binary.BigEndian.PutUint16(sealed[4:], uint16(len(plaintext)))
+	dst = append(dst, plaintext...)
+	sealed = dst[start:]
+	hash := fnv.New32a()
+	hash.Write(sealed[4:])
+	binary.BigEndian.PutUint32(sealed, hash.Sum32())
+	for i := 4; i < len(sealed); i++ {
+		sealed[i] ^= sealed[i-4]
+	}
+	return dst
+}
+
+func (simpleAuthenticator) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	start := len(dst)
+	dst = append(dst, ciphertext...)
+	opened := dst[start:]
+	for i := len(opened) - 1; i >= 4; i-- {
+		opened[i] ^= opened[i-4]
+	}
+	if len(opened) < 6 {
+		return nil, E.New("invalid auth")
+	}
+	hash := fnv.New32a()
+	hash.Write(opened[4:])
+	if binary.BigEndian.Uint32(opened) != hash.Sum32() {
+		return nil, E.New("invalid auth")
+	}
+	if int(binary.BigEndian.Uint16(opened[4:])) != len(opened)-6 {
+		return nil, E.New("invalid auth")
+	}
+	return append(dst[:start], opened[6:]...), nil
+}
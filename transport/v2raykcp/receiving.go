@@ -0,0 +1,188 @@
+package v2raykcp
+
+import (
+	"sync"
+)
+
+type ackList struct {
+	writer          func(seg *ackSegment)
+	timestamps      []uint32
+	numbers         []uint32
+	nextFlush       []uint32
+	flushCandidates []uint32
+	dirty           bool
+}
+
+func newAckList(writer func(seg *ackSegment)) *ackList {
+	return &ackList{
+		writer:          writer,
+		timestamps:      make([]uint32, 0, ackNumberLimit),
+		numbers:         make([]uint32, 0, ackNumberLimit),
+		nextFlush:       make([]uint32, 0, ackNumberLimit),
+		flushCandidates: make([]uint32, 0, ackNumberLimit),
+	}
+}
+
+func (l *ackList) Add(number uint32, timestamp uint32) {
+	l.timestamps = append(l.timestamps, timestamp)
+	l.numbers = append(l.numbers, number)
+	l.nextFlush = append(l.nextFlush, 0)
+	l.dirty = true
+}
+
+// Clear drops numbers the peer has stopped waiting for.
+func (l *ackList) Clear(una uint32) {
+	count := 0
+	for i := range l.numbers {
+		if l.numbers[i] < una {
+			continue
+		}
+		if i != count {
+			l.numbers[count] = l.numbers[i]
+			l.timestamps[count] = l.timestamps[i]
+			l.nextFlush[count] = l.nextFlush[i]
+		}
+		count++
+	}
+	if count < len(l.numbers) {
+		l.numbers = l.numbers[:count]
+		l.timestamps = l.timestamps[:count]
+		l.nextFlush = l.nextFlush[:count]
+		l.dirty = true
+	}
+}
+
+func (l *ackList) Flush(current uint32, rto uint32) {
+	l.flushCandidates = l.flushCandidates[:0]
+	seg := &ackSegment{}
+	for i := range l.numbers {
+		if l.nextFlush[i] > current {
+			if len(l.flushCandidates) < cap(l.flushCandidates) {
+				l.flushCandidates = append(l.flushCandidates, l.numbers[i])
+			}
+			continue
+		}
+		seg.numbers = append(seg.numbers, l.numbers[i])
+		seg.putTimestamp(l.timestamps[i])
+		l.nextFlush[i] = current + max(rto/2, 20)
+		if seg.isFull() {
+			l.writer(seg)
+			seg = &ackSegment{}
+			l.dirty = false
+		}
+	}
+	if l.dirty || len(seg.numbers) > 0 {
+		for _, number := range l.flushCandidates {
+			if seg.isFull() {
+				break
+			}
+			seg.numbers = append(seg.numbers, number)
+		}
+		l.writer(seg)
+		l.dirty = false
+	}
+}
+
+type receivingWorker struct {
+	access     sync.Mutex
+	conn       *Conn
+	window     map[uint32]*dataSegment
+	acks       *ackList
+	leftOver   []byte
+	nextNumber uint32
+	windowSize uint32
+}
+
+func newReceivingWorker(conn *Conn) *receivingWorker {
+	worker := &receivingWorker{
+		conn:       conn,
+		window:     make(map[uint32]*dataSegment),
+		windowSize: conn.config.receivingInFlightSize(),
+	}
+	worker.acks = newAckList(worker.writeAck)
+	return worker
+}
+
+func (w *receivingWorker) Release() {
+	w.access.Lock()
+	defer w.access.Unlock()
+	w.window = make(map[uint32]*dataSegment)
+	w.leftOver = nil
+}
+
+func (w *receivingWorker) ProcessSendingNext(number uint32) {
+	w.access.Lock()
+	defer w.access.Unlock()
+	w.acks.Clear(number)
+}
+
+func (w *receivingWorker) ProcessSegment(seg *dataSegment) {
+	w.access.Lock()
+	defer w.access.Unlock()
+	if seg.number-w.nextNumber >= w.windowSize {
+		return
+	}
+	w.acks.Clear(seg.sendingNext)
+	w.acks.Add(seg.number, seg.timestamp)
+	if _, loaded := w.window[seg.number]; !loaded {
+		w.window[seg.number] = seg
+	}
+}
+
+func (w *receivingWorker) Read(b []byte) int {
+	w.access.Lock()
+	defer w.access.Unlock()
+	var n int
+	if len(w.leftOver) > 0 {
+		n = copy(b, w.leftOver)
+		w.leftOver = w.leftOver[n:]
+	}
+	for n < len(b) {
+		seg := w.window[w.nextNumber]
+		if seg == nil {
+			break
+		}
+		delete(w.window, w.nextNumber)
+		w.nextNumber++
+		copied := copy(b[n:], seg.payload)
+		n += copied
+		if copied < len(seg.payload) {
+			w.leftOver = seg.payload[copied:]
+		}
+	}
+	return n
+}
+
+func (w *receivingWorker) IsDataAvailable() bool {
+	w.access.Lock()
+	defer w.access.Unlock()
+	return w.window[w.nextNumber] != nil
+}
+
+func (w *receivingWorker) NextNumber() uint32 {
+	w.access.Lock()
+	defer w.access.Unlock()
+	return w.nextNumber
+}
+
+func (w *receivingWorker) Flush(current uint32) {
+	w.access.Lock()
+	defer w.access.Unlock()
+	w.acks.Flush(current, w.conn.roundTrip.Timeout())
+}
+
+func (w *receivingWorker) writeAck(seg *ackSegment) {
+	seg.conv = w.conn.conversation
+	seg.receivingNext = w.nextNumber
+	seg.receivingWindow = w.nextNumber + w.windowSize
+	if w.conn.State() == stateReadyToClose {
+		seg.option = optionClose
+	}
+	w.conn.output.Write(seg)
+}
+
+func (w *receivingWorker) UpdateNecessary() bool {
+	w.access.Lock()
+	defer w.access.Unlock()
+	return len(w.acks.numbers) > 0
+}
@@ -0,0 +1,215 @@
+package v2raykcp
+
+import (
+	"encoding/binary"
+)
+
+const (
+	commandACK       byte = 0
+	commandData      byte = 1
+	commandTerminate byte = 2
+	commandPing      byte = 3
+)
+
+const optionClose byte = 1
+
+const (
+	dataSegmentOverhead = 18
+	ackNumberLimit      = 128
+)
+
+type segment interface {
+	Conversation() uint16
+	Command() byte
+	ByteSize() int
+	Serialize(b []byte)
+}
+
+type dataSegment struct {
+	conv        uint16
+	option      byte
+	timestamp   uint32
+	number      uint32
+	sendingNext uint32
+	payload     []byte
+
+	// sending state
+	timeout  uint32
+	transmit uint32
+}
+
+func (s *dataSegment) Conversation() uint16 {
+	return s.conv
+}
+
+func (s *dataSegment) Command() byte {
+	return commandData
+}
+
+func (s *dataSegment) ByteSize() int {
+	return dataSegmentOverhead + len(s.payload)
+}
+
+func (s *dataSegment) Serialize(b []byte) {
+	binary.BigEndian.PutUint16(b, s.conv)
+	b[2] = commandData
+	b[3] = s.option
+	binary.BigEndian.PutUint32(b[4:], s.timestamp)
+	binary.BigEndian.PutUint32(b[8:], s.number)
+	binary.BigEndian.PutUint32(b[12:], s.sendingNext)
+	binary.BigEndian.PutUint16(b[16:], uint16(len(s.payload)))
+	copy(b[18:], s.payload)
+}
+
+func (s *dataSegment) parse(b []byte) ([]byte, bool) {
+	if len(b) < 14 {
+		return nil, false
+	}
+	s.timestamp = binary.BigEndian.Uint32(b)
+	s.number = binary.BigEndian.Uint32(b[4:])
+	s.sendingNext = binary.BigEndian.Uint32(b[8:])
+	dataLen := int(binary.BigEndian.Uint16(b[12:]))
+	b = b[14:]
+	if len(b) < dataLen {
+		return nil, false
+	}
+	s.payload = append([]byte(nil), b[:dataLen]...)
+	return b[dataLen:], true
+}
+
+type ackSegment struct {
+	conv            uint16
+	option          byte
+	receivingWindow uint32
+	receivingNext   uint32
+	timestamp       uint32
+	numbers         []uint32
+}
+
+func (s *ackSegment) Conversation() uint16 {
+	return s.conv
+}
+
+func (s *ackSegment) Command() byte {
+	return commandACK
+}
+
+func (s *ackSegment) ByteSize() int {
+	return 17 + len(s.numbers)*4
+}
+
+func (s *ackSegment) Serialize(b []byte) {
+	binary.BigEndian.PutUint16(b, s.conv)
+	b[2] = commandACK
+	b[3] = s.option
+	binary.BigEndian.PutUint32(b[4:], s.receivingWindow)
+	binary.BigEndian.PutUint32(b[8:], s.receivingNext)
+	binary.BigEndian.PutUint32(b[12:], s.timestamp)
+	b[16] = byte(len(s.numbers))
+	for i, number := range s.numbers {
+		binary.BigEndian.PutUint32(b[17+i*4:], number)
+	}
+}
+
+func (s *ackSegment) parse(b []byte) ([]byte, bool) {
+	if len(b) < 13 {
+		return nil, false
+	}
+	s.receivingWindow = binary.BigEndian.Uint32(b)
+	s.receivingNext = binary.BigEndian.Uint32(b[4:])
+	s.timestamp = binary.BigEndian.Uint32(b[8:])
+	count := int(b[12])
+	b = b[13:]
+	if len(b) < count*4 {
+		return nil, false
+	}
+	s.numbers = make([]uint32, count)
+	for i := range s.numbers {
+		s.numbers[i] = binary.BigEndian.Uint32(b[i*4:])
+	}
+	return b[count*4:], true
+}
+
+func (s *ackSegment) putTimestamp(timestamp uint32) {
+	if timestamp-s.timestamp < 0x7FFFFFFF {
+		s.timestamp = timestamp
+	}
+}
+
+func (s *ackSegment) isFull() bool {
+	return len(s.numbers) == ackNumberLimit
+}
+
+type commandSegment struct {
+	conv          uint16
+	command       byte
+	option        byte
+	sendingNext   uint32
+	receivingNext uint32
+	peerRTO       uint32
+}
+
+func (s *commandSegment) Conversation() uint16 {
+	return s.conv
+}
+
+func (s *commandSegment) Command() byte {
+	return s.command
+}
+
+func (s *commandSegment) ByteSize() int {
+	return 16
+}
+
+func (s *commandSegment) Serialize(b []byte) {
+	binary.BigEndian.PutUint16(b, s.conv)
+	b[2] = s.command
+	b[3] = s.option
+	binary.BigEndian.PutUint32(b[4:], s.sendingNext)
+	binary.BigEndian.PutUint32(b[8:], s.receivingNext)
+	binary.BigEndian.PutUint32(b[12:], s.peerRTO)
+}
+
+func (s *commandSegment) parse(b []byte) ([]byte, bool) {
+	if len(b) < 12 {
+		return nil, false
+	}
+	s.sendingNext = binary.BigEndian.Uint32(b)
+	s.receivingNext = binary.BigEndian.Uint32(b[4:])
+	s.peerRTO = binary.BigEndian.Uint32(b[8:])
+	return b[12:], true
+}
+
+// readSegment parses the first segment in b and returns the remaining bytes,
+// a packet may carry several segments.
+func readSegment(b []byte) (segment, []byte) {
+	if len(b) < 4 {
+		return nil, nil
+	}
+	conv := binary.BigEndian.Uint16(b)
+	command := b[2]
+	option := b[3]
+	b = b[4:]
+	var ok bool
+	switch command {
+	case commandData:
+		seg := &dataSegment{conv: conv, option: option}
+		b, ok = seg.parse(b)
+		if ok {
+			return seg, b
+		}
+	case commandACK:
+		seg := &ackSegment{conv: conv, option: option}
+		b, ok = seg.parse(b)
+		if ok {
+			return seg, b
+		}
+	case commandTerminate, commandPing:
+		seg := &commandSegment{conv: conv, command: command, option: option}
+		b, ok = seg.parse(b)
+		if ok {
+			return seg, b
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,283 @@
+package v2raykcp
+
+import (
+	"container/list"
+	"sync"
+)
+
+type sendingWindow struct {
+	cache             *list.List
+	totalInFlightSize uint32
+	writer            func(seg *dataSegment)
+	onPacketLoss      func(lossRate uint32)
+}
+
+func (w *sendingWindow) Len() uint32 {
+	return uint32(w.cache.Len())
+}
+
+func (w *sendingWindow) IsEmpty() bool {
+	return w.cache.Len() == 0
+}
+
+func (w *sendingWindow) Push(number uint32, payload []byte) {
+	w.cache.PushBack(&dataSegment{
+		number:  number,
+		payload: payload,
+	})
+}
+
+func (w *sendingWindow) FirstNumber() uint32 {
+	return w.cache.Front().Value.(*dataSegment).number
+}
+
+func (w *sendingWindow) Clear(una uint32) {
+	for !w.IsEmpty() {
+		element := w.cache.Front()
+		if element.Value.(*dataSegment).number >= una {
+			break
+		}
+		w.cache.Remove(element)
+	}
+}
+
+// HandleFastAck shortens the timeout of sent segments before the acknowledged one,
+// as they are likely lost.
+func (w *sendingWindow) HandleFastAck(number uint32, rto uint32) {
+	for element := w.cache.Front(); element != nil; element = element.Next() {
+		seg := element.Value.(*dataSegment)
+		if number == seg.number || number-seg.number > 0x7FFFFFFF {
+			break
+		}
+		if seg.transmit > 0 && seg.timeout > rto/3 {
+			seg.timeout -= rto / 3
+		}
+	}
+}
+
+// Flush sends new and timed out segments numbered before cwnd.
+func (w *sendingWindow) Flush(current uint32, rto uint32, cwnd uint32) {
+	if w.IsEmpty() {
+		return
+	}
+	var (
+		lost         uint32
+		inFlightSize uint32
+	)
+	for element := w.cache.Front(); element != nil; element = element.Next() {
+		seg := element.Value.(*dataSegment)
+		if seg.number-cwnd < 0x7FFFFFFF {
+			break
+		}
+		if current-seg.timeout >= 0x7FFFFFFF {
+			continue
+		}
+		if seg.transmit == 0 {
+			w.totalInFlightSize++
+		} else {
+			lost++
+		}
+		seg.timeout = current + rto
+		seg.timestamp = current
+		seg.transmit++
+		w.writer(seg)
+		inFlightSize++
+	}
+	if inFlightSize > 0 && w.totalInFlightSize != 0 {
+		w.onPacketLoss(lost * 100 / w.totalInFlightSize)
+	}
+}
+
+func (w *sendingWindow) Remove(number uint32) bool {
+	for element := w.cache.Front(); element != nil; element = element.Next() {
+		seg := element.Value.(*dataSegment)
+		if seg.number > number {
+			return false
+		} else if seg.number == number {
+			if w.totalInFlightSize > 0 {
+				w.totalInFlightSize--
+			}
+			w.cache.Remove(element)
+			return true
+		}
+	}
+	return false
+}
+
+type sendingWorker struct {
+	access                     sync.Mutex
+	conn                       *Conn
+	window                     *sendingWindow
+	firstUnacknowledged        uint32
+	nextNumber                 uint32
+	remoteNextNumber           uint32
+	controlWindow              uint32
+	windowSize                 uint32
+	firstUnacknowledgedUpdated bool
+	closed                     bool
+}
+
+func newSendingWorker(conn *Conn) *sendingWorker {
+	worker := &sendingWorker{
+		conn:             conn,
+		remoteNextNumber: 32,
+		controlWindow:    conn.config.sendingInFlightSize(),
+		windowSize:       conn.config.sendingBufferSize(),
+	}
+	worker.window = &sendingWindow{
+		cache:        list.New(),
+		writer:       worker.writeData,
+		onPacketLoss: worker.onPacketLoss,
+	}
+	return worker
+}
+
+func (w *sendingWorker) Release() {
+	w.access.Lock()
+	defer w.access.Unlock()
+	w.window.cache.Init()
+	w.closed = true
+}
+
+func (w *sendingWorker) ProcessReceivingNext(number uint32) {
+	w.access.Lock()
+	defer w.access.Unlock()
+	w.processReceivingNext(number)
+}
+
+func (w *sendingWorker) processReceivingNext(number uint32) {
+	w.window.Clear(number)
+	w.findFirstUnacknowledged()
+}
+
+func (w *sendingWorker) findFirstUnacknowledged() {
+	first := w.firstUnacknowledged
+	if !w.window.IsEmpty() {
+		w.firstUnacknowledged = w.window.FirstNumber()
+	} else {
+		w.firstUnacknowledged = w.nextNumber
+	}
+	if first != w.firstUnacknowledged {
+		w.firstUnacknowledgedUpdated = true
+	}
+}
+
+func (w *sendingWorker) processAck(number uint32) bool {
+	// number < first unacknowledged || number >= next number
+	if number-w.firstUnacknowledged > 0x7FFFFFFF || number-w.nextNumber < 0x7FFFFFFF {
+		return false
+	}
+	removed := w.window.Remove(number)
+	if removed {
+		w.findFirstUnacknowledged()
+	}
+	return removed
+}
+
+func (w *sendingWorker) ProcessSegment(current uint32, seg *ackSegment, rto uint32) {
+	w.access.Lock()
+	defer w.access.Unlock()
+	if w.closed {
+		return
+	}
+	if w.remoteNextNumber < seg.receivingWindow {
+		w.remoteNextNumber = seg.receivingWindow
+	}
+	w.processReceivingNext(seg.receivingNext)
+	if len(seg.numbers) == 0 {
+		return
+	}
+	var (
+		maxAck        uint32
+		maxAckRemoved bool
+	)
+	for _, number := range seg.numbers {
+		removed := w.processAck(number)
+		if maxAck < number {
+			maxAck = number
+			maxAckRemoved = removed
+		}
+	}
+	if maxAckRemoved {
+		w.window.HandleFastAck(maxAck, rto)
+		if current-seg.timestamp < 10000 {
+			w.conn.roundTrip.Update(current-seg.timestamp, current)
+		}
+	}
+}
+
+func (w *sendingWorker) Push(payload []byte) bool {
+	w.access.Lock()
+	defer w.access.Unlock()
+	if w.closed || w.window.Len() > w.windowSize {
+		return false
+	}
+	w.window.Push(w.nextNumber, payload)
+	w.nextNumber++
+	return true
+}
+
+func (w *sendingWorker) writeData(seg *dataSegment) {
+	seg.conv = w.conn.conversation
+	seg.sendingNext = w.firstUnacknowledged
+	seg.option = 0
+	if w.conn.State() == stateReadyToClose {
+		seg.option = optionClose
+	}
+	w.conn.output.Write(seg)
+}
+
+func (w *sendingWorker) onPacketLoss(lossRate uint32) {
+	if !w.conn.config.congestion || w.conn.roundTrip.Timeout() == 0 {
+		return
+	}
+	if lossRate >= 15 {
+		w.controlWindow = 3 * w.controlWindow / 4
+	} else if lossRate <= 5 {
+		w.controlWindow += w.controlWindow / 4
+	}
+	w.controlWindow = min(max(w.controlWindow, 16), 2*w.conn.config.sendingInFlightSize())
+}
+
+func (w *sendingWorker) Flush(current uint32) {
+	w.access.Lock()
+	if w.closed {
+		w.access.Unlock()
+		return
+	}
+	cwnd := w.firstUnacknowledged + w.conn.config.sendingInFlightSize()
+	if cwnd > w.remoteNextNumber {
+		cwnd = w.remoteNextNumber
+	}
+	if w.conn.config.congestion && cwnd > w.firstUnacknowledged+w.controlWindow {
+		cwnd = w.firstUnacknowledged + w.controlWindow
+	}
+	if !w.window.IsEmpty() {
+		w.window.Flush(current, w.conn.roundTrip.Timeout(), cwnd)
+		w.firstUnacknowledgedUpdated = false
+	}
+	updated := w.firstUnacknowledgedUpdated
+	w.firstUnacknowledgedUpdated = false
+	w.access.Unlock()
+	if updated {
+		w.conn.ping(current, commandPing)
+	}
+}
+
+func (w *sendingWorker) CloseWrite() {
+	w.access.Lock()
+	defer w.access.Unlock()
+	w.window.Clear(0xFFFFFFFF)
+}
+
+func (w *sendingWorker) IsEmpty() bool {
+	w.access.Lock()
+	defer w.access.Unlock()
+	return w.window.IsEmpty()
+}
+
+func (w *sendingWorker) FirstUnacknowledged() uint32 {
+	w.access.Lock()
+	defer w.access.Unlock()
+	return w.firstUnacknowledged
+}
@@ -0,0 +1,159 @@
+package v2raykcp
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+var _ adapter.V2RayServerTransport = (*Server)(nil)
+
+// maxSessions limits concurrent sessions, since any packet with a new
+// conversation from a new source starts one.
+const maxSessions = 1024
+
+type Server struct {
+	ctx        context.Context
+	logger     logger.ContextLogger
+	config     *config
+	codec      *packetCodec
+	tlsConfig  tls.ServerConfig
+	handler    adapter.V2RayServerTransportHandler
+	access     sync.Mutex
+	sessions   map[sessionID]*Conn
+	packetConn net.PacketConn
+	closed     bool
+}
+
+type sessionID struct {
+	source       netip.AddrPort
+	conversation uint16
+}
+
+func NewServer(ctx context.Context, logger logger.ContextLogger, options option.V2RayKCPOptions, tlsConfig tls.ServerConfig, handler adapter.V2RayServerTransportHandler) (*Server, error) {
+	config, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := newPacketCodec(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		ctx:       ctx,
+		logger:    logger,
+		config:    config,
+		codec:     codec,
+		tlsConfig: tlsConfig,
+		handler:   handler,
+		sessions:  make(map[sessionID]*Conn),
+	}, nil
+}
+
+func (s *Server) Network() []string {
+	return []string{N.NetworkUDP}
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	return os.ErrInvalid
+}
+
+func (s *Server) ServePacket(listener net.PacketConn) error {
+	s.access.Lock()
+	if s.closed {
+		s.access.Unlock()
+		return net.ErrClosed
+	}
+	s.packetConn = listener
+	s.access.Unlock()
+	buffer := make([]byte, 65535)
+	for {
+		n, addr, err := listener.ReadFrom(buffer)
+		if err != nil {
+			return err
+		}
+		segments := s.codec.Decode(buffer[:n])
+		if len(segments) == 0 {
+			continue
+		}
+		s.input(listener, segments, addr)
+	}
+}
+
+func (s *Server) input(packetConn net.PacketConn, segments []segment, addr net.Addr) {
+	source := M.SocksaddrFromNet(addr).Unwrap()
+	id := sessionID{source.AddrPort(), segments[0].Conversation()}
+	s.access.Lock()
+	conn, loaded := s.sessions[id]
+	if !loaded {
+		if s.closed || segments[0].Command() == commandTerminate {
+			s.access.Unlock()
+			return
+		}
+		if len(s.sessions) >= maxSessions {
+			s.access.Unlock()
+			s.logger.Debug("drop session from ", source, ": too many sessions")
+			return
+		}
+		conn = newConn(id.conversation, packetConn.LocalAddr(), addr, s.codec, func(packet []byte) error {
+			_, err := packetConn.WriteTo(packet, addr)
+			return err
+		}, &sessionCloser{s, id}, s.config)
+		s.sessions[id] = conn
+		go s.handleConn(conn, source)
+	}
+	s.access.Unlock()
+	conn.input(segments)
+}
+
+func (s *Server) handleConn(conn *Conn, source M.Socksaddr) {
+	ctx := log.ContextWithNewID(s.ctx)
+	var netConn net.Conn = conn
+	if s.tlsConfig != nil {
+		tlsConn, err := tls.ServerHandshake(ctx, conn, s.tlsConfig)
+		if err != nil {
+			conn.Close()
+			s.logger.ErrorContext(ctx, E.Cause(err, "process connection from ", source, ": TLS handshake"))
+			return
+		}
+		netConn = tlsConn
+	}
+	s.handler.NewConnectionEx(ctx, netConn, source, M.Socksaddr{}, nil)
+}
+
+func (s *Server) Close() error {
+	s.access.Lock()
+	s.closed = true
+	sessions := s.sessions
+	s.sessions = make(map[sessionID]*Conn)
+	packetConn := s.packetConn
+	s.access.Unlock()
+	for _, conn := range sessions {
+		conn.Close()
+	}
+	return common.Close(packetConn)
+}
+
+type sessionCloser struct {
+	server *Server
+	id     sessionID
+}
+
+func (c *sessionCloser) Close() error {
+	c.server.access.Lock()
+	delete(c.server.sessions, c.id)
+	c.server.access.Unlock()
+	return nil
+}
@@ -0,0 +1,65 @@
+package v2rayobfs
+
+import (
+	"context"
+	"net"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/simple-obfs"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+var _ adapter.V2RayClientTransport = (*Client)(nil)
+
+type Client struct {
+	dialer     N.Dialer
+	serverAddr M.Socksaddr
+	tls        bool
+	host       string
+	port       string
+}
+
+func NewClient(ctx context.Context, dialer N.Dialer, serverAddr M.Socksaddr, options option.V2RayObfsOptions, tlsConfig tls.Config) (adapter.V2RayClientTransport, error) {
+	if tlsConfig != nil {
+		return nil, E.New("TLS is not supported by obfs transport")
+	}
+	client := &Client{
+		dialer:     dialer,
+		serverAddr: serverAddr,
+		host:       options.Host,
+		port:       F.ToString(serverAddr.Port),
+	}
+	switch options.Mode {
+	case "", C.ObfsModeHTTP:
+	case C.ObfsModeTLS:
+		client.tls = true
+	default:
+		return nil, E.New("unknown obfs mode: ", options.Mode)
+	}
+	if client.host == "" {
+		client.host = serverAddr.AddrString()
+	}
+	return client, nil
+}
+
+func (c *Client) DialContext(ctx context.Context) (net.Conn, error) {
+	conn, err := c.dialer.DialContext(ctx, N.NetworkTCP, c.serverAddr)
+	if err != nil {
+		return nil, err
+	}
+	if c.tls {
+		return obfs.NewTLSObfs(conn, c.host), nil
+	} else {
+		return obfs.NewHTTPObfs(conn, c.host, c.port), nil
+	}
+}
+
+func (c *Client) Close() error {
+	return nil
+}
@@ -0,0 +1,91 @@
+package v2rayobfs
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/simple-obfs"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+var _ adapter.V2RayServerTransport = (*Server)(nil)
+
+type Server struct {
+	ctx      context.Context
+	logger   logger.ContextLogger
+	handler  adapter.V2RayServerTransportHandler
+	tls      bool
+	host     string
+	access   sync.Mutex
+	listener net.Listener
+	closed   bool
+}
+
+func NewServer(ctx context.Context, logger logger.ContextLogger, options option.V2RayObfsOptions, tlsConfig tls.ServerConfig, handler adapter.V2RayServerTransportHandler) (*Server, error) {
+	if tlsConfig != nil {
+		return nil, E.New("TLS is not supported by obfs transport")
+	}
+	server := &Server{
+		ctx:     ctx,
+		logger:  logger,
+		handler: handler,
+		host:    options.Host,
+	}
+	switch options.Mode {
+	case "", C.ObfsModeHTTP:
+	case C.ObfsModeTLS:
+		server.tls = true
+	default:
+		return nil, E.New("unknown obfs mode: ", options.Mode)
+	}
+	return server, nil
+}
+
+func (s *Server) Network() []string {
+	return []string{N.NetworkTCP}
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	s.access.Lock()
+	if s.closed {
+		s.access.Unlock()
+		return net.ErrClosed
+	}
+	s.listener = listener
+	s.access.Unlock()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		if s.tls {
+			conn = obfs.NewTLSObfsServer(conn, s.host)
+		} else {
+			conn = obfs.NewHTTPObfsServer(conn, s.host)
+		}
+		go s.handler.NewConnectionEx(log.ContextWithNewID(s.ctx), conn, M.SocksaddrFromNet(conn.RemoteAddr()).Unwrap(), M.Socksaddr{}, nil)
+	}
+}
+
+func (s *Server) ServePacket(listener net.PacketConn) error {
+	return os.ErrInvalid
+}
+
+func (s *Server) Close() error {
+	s.access.Lock()
+	s.closed = true
+	listener := s.listener
+	s.access.Unlock()
+	return common.Close(listener)
+}
@@ -0,0 +1,57 @@
+package v2rayplugin
+
+import (
+	"context"
+	"net"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/v2raywebsocket"
+	"github.com/sagernet/sing/common/json/badoption"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+var _ adapter.V2RayClientTransport = (*Client)(nil)
+
+// Client speaks the websocket mode of v2ray-plugin,
+// every connection is a websocket carrying a single VMess mux stream unless mux is disabled.
+type Client struct {
+	websocket  adapter.V2RayClientTransport
+	disableMux bool
+}
+
+func NewClient(ctx context.Context, dialer N.Dialer, serverAddr M.Socksaddr, options option.V2RayPluginOptions, tlsConfig tls.Config) (adapter.V2RayClientTransport, error) {
+	websocketOptions := option.V2RayWebsocketOptions{
+		Path: options.Path,
+	}
+	if options.Host != "" {
+		websocketOptions.Headers = badoption.HTTPHeader{
+			"Host": {options.Host},
+		}
+	}
+	websocketClient, err := v2raywebsocket.NewClient(ctx, dialer, serverAddr, websocketOptions, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		websocket:  websocketClient,
+		disableMux: options.DisableMux,
+	}, nil
+}
+
+func (c *Client) DialContext(ctx context.Context) (net.Conn, error) {
+	conn, err := c.websocket.DialContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if c.disableMux {
+		return conn, nil
+	}
+	return newMuxConn(conn), nil
+}
+
+func (c *Client) Close() error {
+	return c.websocket.Close()
+}
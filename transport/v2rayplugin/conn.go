@@ -0,0 +1,63 @@
+package v2rayplugin
+
+import (
+	"net"
+
+	"github.com/sagernet/sing-vmess"
+	"github.com/sagernet/sing/common/buf"
+)
+
+// muxFrameSize keeps every frame within the 16-bit length of the mux protocol.
+const muxFrameSize = 8192
+
+// muxConn adapts the single stream mux wrapper to plain reads and writes of any size,
+// the wrapper itself fails on reads shorter than a frame header.
+type muxConn struct {
+	net.Conn
+	reader *vmess.MuxConnWrapper
+	cache  *buf.Buffer
+}
+
+func newMuxConn(conn net.Conn) *muxConn {
+	wrapper := vmess.NewMuxConnWrapper(conn, vmess.MuxDestination)
+	return &muxConn{
+		Conn:   wrapper,
+		reader: wrapper,
+	}
+}
+
+func (c *muxConn) Read(p []byte) (n int, err error) {
+	// keep-alive and empty frames carry no data, so read on until one does
+	for c.cache == nil {
+		c.cache = buf.NewSize(muxFrameSize)
+		err = c.reader.ReadBuffer(c.cache)
+		if err != nil {
+			c.cache.Release()
+			c.cache = nil
+			return
+		}
+		if c.cache.IsEmpty() {
+			c.cache.Release()
+			c.cache = nil
+		}
+	}
+	n, _ = c.cache.Read(p)
+	if c.cache.IsEmpty() {
+		c.cache.Release()
+		c.cache = nil
+	}
+	return
+}
+
+func (c *muxConn) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		chunk := p[:min(len(p), muxFrameSize)]
+		_, err = c.Conn.Write(chunk)
+		if err != nil {
+			return
+		}
+		n += len(chunk)
+		p = p[len(chunk):]
+	}
+	return
+}
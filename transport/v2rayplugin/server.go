@@ -0,0 +1,137 @@
+package v2rayplugin
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/v2raywebsocket"
+	"github.com/sagernet/sing-vmess"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	aTLS "github.com/sagernet/sing/common/tls"
+)
+
+var (
+	_ adapter.V2RayServerTransport        = (*Server)(nil)
+	_ adapter.V2RayServerTransportHandler = (*muxHandler)(nil)
+)
+
+// Server serves the websocket transport, checking the Host header of requests first if configured.
+type Server struct {
+	ctx        context.Context
+	logger     logger.ContextLogger
+	host       string
+	tlsConfig  tls.ServerConfig
+	websocket  *v2raywebsocket.Server
+	httpServer *http.Server
+}
+
+func NewServer(ctx context.Context, logger logger.ContextLogger, options option.V2RayPluginOptions, tlsConfig tls.ServerConfig, handler adapter.V2RayServerTransportHandler) (*Server, error) {
+	websocketOptions := option.V2RayWebsocketOptions{
+		Path: options.Path,
+	}
+	if !options.DisableMux {
+		handler = &muxHandler{
+			logger:  logger,
+			handler: handler,
+		}
+	}
+	websocketServer, err := v2raywebsocket.NewServer(ctx, logger, websocketOptions, nil, handler)
+	if err != nil {
+		return nil, err
+	}
+	server := &Server{
+		ctx:       ctx,
+		logger:    logger,
+		host:      options.Host,
+		tlsConfig: tlsConfig,
+		websocket: websocketServer,
+	}
+	server.httpServer = &http.Server{
+		Handler:           server,
+		ReadHeaderTimeout: C.TCPTimeout,
+		MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+		ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+			return log.ContextWithNewID(ctx)
+		},
+	}
+	return server, nil
+}
+
+func (s *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if s.host != "" {
+		host, _, err := net.SplitHostPort(request.Host)
+		if err != nil {
+			host = request.Host
+		}
+		if !strings.EqualFold(host, s.host) {
+			writer.WriteHeader(http.StatusNotFound)
+			s.logger.ErrorContext(request.Context(), E.New("process connection from ", request.RemoteAddr, ": bad host: ", request.Host))
+			return
+		}
+	}
+	s.websocket.ServeHTTP(writer, request)
+}
+
+func (s *Server) Network() []string {
+	return []string{N.NetworkTCP}
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	if s.tlsConfig != nil {
+		listener = aTLS.NewListener(listener, s.tlsConfig)
+	}
+	return s.httpServer.Serve(listener)
+}
+
+func (s *Server) ServePacket(listener net.PacketConn) error {
+	return os.ErrInvalid
+}
+
+func (s *Server) Close() error {
+	return common.Close(common.PtrOrNil(s.httpServer))
+}
+
+type muxHandler struct {
+	logger  logger.ContextLogger
+	handler adapter.V2RayServerTransportHandler
+}
+
+func (h *muxHandler) NewConnectionEx(ctx context.Context, conn net.Conn, source M.Socksaddr, destination M.Socksaddr, onClose N.CloseHandlerFunc) {
+	err := vmess.HandleMuxConnection(ctx, conn, source, (*muxStreamHandler)(h))
+	if err != nil && !E.IsClosedOrCanceled(err) {
+		h.logger.DebugContext(ctx, E.Cause(err, "process v2ray-plugin connection from ", source))
+	}
+	conn.Close()
+	if onClose != nil {
+		onClose(err)
+	}
+}
+
+type muxStreamHandler muxHandler
+
+func (h *muxStreamHandler) NewConnectionEx(ctx context.Context, conn net.Conn, source M.Socksaddr, destination M.Socksaddr, onClose N.CloseHandlerFunc) {
+	// the stream destination is chosen by the plugin client and is meaningless here
+	h.handler.NewConnectionEx(ctx, conn, source, M.Socksaddr{}, onClose)
+}
+
+func (h *muxStreamHandler) NewPacketConnectionEx(ctx context.Context, conn N.PacketConn, source M.Socksaddr, destination M.Socksaddr, onClose N.CloseHandlerFunc) {
+	conn.Close()
+	if onClose != nil {
+		onClose(os.ErrInvalid)
+	}
+}
@@ -0,0 +1,98 @@
+//go:build with_quic
+
+package v2rayquic
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/sagernet/quic-go"
+	"github.com/sagernet/quic-go/http3"
+	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/transport/v2rayxhttp"
+	"github.com/sagernet/sing-quic"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/bufio"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+func init() {
+	v2rayxhttp.RegisterHTTP3Constructor(newXHTTPServer, newXHTTPClient)
+}
+
+func newXHTTPClient(dialer N.Dialer, serverAddr M.Socksaddr, tlsConfig tls.Config) (v2rayxhttp.HTTP3RoundTripper, error) {
+	stdConfig, err := tlsConfig.STDConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &http3.Transport{
+		Dial: func(ctx context.Context, addr string, tlsCfg *tls.STDConfig, cfg *quic.Config) (*quic.Conn, error) {
+			conn, dialErr := dialer.DialContext(ctx, N.NetworkUDP, serverAddr)
+			if dialErr != nil {
+				return nil, dialErr
+			}
+			quicConn, dialErr := quic.DialEarly(ctx, bufio.NewUnbindPacketConn(conn), conn.RemoteAddr(), tlsCfg, cfg)
+			if dialErr != nil {
+				conn.Close()
+				return nil, dialErr
+			}
+			return quicConn, nil
+		},
+		TLSClientConfig: stdConfig,
+	}, nil
+}
+
+type xhttpServer struct {
+	ctx          context.Context
+	logger       logger.ContextLogger
+	tlsConfig    tls.ServerConfig
+	h3Server     *http3.Server
+	udpListener  net.PacketConn
+	quicListener qtls.EarlyListener
+}
+
+func newXHTTPServer(ctx context.Context, logger logger.ContextLogger, tlsConfig tls.ServerConfig, handler http.Handler) (v2rayxhttp.HTTP3Server, error) {
+	err := qtls.ConfigureHTTP3(tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &xhttpServer{
+		ctx:       ctx,
+		logger:    logger,
+		tlsConfig: tlsConfig,
+		h3Server: &http3.Server{
+			Handler: handler,
+			ConnContext: func(ctx context.Context, conn *quic.Conn) context.Context {
+				return log.ContextWithNewID(ctx)
+			},
+		},
+	}, nil
+}
+
+func (s *xhttpServer) Serve(listener net.PacketConn) error {
+	quicListener, err := qtls.ListenEarly(listener, s.tlsConfig, &quic.Config{
+		MaxIncomingStreams: 1 << 60,
+		Allow0RTT:          true,
+	})
+	if err != nil {
+		return err
+	}
+	s.udpListener = listener
+	s.quicListener = quicListener
+	go func() {
+		sErr := s.h3Server.ServeListener(quicListener)
+		if sErr != nil && !E.IsClosedOrCanceled(sErr) {
+			s.logger.ErrorContext(s.ctx, "xhttp: serve HTTP/3: ", sErr)
+		}
+	}()
+	return nil
+}
+
+func (s *xhttpServer) Close() error {
+	return common.Close(common.PtrOrNil(s.h3Server), s.quicListener, s.udpListener)
+}
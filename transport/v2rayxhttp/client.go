@@ -0,0 +1,333 @@
+package v2rayxhttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/v2rayhttp"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/gofrs/uuid/v5"
+	"golang.org/x/net/http2"
+)
+
+var _ adapter.V2RayClientTransport = (*Client)(nil)
+
+type Client struct {
+	ctx             context.Context
+	config          *config
+	mode            string
+	requestURL      url.URL
+	host            string
+	newTransport    func() (http.RoundTripper, error)
+	transportAccess sync.Mutex
+	transport       http.RoundTripper
+}
+
+func NewClient(ctx context.Context, dialer N.Dialer, serverAddr M.Socksaddr, options option.V2RayXHTTPOptions, tlsConfig tls.Config) (adapter.V2RayClientTransport, error) {
+	config, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	var newTransport func() (http.RoundTripper, error)
+	switch {
+	case tlsConfig == nil:
+		newTransport = func() (http.RoundTripper, error) {
+			return &http.Transport{
+				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					return dialer.DialContext(ctx, network, serverAddr)
+				},
+			}, nil
+		}
+	case isHTTP3(tlsConfig.NextProtos()):
+		newTransport = func() (http.RoundTripper, error) {
+			return newHTTP3Client(dialer, serverAddr, tlsConfig)
+		}
+	case len(tlsConfig.NextProtos()) == 1 && tlsConfig.NextProtos()[0] == "http/1.1":
+		tlsDialer := tls.NewDialer(dialer, tlsConfig)
+		newTransport = func() (http.RoundTripper, error) {
+			return &http.Transport{
+				DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					return tlsDialer.DialTLSContext(ctx, serverAddr)
+				},
+			}, nil
+		}
+	default:
+		if len(tlsConfig.NextProtos()) == 0 {
+			tlsConfig.SetNextProtos([]string{http2.NextProtoTLS})
+		}
+		tlsDialer := tls.NewDialer(dialer, tlsConfig)
+		newTransport = func() (http.RoundTripper, error) {
+			return &http2.Transport{
+				DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.STDConfig) (net.Conn, error) {
+					return tlsDialer.DialTLSContext(ctx, serverAddr)
+				},
+			}, nil
+		}
+	}
+	transport, err := newTransport()
+	if err != nil {
+		return nil, err
+	}
+	mode := config.mode
+	if mode == C.XHTTPModeAuto {
+		mode = C.XHTTPModePacketUp
+	}
+	var host string
+	if config.host != "" {
+		host = config.host
+	} else if tlsConfig != nil && tlsConfig.ServerName() != "" {
+		host = tlsConfig.ServerName()
+	} else {
+		host = serverAddr.String()
+	}
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+	requestURL, err := config.requestURL(scheme, serverAddr.String(), config.path)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		ctx:          ctx,
+		config:       config,
+		mode:         mode,
+		requestURL:   requestURL,
+		host:         host,
+		newTransport: newTransport,
+		transport:    transport,
+	}, nil
+}
+
+func (c *Client) roundTripper() http.RoundTripper {
+	c.transportAccess.Lock()
+	defer c.transportAccess.Unlock()
+	return c.transport
+}
+
+func (c *Client) newRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
+	requestURL := c.requestURL
+	requestURL.Path += path
+	request, err := http.NewRequestWithContext(ctx, method, requestURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	request.Host = c.host
+	request.Header = c.config.requestHeader(requestURL)
+	return request, nil
+}
+
+func (c *Client) roundTrip(request *http.Request) (*http.Response, error) {
+	response, err := c.roundTripper().RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, E.New("xhttp: unexpected status: ", response.Status)
+	}
+	return response, nil
+}
+
+func (c *Client) DialContext(ctx context.Context) (net.Conn, error) {
+	if c.mode == C.XHTTPModeStreamOne {
+		return c.dialStreamOne()
+	}
+	sessionID := uuid.Must(uuid.NewV4()).String()
+	downloadRequest, err := c.newRequest(c.ctx, http.MethodGet, sessionID, nil)
+	if err != nil {
+		return nil, err
+	}
+	var conn *v2rayhttp.HTTP2Conn
+	if c.mode == C.XHTTPModeStreamUp {
+		conn, err = c.dialStreamUp(sessionID)
+	} else {
+		conn = v2rayhttp.NewLateHTTPConn(newPacketUploader(c, sessionID))
+	}
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		response, rErr := c.roundTrip(downloadRequest)
+		if rErr != nil {
+			conn.Setup(nil, rErr)
+		} else {
+			conn.Setup(response.Body, nil)
+		}
+	}()
+	return conn, nil
+}
+
+func (c *Client) dialStreamOne() (net.Conn, error) {
+	pipeReader, pipeWriter := io.Pipe()
+	request, err := c.newRequest(c.ctx, http.MethodPost, "", pipeReader)
+	if err != nil {
+		return nil, err
+	}
+	c.setStreamHeader(request)
+	conn := v2rayhttp.NewLateHTTPConn(pipeWriter)
+	go func() {
+		response, rErr := c.roundTrip(request)
+		if rErr != nil {
+			pipeReader.CloseWithError(rErr)
+			conn.Setup(nil, rErr)
+		} else {
+			conn.Setup(response.Body, nil)
+		}
+	}()
+	return conn, nil
+}
+
+func (c *Client) dialStreamUp(sessionID string) (*v2rayhttp.HTTP2Conn, error) {
+	pipeReader, pipeWriter := io.Pipe()
+	request, err := c.newRequest(c.ctx, http.MethodPost, sessionID, pipeReader)
+	if err != nil {
+		return nil, err
+	}
+	c.setStreamHeader(request)
+	go func() {
+		response, rErr := c.roundTrip(request)
+		if rErr != nil {
+			pipeReader.CloseWithError(rErr)
+			return
+		}
+		// the response only carries keep-alive padding
+		_, _ = io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+	}()
+	return v2rayhttp.NewLateHTTPConn(pipeWriter), nil
+}
+
+func (c *Client) setStreamHeader(request *http.Request) {
+	if !c.config.noGRPCHeader {
+		// some CDNs only stream request bodies of gRPC requests
+		request.Header.Set("Content-Type", "application/grpc")
+	}
+}
+
+func (c *Client) Close() error {
+	c.transportAccess.Lock()
+	defer c.transportAccess.Unlock()
+	if closer, isCloser := c.transport.(HTTP3RoundTripper); isCloser {
+		closer.Close()
+		transport, err := c.newTransport()
+		if err != nil {
+			return err
+		}
+		c.transport = transport
+		return nil
+	}
+	c.transport = v2rayhttp.ResetTransport(c.transport)
+	return nil
+}
+
+// packetUploader batches writes into sequenced POST requests for packet-up mode.
+type packetUploader struct {
+	client    *Client
+	sessionID string
+	access    sync.Mutex
+	cond      *sync.Cond
+	buffer    []byte
+	err       error
+	closed    bool
+}
+
+func newPacketUploader(client *Client, sessionID string) *packetUploader {
+	uploader := &packetUploader{
+		client:    client,
+		sessionID: sessionID,
+	}
+	uploader.cond = sync.NewCond(&uploader.access)
+	go uploader.loop()
+	return uploader
+}
+
+func (u *packetUploader) Write(p []byte) (n int, err error) {
+	u.access.Lock()
+	defer u.access.Unlock()
+	for !u.closed && u.err == nil && len(u.buffer) >= u.client.config.maxEachPostBytes {
+		u.cond.Wait()
+	}
+	if u.err != nil {
+		return 0, u.err
+	}
+	if u.closed {
+		return 0, net.ErrClosed
+	}
+	u.buffer = append(u.buffer, p...)
+	u.cond.Broadcast()
+	return len(p), nil
+}
+
+func (u *packetUploader) loop() {
+	var (
+		seq      uint64
+		lastPost time.Time
+	)
+	for {
+		u.access.Lock()
+		for !u.closed && u.err == nil && len(u.buffer) == 0 {
+			u.cond.Wait()
+		}
+		if u.err != nil || len(u.buffer) == 0 {
+			u.access.Unlock()
+			return
+		}
+		u.access.Unlock()
+		if wait := u.client.config.minPostsInterval - time.Since(lastPost); wait > 0 {
+			time.Sleep(wait)
+		}
+		u.access.Lock()
+		payloadLen := min(len(u.buffer), u.client.config.maxEachPostBytes)
+		payload := common.Dup(u.buffer[:payloadLen])
+		u.buffer = u.buffer[payloadLen:]
+		u.cond.Broadcast()
+		u.access.Unlock()
+		lastPost = time.Now()
+		go u.post(seq, payload)
+		seq++
+	}
+}
+
+func (u *packetUploader) post(seq uint64, payload []byte) {
+	request, err := u.client.newRequest(u.client.ctx, http.MethodPost, u.sessionID+"/"+F.ToString(seq), bytes.NewReader(payload))
+	if err == nil {
+		var response *http.Response
+		response, err = u.client.roundTrip(request)
+		if err == nil {
+			response.Body.Close()
+		}
+	}
+	if err != nil {
+		u.access.Lock()
+		if u.err == nil {
+			u.err = E.Cause(err, "xhttp: upload")
+		}
+		u.cond.Broadcast()
+		u.access.Unlock()
+	}
+}
+
+// Close stops accepting writes, already buffered data is still uploaded.
+func (u *packetUploader) Close() error {
+	u.access.Lock()
+	defer u.access.Unlock()
+	u.closed = true
+	u.cond.Broadcast()
+	return nil
+}
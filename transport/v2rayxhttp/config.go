@@ -0,0 +1,177 @@
+package v2rayxhttp
+
+import (
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	sHTTP "github.com/sagernet/sing/protocol/http"
+)
+
+const (
+	defaultMaxEachPostBytes = 1000000
+	defaultMinPostsInterval = 30 * time.Millisecond
+	defaultMaxBufferedPosts = 30
+	sessionTimeout          = 30 * time.Second
+)
+
+var (
+	defaultPaddingBytes       = xRange{100, 1000}
+	defaultStreamUpServerSecs = xRange{20, 80}
+)
+
+type xRange struct {
+	from int
+	to   int
+}
+
+func parseRange(value string, defaultValue xRange) (xRange, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	fromString, toString, isRange := strings.Cut(value, "-")
+	from, err := strconv.Atoi(strings.TrimSpace(fromString))
+	if err != nil {
+		return xRange{}, E.Cause(err, "parse range: ", value)
+	}
+	to := from
+	if isRange {
+		to, err = strconv.Atoi(strings.TrimSpace(toString))
+		if err != nil {
+			return xRange{}, E.Cause(err, "parse range: ", value)
+		}
+	}
+	if from < 0 || to < from {
+		return xRange{}, E.New("invalid range: ", value)
+	}
+	return xRange{from, to}, nil
+}
+
+func (r xRange) rand() int {
+	if r.to <= r.from {
+		return r.from
+	}
+	return r.from + rand.Intn(r.to-r.from+1)
+}
+
+func (r xRange) contains(value int) bool {
+	return value >= r.from && value <= r.to
+}
+
+type config struct {
+	host               string
+	path               string
+	mode               string
+	headers            http.Header
+	paddingBytes       xRange
+	noGRPCHeader       bool
+	noSSEHeader        bool
+	maxEachPostBytes   int
+	minPostsInterval   time.Duration
+	maxBufferedPosts   int
+	streamUpServerSecs xRange
+}
+
+func newConfig(options option.V2RayXHTTPOptions) (*config, error) {
+	switch options.Mode {
+	case "":
+		options.Mode = C.XHTTPModeAuto
+	case C.XHTTPModeAuto, C.XHTTPModePacketUp, C.XHTTPModeStreamUp, C.XHTTPModeStreamOne:
+	default:
+		return nil, E.New("unknown xhttp mode: ", options.Mode)
+	}
+	paddingBytes, err := parseRange(options.XPaddingBytes, defaultPaddingBytes)
+	if err != nil {
+		return nil, E.Cause(err, "x_padding_bytes")
+	}
+	streamUpServerSecs, err := parseRange(options.StreamUpServerSecs, defaultStreamUpServerSecs)
+	if err != nil {
+		return nil, E.Cause(err, "stream_up_server_secs")
+	}
+	path := options.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	config := &config{
+		host:               options.Host,
+		path:               path,
+		mode:               options.Mode,
+		headers:            options.Headers.Build(),
+		paddingBytes:       paddingBytes,
+		noGRPCHeader:       options.NoGRPCHeader,
+		noSSEHeader:        options.NoSSEHeader,
+		maxEachPostBytes:   int(options.MaxEachPostBytes),
+		minPostsInterval:   time.Duration(options.MinPostsInterval),
+		maxBufferedPosts:   int(options.MaxBufferedPosts),
+		streamUpServerSecs: streamUpServerSecs,
+	}
+	if config.maxEachPostBytes == 0 {
+		config.maxEachPostBytes = defaultMaxEachPostBytes
+	}
+	if config.minPostsInterval == 0 {
+		config.minPostsInterval = defaultMinPostsInterval
+	}
+	if config.maxBufferedPosts == 0 {
+		config.maxBufferedPosts = defaultMaxBufferedPosts
+	}
+	return config, nil
+}
+
+// padding uses 'X' since it keeps its length after HPACK and QPACK huffman encoding.
+func (c *config) padding() string {
+	return strings.Repeat("X", c.paddingBytes.rand())
+}
+
+func (c *config) requestURL(scheme string, host string, path string) (url.URL, error) {
+	var requestURL url.URL
+	requestURL.Scheme = scheme
+	requestURL.Host = host
+	err := sHTTP.URLSetPath(&requestURL, path)
+	if err != nil {
+		return url.URL{}, E.Cause(err, "parse path")
+	}
+	return requestURL, nil
+}
+
+func (c *config) requestHeader(requestURL url.URL) http.Header {
+	header := c.headers.Clone()
+	requestURL.RawQuery = "x_padding=" + c.padding()
+	header.Set("Referer", requestURL.String())
+	return header
+}
+
+func (c *config) requestPaddingLength(request *http.Request) int {
+	referrer := request.Header.Get("Referer")
+	if referrer != "" {
+		referrerURL, err := url.Parse(referrer)
+		if err != nil {
+			return -1
+		}
+		return len(referrerURL.Query().Get("x_padding"))
+	}
+	return len(request.URL.Query().Get("x_padding"))
+}
+
+func (c *config) writeResponseHeader(writer http.ResponseWriter) {
+	for key, values := range c.headers {
+		for _, value := range values {
+			writer.Header().Add(key, value)
+		}
+	}
+	writer.Header().Set("Access-Control-Allow-Origin", "*")
+	writer.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+	writer.Header().Set("X-Padding", c.padding())
+}
+
+func (c *config) allowMode(mode string) bool {
+	return c.mode == C.XHTTPModeAuto || c.mode == mode
+}
@@ -0,0 +1,56 @@
+package v2rayxhttp
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+type HTTP3RoundTripper interface {
+	http.RoundTripper
+	Close() error
+}
+
+type HTTP3Server interface {
+	Serve(listener net.PacketConn) error
+	Close() error
+}
+
+type (
+	HTTP3ClientConstructor func(dialer N.Dialer, serverAddr M.Socksaddr, tlsConfig tls.Config) (HTTP3RoundTripper, error)
+	HTTP3ServerConstructor func(ctx context.Context, logger logger.ContextLogger, tlsConfig tls.ServerConfig, handler http.Handler) (HTTP3Server, error)
+)
+
+var (
+	http3ClientConstructor HTTP3ClientConstructor
+	http3ServerConstructor HTTP3ServerConstructor
+)
+
+func RegisterHTTP3Constructor(server HTTP3ServerConstructor, client HTTP3ClientConstructor) {
+	http3ServerConstructor = server
+	http3ClientConstructor = client
+}
+
+func isHTTP3(nextProtos []string) bool {
+	return len(nextProtos) == 1 && nextProtos[0] == "h3"
+}
+
+func newHTTP3Client(dialer N.Dialer, serverAddr M.Socksaddr, tlsConfig tls.Config) (HTTP3RoundTripper, error) {
+	if http3ClientConstructor == nil {
+		return nil, C.ErrQUICNotIncluded
+	}
+	return http3ClientConstructor(dialer, serverAddr, tlsConfig)
+}
+
+func newHTTP3Server(ctx context.Context, logger logger.ContextLogger, tlsConfig tls.ServerConfig, handler http.Handler) (HTTP3Server, error) {
+	if http3ServerConstructor == nil {
+		return nil, C.ErrQUICNotIncluded
+	}
+	return http3ServerConstructor(ctx, logger, tlsConfig, handler)
+}
@@ -0,0 +1,328 @@
+package v2rayxhttp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/v2rayhttp"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	aTLS "github.com/sagernet/sing/common/tls"
+	sHttp "github.com/sagernet/sing/protocol/http"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+var _ adapter.V2RayServerTransport = (*Server)(nil)
+
+type Server struct {
+	ctx           context.Context
+	logger        logger.ContextLogger
+	tlsConfig     tls.ServerConfig
+	handler       adapter.V2RayServerTransportHandler
+	config        *config
+	httpServer    *http.Server
+	h2cHandler    http.Handler
+	http3Server   HTTP3Server
+	sessionAccess sync.Mutex
+	sessions      map[string]*serverSession
+}
+
+type serverSession struct {
+	uploadQueue *uploadQueue
+	connected   bool
+	timer       *time.Timer
+}
+
+func NewServer(ctx context.Context, logger logger.ContextLogger, options option.V2RayXHTTPOptions, tlsConfig tls.ServerConfig, handler adapter.V2RayServerTransportHandler) (*Server, error) {
+	config, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	server := &Server{
+		ctx:       ctx,
+		logger:    logger,
+		tlsConfig: tlsConfig,
+		handler:   handler,
+		config:    config,
+		sessions:  make(map[string]*serverSession),
+	}
+	if tlsConfig != nil && isHTTP3(tlsConfig.NextProtos()) {
+		server.http3Server, err = newHTTP3Server(ctx, logger, tlsConfig, server)
+		if err != nil {
+			return nil, err
+		}
+		return server, nil
+	}
+	server.httpServer = &http.Server{
+		Handler:           server,
+		ReadHeaderTimeout: C.TCPTimeout,
+		MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+		ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+			return log.ContextWithNewID(ctx)
+		},
+	}
+	server.h2cHandler = h2c.NewHandler(server, &http2.Server{})
+	return server, nil
+}
+
+func (s *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "PRI" && len(request.Header) == 0 && request.URL.Path == "*" && request.Proto == "HTTP/2.0" {
+		s.h2cHandler.ServeHTTP(writer, request)
+		return
+	}
+	if s.config.host != "" && request.Host != s.config.host {
+		s.invalidRequest(writer, request, http.StatusBadRequest, E.New("bad host: ", request.Host))
+		return
+	}
+	if !strings.HasPrefix(request.URL.Path, s.config.path) {
+		s.invalidRequest(writer, request, http.StatusNotFound, E.New("bad path: ", request.URL.Path))
+		return
+	}
+	paddingLength := s.config.requestPaddingLength(request)
+	if !s.config.paddingBytes.contains(paddingLength) {
+		s.invalidRequest(writer, request, http.StatusBadRequest, E.New("invalid padding length: ", paddingLength))
+		return
+	}
+	var sessionID, seq string
+	subPath := strings.Split(request.URL.Path[len(s.config.path):], "/")
+	sessionID = subPath[0]
+	if len(subPath) > 1 {
+		seq = subPath[1]
+	}
+	s.config.writeResponseHeader(writer)
+	switch {
+	case sessionID == "" && request.Method == http.MethodPost:
+		if !s.config.allowMode(C.XHTTPModeStreamOne) {
+			s.invalidRequest(writer, request, http.StatusBadRequest, E.New("stream-one mode is not allowed"))
+			return
+		}
+		s.serveStreamOne(writer, request)
+	case sessionID != "" && request.Method == http.MethodGet:
+		s.serveDownload(writer, request, sessionID)
+	case sessionID != "" && request.Method == http.MethodPost && seq == "":
+		if !s.config.allowMode(C.XHTTPModeStreamUp) {
+			s.invalidRequest(writer, request, http.StatusBadRequest, E.New("stream-up mode is not allowed"))
+			return
+		}
+		s.serveStreamUp(writer, request, sessionID)
+	case sessionID != "" && request.Method == http.MethodPost:
+		if !s.config.allowMode(C.XHTTPModePacketUp) {
+			s.invalidRequest(writer, request, http.StatusBadRequest, E.New("packet-up mode is not allowed"))
+			return
+		}
+		s.servePacketUp(writer, request, sessionID, seq)
+	default:
+		s.invalidRequest(writer, request, http.StatusMethodNotAllowed, E.New("bad request: ", request.Method, " ", request.URL.Path))
+	}
+}
+
+func (s *Server) writeDownloadHeader(writer http.ResponseWriter) {
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.Header().Set("Cache-Control", "no-store")
+	if !s.config.noSSEHeader {
+		writer.Header().Set("Content-Type", "text/event-stream")
+	}
+	writer.WriteHeader(http.StatusOK)
+	writer.(http.Flusher).Flush()
+}
+
+func (s *Server) serveConn(writer http.ResponseWriter, request *http.Request, reader io.Reader) {
+	flusher := writer.(http.Flusher)
+	done := make(chan struct{})
+	conn := v2rayhttp.NewHTTP2Wrapper(&v2rayhttp.ServerHTTPConn{
+		HTTP2Conn: v2rayhttp.NewHTTPConn(reader, writer),
+		Flusher:   flusher,
+	})
+	s.handler.NewConnectionEx(request.Context(), conn, sHttp.SourceAddress(request), M.Socksaddr{}, N.OnceClose(func(it error) {
+		close(done)
+	}))
+	select {
+	case <-done:
+	case <-request.Context().Done():
+	}
+	conn.CloseWrapper()
+}
+
+func (s *Server) serveStreamOne(writer http.ResponseWriter, request *http.Request) {
+	// HTTP/1.1 handlers may not read the request body after writing the response without this
+	_ = http.NewResponseController(writer).EnableFullDuplex()
+	s.writeDownloadHeader(writer)
+	s.serveConn(writer, request, request.Body)
+}
+
+func (s *Server) serveDownload(writer http.ResponseWriter, request *http.Request, sessionID string) {
+	session := s.upsertSession(sessionID)
+	s.sessionAccess.Lock()
+	if session.connected {
+		s.sessionAccess.Unlock()
+		s.invalidRequest(writer, request, http.StatusConflict, E.New("duplicate download for session: ", sessionID))
+		return
+	}
+	session.connected = true
+	session.timer.Stop()
+	s.sessionAccess.Unlock()
+	defer s.removeSession(sessionID)
+	s.writeDownloadHeader(writer)
+	s.serveConn(writer, request, session.uploadQueue)
+}
+
+func (s *Server) serveStreamUp(writer http.ResponseWriter, request *http.Request, sessionID string) {
+	session := s.upsertSession(sessionID)
+	done, err := session.uploadQueue.PushReader(request.Body)
+	if err != nil {
+		s.invalidRequest(writer, request, http.StatusConflict, err)
+		return
+	}
+	// HTTP/1.1 handlers may not read the request body after writing the response without this
+	_ = http.NewResponseController(writer).EnableFullDuplex()
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(http.StatusOK)
+	flusher := writer.(http.Flusher)
+	flusher.Flush()
+	// periodic padding keeps CDNs from closing the idle response while the body is uploading
+	keepAlive := request.Header.Get("Referer") != "" && s.config.streamUpServerSecs.to > 0
+	for {
+		var keepAliveTimer <-chan time.Time
+		if keepAlive {
+			keepAliveTimer = time.After(time.Duration(s.config.streamUpServerSecs.rand()) * time.Second)
+		}
+		select {
+		case <-done:
+			return
+		case <-request.Context().Done():
+			return
+		case <-keepAliveTimer:
+		}
+		_, err = io.WriteString(writer, s.config.padding())
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func (s *Server) servePacketUp(writer http.ResponseWriter, request *http.Request, sessionID string, seqString string) {
+	seq, err := strconv.ParseUint(seqString, 10, 64)
+	if err != nil {
+		s.invalidRequest(writer, request, http.StatusBadRequest, E.Cause(err, "parse seq"))
+		return
+	}
+	payload, err := io.ReadAll(io.LimitReader(request.Body, int64(s.config.maxEachPostBytes)+1))
+	if err != nil {
+		s.invalidRequest(writer, request, http.StatusInternalServerError, E.Cause(err, "read request body"))
+		return
+	}
+	if len(payload) > s.config.maxEachPostBytes {
+		s.invalidRequest(writer, request, http.StatusRequestEntityTooLarge, E.New("too large upload"))
+		return
+	}
+	session := s.upsertSession(sessionID)
+	err = session.uploadQueue.Push(seq, payload)
+	if err != nil {
+		s.invalidRequest(writer, request, http.StatusInternalServerError, E.Cause(err, "push upload"))
+		return
+	}
+	writer.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) upsertSession(sessionID string) *serverSession {
+	s.sessionAccess.Lock()
+	defer s.sessionAccess.Unlock()
+	session, loaded := s.sessions[sessionID]
+	if loaded {
+		return session
+	}
+	session = &serverSession{
+		uploadQueue: newUploadQueue(s.config.maxBufferedPosts),
+	}
+	session.timer = time.AfterFunc(sessionTimeout, func() {
+		s.sessionAccess.Lock()
+		connected := session.connected
+		s.sessionAccess.Unlock()
+		if !connected {
+			s.removeSession(sessionID)
+		}
+	})
+	s.sessions[sessionID] = session
+	return session
+}
+
+func (s *Server) removeSession(sessionID string) {
+	s.sessionAccess.Lock()
+	session, loaded := s.sessions[sessionID]
+	if loaded {
+		delete(s.sessions, sessionID)
+	}
+	s.sessionAccess.Unlock()
+	if loaded {
+		session.uploadQueue.Close()
+	}
+}
+
+func (s *Server) invalidRequest(writer http.ResponseWriter, request *http.Request, statusCode int, err error) {
+	if statusCode > 0 {
+		writer.WriteHeader(statusCode)
+	}
+	s.logger.ErrorContext(request.Context(), E.Cause(err, "process connection from ", request.RemoteAddr))
+}
+
+func (s *Server) Network() []string {
+	if s.http3Server != nil {
+		return []string{N.NetworkUDP}
+	}
+	return []string{N.NetworkTCP}
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	if s.httpServer == nil {
+		return E.New("xhttp: HTTP/3 server does not serve TCP")
+	}
+	if s.tlsConfig != nil {
+		if len(s.tlsConfig.NextProtos()) == 0 {
+			s.tlsConfig.SetNextProtos([]string{http2.NextProtoTLS, "http/1.1"})
+		} else if !common.Contains(s.tlsConfig.NextProtos(), http2.NextProtoTLS) && !common.Contains(s.tlsConfig.NextProtos(), "http/1.1") {
+			s.tlsConfig.SetNextProtos(append([]string{http2.NextProtoTLS}, s.tlsConfig.NextProtos()...))
+		}
+		listener = aTLS.NewListener(listener, s.tlsConfig)
+	}
+	return s.httpServer.Serve(listener)
+}
+
+func (s *Server) ServePacket(listener net.PacketConn) error {
+	if s.http3Server == nil {
+		return E.New("xhttp: HTTP/3 is not enabled")
+	}
+	return s.http3Server.Serve(listener)
+}
+
+func (s *Server) Close() error {
+	s.sessionAccess.Lock()
+	sessions := s.sessions
+	s.sessions = make(map[string]*serverSession)
+	s.sessionAccess.Unlock()
+	for _, session := range sessions {
+		session.timer.Stop()
+		session.uploadQueue.Close()
+	}
+	return common.Close(common.PtrOrNil(s.httpServer), s.http3Server)
+}
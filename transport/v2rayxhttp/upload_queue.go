@@ -0,0 +1,121 @@
+package v2rayxhttp
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// uploadQueue reorders packet-up posts by sequence, or relays a single stream-up body.
+type uploadQueue struct {
+	access     sync.Mutex
+	cond       *sync.Cond
+	packets    map[uint64][]byte
+	current    []byte
+	nextSeq    uint64
+	maxPackets int
+	reader     io.Reader
+	readerDone chan struct{}
+	closed     bool
+}
+
+func newUploadQueue(maxPackets int) *uploadQueue {
+	queue := &uploadQueue{
+		packets:    make(map[uint64][]byte),
+		maxPackets: maxPackets,
+	}
+	queue.cond = sync.NewCond(&queue.access)
+	return queue
+}
+
+func (q *uploadQueue) Push(seq uint64, payload []byte) error {
+	q.access.Lock()
+	defer q.access.Unlock()
+	if q.closed {
+		return net.ErrClosed
+	}
+	if q.reader != nil {
+		return E.New("packet-up post to a stream-up session")
+	}
+	if seq < q.nextSeq {
+		return E.New("duplicate packet: ", seq)
+	}
+	if len(q.packets) >= q.maxPackets {
+		return E.New("too many buffered packets")
+	}
+	q.packets[seq] = payload
+	q.cond.Broadcast()
+	return nil
+}
+
+// PushReader sets the stream-up body and returns a channel closed when the body is drained.
+func (q *uploadQueue) PushReader(reader io.Reader) (<-chan struct{}, error) {
+	q.access.Lock()
+	defer q.access.Unlock()
+	if q.closed {
+		return nil, net.ErrClosed
+	}
+	if q.reader != nil || q.nextSeq > 0 || len(q.packets) > 0 {
+		return nil, E.New("duplicate upload for session")
+	}
+	q.reader = reader
+	q.readerDone = make(chan struct{})
+	q.cond.Broadcast()
+	return q.readerDone, nil
+}
+
+func (q *uploadQueue) Read(p []byte) (n int, err error) {
+	q.access.Lock()
+	for {
+		if q.reader != nil {
+			reader := q.reader
+			q.access.Unlock()
+			n, err = reader.Read(p)
+			if err != nil {
+				q.closeReader()
+			}
+			return
+		}
+		if len(q.current) > 0 {
+			n = copy(p, q.current)
+			q.current = q.current[n:]
+			q.access.Unlock()
+			return
+		}
+		if payload, loaded := q.packets[q.nextSeq]; loaded {
+			delete(q.packets, q.nextSeq)
+			q.nextSeq++
+			q.current = payload
+			continue
+		}
+		if q.closed {
+			q.access.Unlock()
+			return 0, io.EOF
+		}
+		q.cond.Wait()
+	}
+}
+
+func (q *uploadQueue) closeReader() {
+	q.access.Lock()
+	defer q.access.Unlock()
+	if q.readerDone != nil {
+		close(q.readerDone)
+		q.readerDone = nil
+	}
+}
+
+func (q *uploadQueue) Close() error {
+	q.access.Lock()
+	defer q.access.Unlock()
+	q.closed = true
+	q.packets = nil
+	if q.readerDone != nil {
+		close(q.readerDone)
+		q.readerDone = nil
+	}
+	q.cond.Broadcast()
+	return nil
+}